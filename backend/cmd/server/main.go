@@ -1,94 +1,343 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/commands"
 	"jobsity-backend/internal/config"
 	"jobsity-backend/internal/database"
 	"jobsity-backend/internal/handlers"
 	"jobsity-backend/internal/middleware"
+	"jobsity-backend/internal/quotes"
+	"jobsity-backend/internal/ratelimit"
 	"jobsity-backend/internal/repository"
 	"jobsity-backend/internal/service"
+	"jobsity-backend/internal/storage"
+	"jobsity-backend/internal/unfurl"
 	"jobsity-backend/internal/websocket"
+	"jobsity-backend/pkg/domain"
 
 	fiberws "github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/streadway/amqp"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func main() {
+	dev := flag.Bool("dev", false, "keep data and queues in memory instead of MongoDB and RabbitMQ")
+	flag.Parse()
+
+	// "migrate" applies schema migrations and exits instead of serving
+	migrateOnly := flag.Arg(0) == "migrate"
+	if flag.NArg() > 0 && !migrateOnly {
+		log.Fatalf("Unknown command %q", flag.Arg(0))
+	}
+	if migrateOnly && *dev {
+		log.Fatal("migrate needs a database and cannot be used with --dev")
+	}
+
 	// Load configuration
 	cfg := config.Load()
+	rabbitMQConfig := config.LoadRabbitMQConfig()
 
-	// Connect to MongoDB
-	client, err := database.ConnectMongo(database.Config{
-		URI:      cfg.Database.URI,
-		Database: cfg.Database.Database,
+	var (
+		db    *mongo.Database
+		repos repositories
+		queue broker.Broker
+	)
+	if *dev {
+		log.Println("Running in dev mode: data and queues are kept in memory and lost on exit")
+		repos = newMemoryRepositories()
+		queue = broker.NewMemoryBroker()
+
+		// Everything else that could need an external service stays in process
+		cfg.Limits.Store = "memory"
+		cfg.Storage.Backend = "local"
+		cfg.Bot.Embedded = true
+
+		if err := seedDevData(context.Background(), repos); err != nil {
+			log.Fatal("Failed to seed dev data:", err)
+		}
+	} else {
+		if cfg.Events.Delivery != "direct" && cfg.Database.Backend != "mongo" {
+			log.Fatalf("Event delivery %q needs messages stored in MongoDB", cfg.Events.Delivery)
+		}
+
+		client, sqlDB, err := connectDatabases(cfg.Database)
+		if err != nil {
+			log.Fatal("Failed to connect to the database:", err)
+		}
+		defer database.DisconnectMongo(client)
+		defer database.DisconnectPostgres(sqlDB)
+
+		// Get database instance
+		db = client.Database(cfg.Database.Database)
+
+		if migrateOnly || cfg.Database.MigrateOnStart {
+			if err := migrateDatabases(db, sqlDB); err != nil {
+				log.Fatal("Failed to migrate the database:", err)
+			}
+		}
+		if migrateOnly {
+			return
+		}
+
+		repos = newMongoRepositories(db)
+		if sqlDB != nil {
+			repos.useSQL(sqlDB)
+		}
+
+		// Connect to RabbitMQ
+		rabbitMQConn, err := config.ConnectRabbitMQ(rabbitMQConfig)
+		if err != nil {
+			log.Fatal("Failed to connect to RabbitMQ:", err)
+		}
+		queue = rabbitMQConn
+	}
+	defer queue.Close()
+
+	// Setup stock queues
+	queueOptions := broker.ConsumerOptions{
+		Prefetch: int(cfg.Queue.Prefetch),
+		Retry: broker.RetryPolicy{
+			MaxRetries: int(cfg.Queue.MaxRetries),
+			BaseDelay:  cfg.Queue.RetryBaseDelay,
+			MaxDelay:   cfg.Queue.RetryMaxDelay,
+		},
+	}
+	err := queue.DeclareTopology(func(ch *amqp.Channel) error {
+		return config.SetupStockQueue(ch, queueOptions.Retry)
 	})
 	if err != nil {
-		log.Fatal("Failed to connect to MongoDB:", err)
+		log.Fatal("Failed to setup RabbitMQ queues:", err)
 	}
-	defer database.DisconnectMongo(client)
 
-	// Connect to RabbitMQ
-	rabbitMQConfig := config.LoadRabbitMQConfig()
-	rabbitMQConn, err := config.ConnectRabbitMQ(rabbitMQConfig)
+	// Initialize blob storage for uploads
+	blobStore, err := newBlobStore(cfg.Storage)
 	if err != nil {
-		log.Fatal("Failed to connect to RabbitMQ:", err)
+		log.Fatal("Failed to initialize blob storage:", err)
 	}
-	defer rabbitMQConn.Close()
 
-	// Create RabbitMQ channel
-	rabbitMQCh, err := rabbitMQConn.Channel()
+	// Initialize WebSocket hub
+	wsHub := websocket.NewHub()
+	go wsHub.Run()
+
+	// Initialize slash commands
+	commandRegistry := commands.NewRegistry()
+	commandPublisher := service.NewBrokerCommandPublisher(queue, rabbitMQConfig.ConfirmTimeout)
+	commandRegistry.MustRegister(service.NewStockCommand(commandPublisher))
+	commandRegistry.MustRegister(service.NewQuoteCommand(commandPublisher))
+	stockAlertService := service.NewStockAlertService(repos.stockAlerts, cfg.Alerts.MaxPerUser)
+	commandRegistry.MustRegister(service.NewAlertCommand(stockAlertService))
+	commandRegistry.MustRegister(service.NewAlertsCommand(stockAlertService))
+	watchlistService, err := service.NewWatchlistService(repos.watchlists, cfg.Watch.DefaultSchedule, int(cfg.Watch.MaxSymbols))
 	if err != nil {
-		log.Fatal("Failed to create RabbitMQ channel:", err)
+		log.Fatal("Failed to create watchlist service:", err)
 	}
-	defer rabbitMQCh.Close()
+	commandRegistry.MustRegister(service.NewWatchCommand(watchlistService))
+	commandRegistry.MustRegister(service.NewUnwatchCommand(watchlistService))
 
-	// Setup RabbitMQ queues
-	err = config.SetupStockQueue(rabbitMQCh)
+	// Initialize per-user rate limits
+	rateLimiter, err := newRateLimiter(cfg.Limits, db)
 	if err != nil {
-		log.Fatal("Failed to setup RabbitMQ queues:", err)
+		log.Fatal("Failed to initialize rate limits:", err)
 	}
 
-	// Get database instance
-	db := client.Database(cfg.Database.Database)
+	// Initialize WebSocket handler
+	wsHandler := websocket.NewHandler(wsHub, commandRegistry, rateLimiter)
 
-	// Initialize repositories
-	userRepo := repository.NewMongoUserRepository(db.Collection("users"))
-	channelRepo := repository.NewMongoChannelRepository(db.Collection("channels"))
-	messageRepo := repository.NewMongoMessageRepository(db.Collection("messages"))
+	// Initialize services
+	userService := service.NewUserService(repos.users)
+	channelService := service.NewChannelService(repos.channels)
+	baseMessageService := service.NewMessageService(repos.messages, repos.channels, repos.attachments)
 
-	// Initialize WebSocket hub
-	wsHub := websocket.NewHub()
-	go wsHub.Run()
+	// Queue consumers that finish their in-flight deliveries on shutdown
+	var consumers []drainer
 
-	// Initialize WebSocket handler
-	wsHandler := websocket.NewHandler(wsHub)
+	// Broadcast message events from this server, or deliver them to the
+	// clients of every server through the outbox or MongoDB change streams.
+	// Events of the workers and ephemeral replies are sent with broadcast
+	// and sendToUser.
+	var eventMessageService service.MessageService
+	broadcast := wsHandler.BroadcastMessage
+	var sendToUser service.EphemeralSender = wsHandler.SendToUser
+	switch cfg.Events.Delivery {
+	case "direct":
+		eventMessageService = service.NewWebSocketMessageService(baseMessageService, wsHandler)
+	case "outbox":
+		eventSubscriber, err := service.NewEventSubscriber(queue, wsHandler.BroadcastMessage, wsHandler.SendToUser)
+		if err != nil {
+			log.Fatal("Failed to create event subscriber:", err)
+		}
+		defer eventSubscriber.Close()
 
-	// Initialize services
-	userService := service.NewUserService(userRepo)
-	channelService := service.NewChannelService(channelRepo)
-	baseMessageService := service.NewMessageService(messageRepo, channelRepo)
-	messageService := service.NewWebSocketMessageService(baseMessageService, wsHandler)
+		// Subscribe before relaying so this server's clients get every event
+		err = eventSubscriber.Start()
+		if err != nil {
+			log.Fatal("Failed to start event subscriber:", err)
+		}
+		consumers = append(consumers, eventSubscriber)
+
+		outboxRelay := service.NewOutboxRelay(queue, repos.outbox, cfg.Events.RelayInterval)
+		outboxRelay.Start()
+		defer outboxRelay.Close()
 
-	// Initialize stock bot
-	stockBot, err := service.NewStockBot(rabbitMQConn)
+		eventMessageService = service.NewOutboxMessageService(baseMessageService, repos.outbox, repos.transactor, outboxRelay)
+
+		outboxEvents := service.NewOutboxEvents(repos.outbox, outboxRelay)
+		broadcast = outboxEvents.Broadcast
+		sendToUser = outboxEvents.SendToUser
+	case "changestream":
+		if db == nil {
+			log.Fatal("Change stream event delivery needs MongoDB and cannot be used with --dev")
+		}
+
+		watcherName := cfg.Events.WatcherName
+		if watcherName == "" {
+			watcherName, err = os.Hostname()
+			if err != nil {
+				log.Fatal("Failed to name the change stream watcher:", err)
+			}
+		}
+
+		// Every server watches MongoDB, so writes are not broadcast again
+		changeWatcher := database.NewChangeWatcher(db, watcherName, wsHandler.BroadcastMessage)
+		err = changeWatcher.Start()
+		if err != nil {
+			log.Fatal("Failed to start change stream watcher:", err)
+		}
+		defer changeWatcher.Close()
+
+		eventMessageService = baseMessageService
+
+		// Change streams carry only persisted writes, so the events of the
+		// workers and ephemeral replies go through the event exchange
+		eventSubscriber, err := service.NewEventSubscriber(queue, wsHandler.BroadcastMessage, wsHandler.SendToUser)
+		if err != nil {
+			log.Fatal("Failed to create event subscriber:", err)
+		}
+		defer eventSubscriber.Close()
+
+		err = eventSubscriber.Start()
+		if err != nil {
+			log.Fatal("Failed to start event subscriber:", err)
+		}
+		consumers = append(consumers, eventSubscriber)
+
+		brokerEvents := service.NewBrokerEvents(queue)
+		broadcast = brokerEvents.Broadcast
+		sendToUser = brokerEvents.SendToUser
+	default:
+		log.Fatalf("Unknown event delivery %q", cfg.Events.Delivery)
+	}
+	// Initialize link unfurl worker
+	previewFetcher, err := unfurl.NewFetcher(unfurl.FetcherOptions{
+		Timeout:         cfg.Unfurl.Timeout,
+		MaxBytes:        cfg.Unfurl.MaxBytes,
+		AllowedNetworks: cfg.Unfurl.AllowedNetworks,
+	})
+	if err != nil {
+		log.Fatal("Failed to create link preview fetcher:", err)
+	}
+	unfurlWorker, err := service.NewUnfurlWorker(queue, repos.messages, unfurl.NewCachingFetcher(previewFetcher, cfg.Unfurl.CacheTTL, int(cfg.Unfurl.CacheSize)), broadcast)
 	if err != nil {
-		log.Fatal("Failed to create stock bot:", err)
+		log.Fatal("Failed to create unfurl worker:", err)
 	}
-	defer stockBot.Close()
+	defer unfurlWorker.Close()
 
-	// Start stock bot
-	err = stockBot.Start()
+	// Start unfurl worker
+	err = unfurlWorker.Start()
 	if err != nil {
-		log.Fatal("Failed to start stock bot:", err)
+		log.Fatal("Failed to start unfurl worker:", err)
 	}
+	consumers = append(consumers, unfurlWorker)
 
-	// Initialize stock response handler
-	stockResponseHandler, err := service.NewStockResponseHandler(rabbitMQConn, func(channelID string, message []byte) {
-		wsHub.BroadcastToChannel(channelID, message)
+	messageService := service.NewUnfurlMessageService(eventMessageService, unfurlWorker)
+
+	// Initialize thumbnail worker
+	thumbnailWorker, err := service.NewThumbnailWorker(queue, repos.attachments, repos.messages, blobStore, broadcast, queueOptions.Retry)
+	if err != nil {
+		log.Fatal("Failed to create thumbnail worker:", err)
+	}
+	defer thumbnailWorker.Close()
+
+	// Start thumbnail worker
+	err = thumbnailWorker.Start()
+	if err != nil {
+		log.Fatal("Failed to start thumbnail worker:", err)
+	}
+	consumers = append(consumers, thumbnailWorker)
+
+	attachmentService := service.NewAttachmentService(repos.attachments, blobStore, service.NewOpenChannelMembership(repos.channels), thumbnailWorker, service.AttachmentOptions{
+		MaxSize:      cfg.Uploads.MaxSize,
+		AllowedTypes: cfg.Uploads.AllowedTypes,
+		URLSecret:    []byte(cfg.Uploads.URLSecret),
+		URLTTL:       cfg.Uploads.URLTTL,
 	})
+
+	// Start orphaned upload cleanup
+	attachmentCleaner := service.NewAttachmentCleaner(attachmentService, cfg.Uploads.CleanupInterval, cfg.Uploads.OrphanTTL)
+	attachmentCleaner.Start()
+	defer attachmentCleaner.Close()
+
+	// Run the stock bot, price alerts and watchlist digests in process unless
+	// they are deployed as cmd/stockbot
+	var stockHandler *handlers.StockHandler
+	if cfg.Bot.Embedded {
+		// Initialize quote providers, shared by the stock bot, alerts and digests
+		quoteProvider, err := quotes.NewChainByName(cfg.Quotes.Providers, quotes.StooqOptions{
+			BaseURL: cfg.Quotes.StooqURL,
+			Timeout: cfg.Quotes.Timeout,
+		})
+		if err != nil {
+			log.Fatal("Failed to initialize quote providers:", err)
+		}
+		quoteCache := quotes.NewCachingProvider(quoteProvider, quotes.CacheOptions{
+			TTL:         cfg.Quotes.CacheTTL,
+			NegativeTTL: cfg.Quotes.NegativeCacheTTL,
+			MaxEntries:  int(cfg.Quotes.CacheSize),
+		})
+		stockHandler = handlers.NewStockHandler(quoteCache)
+
+		botOptions := queueOptions
+		botOptions.Consumers = int(cfg.Bot.Consumers)
+		stockBot, err := service.NewStockBot(queue, quoteCache, botOptions)
+		if err != nil {
+			log.Fatal("Failed to create stock bot:", err)
+		}
+		defer stockBot.Close()
+
+		// Start stock bot
+		err = stockBot.Start()
+		if err != nil {
+			log.Fatal("Failed to start stock bot:", err)
+		}
+		consumers = append(consumers, stockBot)
+
+		// Start stock price alert polling
+		stockAlertScheduler := service.NewStockAlertScheduler(repos.stockAlerts, quoteCache, queue, cfg.Alerts.PollInterval)
+		stockAlertScheduler.Start()
+		defer stockAlertScheduler.Close()
+
+		// Start watchlist digests
+		watchlistScheduler := service.NewWatchlistScheduler(repos.watchlists, quoteCache, queue, cfg.Watch.CheckInterval, cfg.Watch.CatchUpWindow)
+		watchlistScheduler.Start()
+		defer watchlistScheduler.Close()
+	}
+
+	// Initialize stock response handler
+	stockResponseHandler, err := service.NewStockResponseHandler(queue, messageService, sendToUser, queueOptions)
 	if err != nil {
 		log.Fatal("Failed to create stock response handler:", err)
 	}
@@ -99,15 +348,31 @@ func main() {
 	if err != nil {
 		log.Fatal("Failed to start stock response handler:", err)
 	}
+	consumers = append(consumers, stockResponseHandler)
+
+	// Initialize dead letter inspection for the retried queues
+	deadLetterService, err := service.NewDeadLetterService(queue, []string{"stock_commands", "stock_responses", "attachment_processing"})
+	if err != nil {
+		log.Fatal("Failed to create dead letter service:", err)
+	}
 
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(userService)
 	channelHandler := handlers.NewChannelHandler(channelService)
-	messageHandler := handlers.NewMessageHandler(messageService, rabbitMQCh)
+	messageHandler := handlers.NewMessageHandler(messageService, commandRegistry, sendToUser, rateLimiter)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
+	adminHandler := handlers.NewAdminHandler(deadLetterService)
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
 		AppName: "Jobsity Backend API v1.0.0",
+		// Leave room for multipart overhead on top of the largest allowed upload
+		BodyLimit: int(cfg.Uploads.MaxSize) + 1<<20,
+		// Take client IPs from the proxy header only when sent by a trusted proxy
+		ProxyHeader:             cfg.Server.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.Server.TrustedProxies,
+		EnableIPValidation:      true,
 	})
 
 	// Middleware
@@ -116,8 +381,10 @@ func main() {
 	// Health check route
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
-			"status":  "ok",
-			"message": "Server is running",
+			"status":   "ok",
+			"message":  "Server is running",
+			"rabbitmq": queue.IsConnected(),
+			"dev":      *dev,
 		})
 	})
 
@@ -125,7 +392,7 @@ func main() {
 	api := app.Group("/api/v1")
 
 	// User routes
-	api.Post("/login", userHandler.Login)
+	api.Post("/login", middleware.LoginRateLimitMiddleware(rateLimiter), userHandler.Login)
 	api.Post("/users", userHandler.CreateUser)
 	api.Get("/users/:email", userHandler.GetUser)
 
@@ -144,11 +411,231 @@ func main() {
 	api.Put("/messages/:id", middleware.AuthMiddleware(), messageHandler.UpdateMessage)
 	api.Delete("/messages/:id", middleware.AuthMiddleware(), messageHandler.DeleteMessage)
 
+	// Attachment routes
+	api.Post("/uploads", middleware.AuthMiddleware(), middleware.RateLimitMiddleware(rateLimiter, ratelimit.ActionUpload), attachmentHandler.Upload)
+	api.Get("/attachments/:id/url", middleware.AuthMiddleware(), attachmentHandler.GetDownloadURL)
+	api.Get("/attachments/:id/download", attachmentHandler.Download)
+
+	// Stock bot routes; a standalone bot reports its cache on its health endpoint
+	if stockHandler != nil {
+		api.Get("/stock/stats", stockHandler.GetStats)
+	}
+
+	// Admin routes
+	admin := api.Group("/admin", middleware.AuthMiddleware(), middleware.AdminMiddleware(cfg.Admin.Emails))
+	admin.Get("/dead-letters/:queue", adminHandler.GetDeadLetters)
+	admin.Post("/dead-letters/:queue/requeue", adminHandler.RequeueDeadLetters)
+
 	// WebSocket routes
 	api.Get("/ws", fiberws.New(wsHandler.HandleWebSocket))
 	api.Get("/ws/stats", wsHandler.GetStats())
 
-	// Start server
-	log.Printf("Server starting on :%s", cfg.Server.Port)
-	log.Fatal(app.Listen(":" + cfg.Server.Port))
+	// Serve until SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		log.Printf("Server starting on :%s", cfg.Server.Port)
+		if err := app.Listen(":" + cfg.Server.Port); err != nil {
+			log.Printf("Server stopped: %v", err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("Shutting down server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	shutdownServer(shutdownCtx, app, wsHandler, consumers, cfg.Server.ReconnectDelay)
+	wsHub.Stop()
+
+	// The deferred calls stop the workers and close the broker and databases
+	log.Printf("Server stopped")
+}
+
+// drainer is a queue consumer that can stop once the deliveries it is
+// handling have finished
+type drainer interface {
+	Shutdown(ctx context.Context) error
+}
+
+// shutdownServer stops accepting connections, tells WebSocket clients to
+// reconnect after reconnectDelay and waits until ctx expires for in-flight
+// HTTP requests and then queue deliveries to finish
+func shutdownServer(ctx context.Context, app *fiber.App, wsHandler *websocket.Handler, consumers []drainer, reconnectDelay time.Duration) {
+	// Closing the listener first keeps clients from reconnecting here
+	httpDone := make(chan error, 1)
+	go func() {
+		httpDone <- app.ShutdownWithContext(ctx)
+	}()
+
+	if err := wsHandler.Shutdown(ctx, reconnectDelay); err != nil {
+		log.Printf("WebSocket clients did not disconnect: %v", err)
+	}
+	if err := <-httpDone; err != nil {
+		log.Printf("In-flight requests did not finish: %v", err)
+	}
+
+	// Drain the workers once requests can no longer queue jobs for them
+	for _, consumer := range consumers {
+		if err := consumer.Shutdown(ctx); err != nil {
+			log.Printf("Queue consumer did not finish in-flight deliveries: %v", err)
+		}
+	}
+}
+
+// connectDatabases connects to MongoDB and, when it stores users, channels and
+// messages, PostgreSQL. The PostgreSQL pool is nil otherwise.
+func connectDatabases(cfg config.DatabaseConfig) (*mongo.Client, *sql.DB, error) {
+	if cfg.Backend != "mongo" && cfg.Backend != "postgres" {
+		return nil, nil, fmt.Errorf("unknown database backend %q", cfg.Backend)
+	}
+
+	client, err := database.ConnectMongo(database.Config{
+		URI:      cfg.URI,
+		Database: cfg.Database,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("MongoDB: %w", err)
+	}
+	if cfg.Backend != "postgres" {
+		return client, nil, nil
+	}
+
+	sqlDB, err := database.ConnectPostgres(cfg.PostgresURL)
+	if err != nil {
+		database.DisconnectMongo(client)
+		return nil, nil, fmt.Errorf("PostgreSQL: %w", err)
+	}
+	return client, sqlDB, nil
+}
+
+// migrateDatabases applies the schema migrations of MongoDB and, if sqlDB is
+// not nil, PostgreSQL
+func migrateDatabases(db *mongo.Database, sqlDB *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if err := database.MigrateMongo(ctx, db); err != nil {
+		return fmt.Errorf("MongoDB: %w", err)
+	}
+	if sqlDB != nil {
+		if err := database.MigrateSQL(ctx, sqlDB); err != nil {
+			return fmt.Errorf("PostgreSQL: %w", err)
+		}
+	}
+	return nil
+}
+
+// repositories holds the repository of each collection
+type repositories struct {
+	users       repository.UserRepository
+	channels    repository.ChannelRepository
+	messages    repository.MessageRepository
+	attachments repository.AttachmentRepository
+	stockAlerts repository.StockAlertRepository
+	watchlists  repository.WatchlistRepository
+	outbox      repository.OutboxRepository
+	transactor  repository.Transactor
+}
+
+// newMongoRepositories creates repositories backed by the collections of db
+func newMongoRepositories(db *mongo.Database) repositories {
+	return repositories{
+		users:       repository.NewMongoUserRepository(db.Collection("users")),
+		channels:    repository.NewMongoChannelRepository(db.Collection("channels")),
+		messages:    repository.NewMongoMessageRepository(db.Collection("messages")),
+		attachments: repository.NewMongoAttachmentRepository(db.Collection("attachments")),
+		stockAlerts: repository.NewMongoStockAlertRepository(db.Collection("stock_alerts")),
+		watchlists:  repository.NewMongoWatchlistRepository(db.Collection("watchlists")),
+		outbox:      repository.NewMongoOutboxRepository(db.Collection("outbox")),
+		transactor:  repository.NewMongoTransactor(db.Client()),
+	}
+}
+
+// useSQL moves users, channels and messages to the SQL database db
+func (r *repositories) useSQL(db *sql.DB) {
+	r.users = repository.NewSQLUserRepository(db)
+	r.channels = repository.NewSQLChannelRepository(db)
+	r.messages = repository.NewSQLMessageRepository(db)
+}
+
+// newMemoryRepositories creates empty in-memory repositories for dev mode
+func newMemoryRepositories() repositories {
+	return repositories{
+		users:       repository.NewMemoryUserRepository(),
+		channels:    repository.NewMemoryChannelRepository(),
+		messages:    repository.NewMemoryMessageRepository(),
+		attachments: repository.NewMemoryAttachmentRepository(),
+		stockAlerts: repository.NewMemoryStockAlertRepository(),
+		watchlists:  repository.NewMemoryWatchlistRepository(),
+		outbox:      repository.NewMemoryOutboxRepository(),
+		transactor:  repository.NewMemoryTransactor(),
+	}
+}
+
+// seedDevData creates the users and channels that db/init-mongo.js creates
+// for Docker Compose, so dev mode can be logged into straight away
+func seedDevData(ctx context.Context, repos repositories) error {
+	for _, email := range []string{"user1@jobsity.com", "user2@jobsity.com"} {
+		err := repos.users.Create(ctx, &domain.User{Email: email, Password: "password"})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range []string{"channel-1", "channel-2"} {
+		err := repos.channels.Create(ctx, &domain.Channel{
+			Name:        name,
+			Description: "Channel " + strings.TrimPrefix(name, "channel-"),
+			CreatedBy:   "user1@jobsity.com",
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newRateLimiter creates the per-user rate limiter with the store selected by
+// the configuration
+func newRateLimiter(cfg config.RateLimitConfig, db *mongo.Database) (*ratelimit.Limiter, error) {
+	limits := map[ratelimit.Action]ratelimit.Limit{
+		ratelimit.ActionPost:    cfg.Post,
+		ratelimit.ActionCommand: cfg.Command,
+		ratelimit.ActionUpload:  cfg.Upload,
+		ratelimit.ActionLogin:   cfg.Login,
+	}
+
+	switch cfg.Store {
+	case "mongo":
+		store := ratelimit.NewMongoStore(db.Collection("rate_limits"))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := store.EnsureIndexes(ctx); err != nil {
+			return nil, err
+		}
+		return ratelimit.NewLimiter(store, limits), nil
+	case "memory":
+		return ratelimit.NewLimiter(ratelimit.NewMemoryStore(), limits), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", cfg.Store)
+	}
+}
+
+// newBlobStore creates the blob store selected by the storage configuration
+func newBlobStore(cfg config.StorageConfig) (storage.BlobStore, error) {
+	switch cfg.Backend {
+	case "s3":
+		return storage.NewS3BlobStore(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		})
+	default:
+		return storage.NewLocalBlobStore(cfg.LocalDir)
+	}
 }
@@ -0,0 +1,133 @@
+// Command stockbot answers /stock and /quote commands from the stock_commands
+// queue, separately from the chat server. It also checks price alerts and
+// posts watchlist digests. Run as many instances as needed; they compete for
+// commands on the shared queue and claim alerts and digests in MongoDB.
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/config"
+	"jobsity-backend/internal/database"
+	"jobsity-backend/internal/quotes"
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func main() {
+	// Load configuration
+	cfg := config.LoadStockBot()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Connect to RabbitMQ
+	rabbitMQConn, err := config.ConnectRabbitMQ(config.LoadRabbitMQConfig())
+	if err != nil {
+		log.Fatal("Failed to connect to RabbitMQ:", err)
+	}
+	defer rabbitMQConn.Close()
+
+	// Connect to MongoDB for price alerts and watchlists
+	client, err := database.ConnectMongo(database.Config{
+		URI:      cfg.Database.URI,
+		Database: cfg.Database.Database,
+	})
+	if err != nil {
+		log.Fatal("Failed to connect to MongoDB:", err)
+	}
+	defer database.DisconnectMongo(client)
+	db := client.Database(cfg.Database.Database)
+
+	// Initialize quote providers
+	quoteProvider, err := quotes.NewChainByName(cfg.Quotes.Providers, quotes.StooqOptions{
+		BaseURL: cfg.Quotes.StooqURL,
+		Timeout: cfg.Quotes.Timeout,
+	})
+	if err != nil {
+		log.Fatal("Failed to initialize quote providers:", err)
+	}
+	quoteCache := quotes.NewCachingProvider(quoteProvider, quotes.CacheOptions{
+		TTL:         cfg.Quotes.CacheTTL,
+		NegativeTTL: cfg.Quotes.NegativeCacheTTL,
+		MaxEntries:  int(cfg.Quotes.CacheSize),
+	})
+
+	// Start stock bot
+	stockBot, err := service.NewStockBot(rabbitMQConn, quoteCache, broker.ConsumerOptions{
+		Prefetch:  int(cfg.Queue.Prefetch),
+		Consumers: int(cfg.Consumers),
+		Retry: broker.RetryPolicy{
+			MaxRetries: int(cfg.Queue.MaxRetries),
+			BaseDelay:  cfg.Queue.RetryBaseDelay,
+			MaxDelay:   cfg.Queue.RetryMaxDelay,
+		},
+	})
+	if err != nil {
+		log.Fatal("Failed to create stock bot:", err)
+	}
+	err = stockBot.Start()
+	if err != nil {
+		log.Fatal("Failed to start stock bot:", err)
+	}
+
+	// Start stock price alert polling
+	stockAlertScheduler := service.NewStockAlertScheduler(repository.NewMongoStockAlertRepository(db.Collection("stock_alerts")), quoteCache, rabbitMQConn, cfg.Alerts.PollInterval)
+	stockAlertScheduler.Start()
+	defer stockAlertScheduler.Close()
+
+	// Start watchlist digests
+	watchlistScheduler := service.NewWatchlistScheduler(repository.NewMongoWatchlistRepository(db.Collection("watchlists")), quoteCache, rabbitMQConn, cfg.Watch.CheckInterval, cfg.Watch.CatchUpWindow)
+	watchlistScheduler.Start()
+	defer watchlistScheduler.Close()
+
+	// Health endpoint
+	app := fiber.New(fiber.Config{
+		AppName:               "Jobsity Stock Bot",
+		DisableStartupMessage: true,
+	})
+	app.Get("/health", func(c *fiber.Ctx) error {
+		// Without the broker no commands can be answered
+		connected := rabbitMQConn.IsConnected()
+		code, status := fiber.StatusOK, "ok"
+		if !connected {
+			code, status = fiber.StatusServiceUnavailable, "unavailable"
+		}
+		return c.Status(code).JSON(fiber.Map{
+			"status":      status,
+			"rabbitmq":    connected,
+			"consumers":   stockBot.Consumers(),
+			"provider":    quoteCache.Name(),
+			"quote_cache": quoteCache.Stats(),
+		})
+	})
+
+	go func() {
+		log.Printf("Stock bot running %d consumers, health on :%s", stockBot.Consumers(), cfg.HealthPort)
+		if err := app.Listen(":" + cfg.HealthPort); err != nil {
+			log.Printf("Health endpoint stopped: %v", err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("Shutting down stock bot")
+
+	// Let commands being looked up finish; the rest go back to the queue
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := stockBot.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Stock bot did not finish in-flight commands: %v", err)
+	}
+	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
+		log.Printf("Failed to stop health endpoint: %v", err)
+	}
+	log.Printf("Stock bot stopped")
+}
@@ -0,0 +1,49 @@
+package broker
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+)
+
+// Broker is what workers need from a message broker. Connection implements it
+// on RabbitMQ and MemoryBroker in process memory.
+type Broker interface {
+	Publisher
+
+	// PublishConfirmed publishes a mandatory message and waits until the
+	// broker has accepted it, or ctx expires
+	PublishConfirmed(ctx context.Context, exchange, key string, msg amqp.Publishing) error
+
+	// DeclareTopology declares exchanges and queues with fn
+	DeclareTopology(fn TopologyFunc) error
+
+	// Consume registers handler for deliveries from queue; deliveries must be
+	// acknowledged by the handler
+	Consume(queue string, prefetch int, handler func(amqp.Delivery)) *Consumer
+
+	// Subscribe registers handler for every message published to a fanout
+	// exchange, on a queue of its own; deliveries must be acknowledged by
+	// the handler
+	Subscribe(exchange string, handler func(amqp.Delivery)) (*Consumer, error)
+
+	// Channel opens a channel for reading queues directly; the caller must
+	// close it
+	Channel() (Channel, error)
+
+	// IsConnected reports whether the broker is currently reachable
+	IsConnected() bool
+
+	Close() error
+}
+
+// Channel is the subset of *amqp.Channel used to read and republish messages
+// outside of a consumer
+type Channel interface {
+	Publisher
+
+	// Get takes the next message from queue, reporting false if it is empty
+	Get(queue string, autoAck bool) (amqp.Delivery, bool, error)
+
+	Close() error
+}
@@ -0,0 +1,162 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+var (
+	// ErrNotConfirmed is returned when the broker refuses (nacks) a publish
+	ErrNotConfirmed = errors.New("message was not confirmed by the broker")
+
+	// ErrUnroutable is returned when a mandatory publish matches no queue
+	ErrUnroutable = errors.New("message could not be routed to a queue")
+)
+
+// confirmChannel is a channel in confirm mode with its notification channels
+type confirmChannel struct {
+	ch       *amqp.Channel
+	confirms chan amqp.Confirmation
+	returns  chan amqp.Return
+}
+
+// confirmPublisher serialises confirmed publishes on a single channel so each
+// confirmation can be matched to its publish
+type confirmPublisher struct {
+	mutex   sync.Mutex
+	current *confirmChannel
+}
+
+// PublishConfirmed publishes a mandatory message and waits until the broker
+// has confirmed it, or ctx expires. Unroutable messages fail with
+// ErrUnroutable and nacked ones with ErrNotConfirmed.
+func (c *Connection) PublishConfirmed(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+	c.confirms.mutex.Lock()
+	defer c.confirms.mutex.Unlock()
+
+	cc, err := c.confirmChannel(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = cc.ch.Publish(exchange, key, true, false, msg)
+	if err != nil {
+		c.discardConfirmChannel()
+		if errors.Is(err, amqp.ErrClosed) {
+			return fmt.Errorf("%w: %v", ErrDisconnected, err)
+		}
+		return err
+	}
+
+	err = AwaitConfirmation(ctx, cc.confirms, cc.returns)
+	if errors.Is(err, ErrDisconnected) || ctx.Err() != nil {
+		// The channel is gone, or a late confirmation would be matched to
+		// the next publish
+		c.discardConfirmChannel()
+	}
+	return err
+}
+
+// AwaitConfirmation waits for the confirmation of a mandatory publish on a
+// channel in confirm mode, given the channel's publish and return
+// notifications. It fails with ErrUnroutable if the message was returned,
+// ErrNotConfirmed if it was nacked and ErrDisconnected if the channel closed
+// first.
+func AwaitConfirmation(ctx context.Context, confirms <-chan amqp.Confirmation, returns <-chan amqp.Return) error {
+	select {
+	case returned, ok := <-returns:
+		// The notifications are closed together when the channel closes
+		if !ok {
+			return ErrDisconnected
+		}
+		// A return is always followed by the confirmation of the same message
+		select {
+		case <-confirms:
+		case <-ctx.Done():
+		}
+		return fmt.Errorf("%w: %s", ErrUnroutable, returned.ReplyText)
+
+	case confirmation, ok := <-confirms:
+		if !ok {
+			return ErrDisconnected
+		}
+		if !confirmation.Ack {
+			return ErrNotConfirmed
+		}
+		// Returns are dispatched before the ack, so one would already be waiting
+		select {
+		case returned, ok := <-returns:
+			if ok {
+				return fmt.Errorf("%w: %s", ErrUnroutable, returned.ReplyText)
+			}
+		default:
+		}
+		return nil
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// confirmChannel returns the channel used for confirmed publishes, opening it if needed
+func (c *Connection) confirmChannel(ctx context.Context) (*confirmChannel, error) {
+	if c.confirms.current != nil {
+		return c.confirms.current, nil
+	}
+
+	c.mutex.Lock()
+	connected := c.conn != nil
+	c.mutex.Unlock()
+	if !connected && !c.options.BlockPublishers {
+		return nil, ErrDisconnected
+	}
+
+	conn, err := c.connection(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, ErrDisconnected
+		}
+		return nil, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDisconnected, err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	cc := &confirmChannel{
+		ch:       ch,
+		confirms: ch.NotifyPublish(make(chan amqp.Confirmation, 1)),
+		returns:  ch.NotifyReturn(make(chan amqp.Return, 1)),
+	}
+
+	// Forget the channel as soon as it dies so the next publish reopens it
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		<-closed
+		c.confirms.mutex.Lock()
+		if c.confirms.current == cc {
+			c.confirms.current = nil
+		}
+		c.confirms.mutex.Unlock()
+	}()
+
+	c.confirms.current = cc
+	return cc, nil
+}
+
+// discardConfirmChannel closes the confirm channel after it got out of step
+func (c *Connection) discardConfirmChannel() {
+	if c.confirms.current != nil {
+		c.confirms.current.ch.Close()
+		c.confirms.current = nil
+	}
+}
@@ -0,0 +1,473 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+var (
+	// ErrDisconnected is returned by publishers while the broker is unreachable
+	ErrDisconnected = errors.New("message broker is unavailable, please try again later")
+
+	// ErrConnectionClosed is returned after Close has been called
+	ErrConnectionClosed = errors.New("message broker connection is closed")
+)
+
+// ConnectionOptions configures reconnection and publishing behaviour
+type ConnectionOptions struct {
+	ReconnectMinDelay time.Duration
+	ReconnectMaxDelay time.Duration
+
+	// BlockPublishers makes Publish wait up to PublishTimeout for the broker
+	// to come back instead of failing immediately with ErrDisconnected
+	BlockPublishers bool
+	PublishTimeout  time.Duration
+}
+
+// TopologyFunc declares exchanges and queues on a fresh channel
+type TopologyFunc func(ch *amqp.Channel) error
+
+// Connection is a RabbitMQ connection that survives broker restarts. When the
+// connection drops it reconnects with exponential backoff, re-declares every
+// registered topology and re-registers every consumer.
+type Connection struct {
+	url     string
+	options ConnectionOptions
+
+	mutex    sync.Mutex
+	conn     *amqp.Connection
+	pubCh    *amqp.Channel
+	topology []TopologyFunc
+	confirms confirmPublisher
+
+	// ready is closed while connected and replaced when the connection drops
+	ready chan struct{}
+	done  chan struct{}
+}
+
+// Dial connects to RabbitMQ. The first connection attempt must succeed; later
+// failures are recovered from in the background.
+func Dial(url string, options ConnectionOptions) (*Connection, error) {
+	if options.ReconnectMinDelay <= 0 {
+		options.ReconnectMinDelay = time.Second
+	}
+	if options.ReconnectMaxDelay < options.ReconnectMinDelay {
+		options.ReconnectMaxDelay = options.ReconnectMinDelay
+	}
+
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Connection{
+		url:     url,
+		options: options,
+		ready:   make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	c.setConnected(conn)
+	return c, nil
+}
+
+// IsConnected reports whether the broker is currently reachable
+func (c *Connection) IsConnected() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.conn != nil
+}
+
+// DeclareTopology runs fn now and again after every reconnect
+func (c *Connection) DeclareTopology(fn TopologyFunc) error {
+	c.mutex.Lock()
+	c.topology = append(c.topology, fn)
+	conn := c.conn
+	c.mutex.Unlock()
+
+	if conn == nil {
+		// Declared once the connection is back
+		return nil
+	}
+	return runTopology(conn, []TopologyFunc{fn})
+}
+
+// Channel opens a new channel, failing with ErrDisconnected while the broker
+// is unreachable. The caller owns the channel and must close it.
+func (c *Connection) Channel() (Channel, error) {
+	c.mutex.Lock()
+	conn := c.conn
+	c.mutex.Unlock()
+
+	if conn == nil {
+		return nil, ErrDisconnected
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// Publish publishes a message on a shared channel. It has the same signature
+// as (*amqp.Channel).Publish so the connection can be used as a Publisher.
+func (c *Connection) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	ctx := context.Background()
+	if c.options.BlockPublishers {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.options.PublishTimeout)
+		defer cancel()
+	}
+
+	ch, err := c.publishChannel(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = ch.Publish(exchange, key, mandatory, immediate, msg)
+	if err != nil {
+		c.discardPublishChannel(ch)
+		if errors.Is(err, amqp.ErrClosed) {
+			return fmt.Errorf("%w: %v", ErrDisconnected, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// Consume registers handler for deliveries from queue. Deliveries must be
+// acknowledged by the handler. The consumer is re-registered whenever its
+// channel or the connection is lost, until it is cancelled.
+func (c *Connection) Consume(queue string, prefetch int, handler func(amqp.Delivery)) *Consumer {
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer := &Consumer{cancel: cancel}
+	go c.consume(ctx, consumer, queue, prefetch, handler)
+	return consumer
+}
+
+// Subscribe declares a subscription queue bound to the fanout exchange, again
+// after every reconnect, and consumes it with handler
+func (c *Connection) Subscribe(exchange string, handler func(amqp.Delivery)) (*Consumer, error) {
+	queue := SubscriptionQueueName(exchange)
+	err := c.DeclareTopology(func(ch *amqp.Channel) error {
+		return DeclareSubscription(ch, exchange, queue)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.Consume(queue, subscriptionPrefetch, handler), nil
+}
+
+// Close closes the connection and stops reconnecting
+func (c *Connection) Close() error {
+	c.mutex.Lock()
+	select {
+	case <-c.done:
+		c.mutex.Unlock()
+		return nil
+	default:
+	}
+	close(c.done)
+	conn := c.conn
+	c.conn = nil
+	c.pubCh = nil
+	c.mutex.Unlock()
+
+	if conn != nil {
+		return conn.Close()
+	}
+	return nil
+}
+
+// connection returns the live connection, waiting for a reconnect if needed
+func (c *Connection) connection(ctx context.Context) (*amqp.Connection, error) {
+	for {
+		c.mutex.Lock()
+		conn, ready := c.conn, c.ready
+		c.mutex.Unlock()
+
+		if conn != nil {
+			return conn, nil
+		}
+
+		select {
+		case <-ready:
+		case <-c.done:
+			return nil, ErrConnectionClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// publishChannel returns the shared publishing channel, opening it if needed
+func (c *Connection) publishChannel(ctx context.Context) (*amqp.Channel, error) {
+	c.mutex.Lock()
+	if c.conn == nil && !c.options.BlockPublishers {
+		c.mutex.Unlock()
+		return nil, ErrDisconnected
+	}
+	c.mutex.Unlock()
+
+	conn, err := c.connection(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, ErrDisconnected
+		}
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.pubCh != nil {
+		return c.pubCh, nil
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDisconnected, err)
+	}
+	c.pubCh = ch
+	return ch, nil
+}
+
+// discardPublishChannel forgets a publishing channel that failed so the next
+// publish opens a fresh one
+func (c *Connection) discardPublishChannel(ch *amqp.Channel) {
+	c.mutex.Lock()
+	if c.pubCh == ch {
+		c.pubCh = nil
+	}
+	c.mutex.Unlock()
+	ch.Close()
+}
+
+// setConnected installs a live connection and starts watching it
+func (c *Connection) setConnected(conn *amqp.Connection) {
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+	c.mutex.Lock()
+	c.conn = conn
+	c.pubCh = nil
+	close(c.ready)
+	c.mutex.Unlock()
+
+	go c.watch(closed)
+}
+
+// watch waits for the connection to drop and then reconnects
+func (c *Connection) watch(closed chan *amqp.Error) {
+	reason := <-closed
+
+	c.mutex.Lock()
+	select {
+	case <-c.done:
+		c.mutex.Unlock()
+		return
+	default:
+	}
+	c.conn = nil
+	c.pubCh = nil
+	c.ready = make(chan struct{})
+	c.mutex.Unlock()
+
+	log.Printf("RabbitMQ connection lost: %v", reason)
+	c.reconnect()
+}
+
+// reconnect dials until it succeeds or the connection is closed
+func (c *Connection) reconnect() {
+	delay := c.options.ReconnectMinDelay
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-c.done:
+			return
+		case <-time.After(delay):
+		}
+
+		conn, err := amqp.Dial(c.url)
+		if err == nil {
+			c.mutex.Lock()
+			topology := append([]TopologyFunc(nil), c.topology...)
+			c.mutex.Unlock()
+
+			err = runTopology(conn, topology)
+			if err != nil {
+				conn.Close()
+			}
+		}
+		if err != nil {
+			log.Printf("RabbitMQ reconnect attempt %d failed: %v", attempt, err)
+			delay *= 2
+			if delay > c.options.ReconnectMaxDelay {
+				delay = c.options.ReconnectMaxDelay
+			}
+			continue
+		}
+
+		log.Printf("RabbitMQ connection restored after %d attempt(s)", attempt)
+		c.setConnected(conn)
+		return
+	}
+}
+
+// consume keeps a consumer registered across channel and connection failures
+func (c *Connection) consume(ctx context.Context, consumer *Consumer, queue string, prefetch int, handler func(amqp.Delivery)) {
+	for {
+		conn, err := c.connection(ctx)
+		if err != nil {
+			return
+		}
+
+		msgs, err := consumer.open(conn, queue, prefetch)
+		if err != nil {
+			log.Printf("Failed to register a consumer for %s: %v", queue, err)
+		} else {
+			for msg := range msgs {
+				if !consumer.begin() {
+					// Draining: the delivery is requeued when the channel closes
+					break
+				}
+				handler(msg)
+				consumer.active.Done()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.done:
+			return
+		case <-time.After(c.options.ReconnectMinDelay):
+			log.Printf("Re-registering consumer for %s", queue)
+		}
+	}
+}
+
+// runTopology declares every topology function on a temporary channel
+func runTopology(conn *amqp.Connection, topology []TopologyFunc) error {
+	if len(topology) == 0 {
+		return nil
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	for _, fn := range topology {
+		if err := fn(ch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Consumer is a queue subscription registered with Connection.Consume
+type Consumer struct {
+	mutex     sync.Mutex
+	ch        *amqp.Channel
+	cancel    context.CancelFunc
+	cancelled bool
+	draining  bool
+
+	// active counts deliveries being handled
+	active sync.WaitGroup
+}
+
+// open starts consuming on a new channel
+func (c *Consumer) open(conn *amqp.Connection, queue string, prefetch int) (<-chan amqp.Delivery, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	if prefetch > 0 {
+		if err := ch.Qos(prefetch, 0, false); err != nil {
+			ch.Close()
+			return nil, err
+		}
+	}
+
+	msgs, err := ch.Consume(
+		queue, // queue
+		"",    // consumer
+		false, // auto-ack
+		false, // exclusive
+		false, // no-local
+		false, // no-wait
+		nil,   // args
+	)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.cancelled {
+		ch.Close()
+		return nil, context.Canceled
+	}
+	c.ch = ch
+	return msgs, nil
+}
+
+// begin marks the start of handling a delivery, reporting false once the
+// consumer is draining
+func (c *Consumer) begin() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.draining || c.cancelled {
+		return false
+	}
+	c.active.Add(1)
+	return true
+}
+
+// Drain stops handling new deliveries, waits for the one being handled to
+// finish and then cancels the consumer. Prefetched deliveries that were not
+// handled are returned to the queue. If ctx expires first the consumer is
+// cancelled anyway and ctx's error is returned.
+func (c *Consumer) Drain(ctx context.Context) error {
+	c.mutex.Lock()
+	c.draining = true
+	c.mutex.Unlock()
+	defer c.Cancel()
+
+	idle := make(chan struct{})
+	go func() {
+		c.active.Wait()
+		close(idle)
+	}()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Cancel stops the consumer. Deliveries that were not yet acknowledged are
+// returned to the queue by the broker.
+func (c *Consumer) Cancel() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.cancelled {
+		return
+	}
+	c.cancelled = true
+	c.cancel()
+	if c.ch != nil {
+		c.ch.Close()
+	}
+}
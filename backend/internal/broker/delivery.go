@@ -0,0 +1,123 @@
+package broker
+
+import (
+	"errors"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// Headers added to messages as they are retried and dead-lettered
+const (
+	RetryCountHeader     = "x-retry-count"
+	ErrorHeader          = "x-error"
+	DeadLetteredAtHeader = "x-dead-lettered-at"
+)
+
+// Publisher is the subset of *amqp.Channel used to re-route deliveries
+type Publisher interface {
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks err as not worth retrying; the delivery is dead-lettered
+// straight away
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// RetryCount returns how many times a delivery has already been retried
+func RetryCount(msg amqp.Delivery) int {
+	switch count := msg.Headers[RetryCountHeader].(type) {
+	case int32:
+		return int(count)
+	case int64:
+		return int(count)
+	case int:
+		return count
+	}
+	return 0
+}
+
+// IsLastAttempt reports whether a failure of this delivery will be
+// dead-lettered rather than retried
+func IsLastAttempt(msg amqp.Delivery, policy RetryPolicy) bool {
+	return RetryCount(msg) >= policy.MaxRetries
+}
+
+// Settle acknowledges a delivery consumed from queue according to the outcome
+// of processing it. Successful deliveries are acked; failures are republished
+// to the next delay queue until the policy is exhausted, after which (or
+// immediately, for permanent errors) they are moved to the dead-letter queue.
+func Settle(pub Publisher, queue string, msg amqp.Delivery, policy RetryPolicy, processErr error) error {
+	if processErr == nil {
+		return msg.Ack(false)
+	}
+
+	attempt := RetryCount(msg) + 1
+	if !IsPermanent(processErr) && attempt <= policy.MaxRetries {
+		retry := republish(msg)
+		retry.Headers[RetryCountHeader] = int32(attempt)
+
+		err := pub.Publish("", RetryQueueName(queue, policy.Delay(attempt)), false, false, retry)
+		if err != nil {
+			// Let the broker redeliver it rather than losing it
+			msg.Nack(false, true)
+			return err
+		}
+		return msg.Ack(false)
+	}
+
+	deadLetter := republish(msg)
+	deadLetter.Headers[ErrorHeader] = processErr.Error()
+	deadLetter.Headers[DeadLetteredAtHeader] = time.Now().UTC().Format(time.RFC3339)
+
+	err := pub.Publish(DeadLetterExchange, queue, false, false, deadLetter)
+	if err != nil {
+		// Let the broker redeliver it; rejecting it would drop it from work
+		// queues that DeadLetterPolicy does not apply to
+		msg.Nack(false, true)
+		return err
+	}
+	return msg.Ack(false)
+}
+
+// republish copies a delivery into a persistent publishing
+func republish(msg amqp.Delivery) amqp.Publishing {
+	headers := amqp.Table{}
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+
+	return amqp.Publishing{
+		Headers:       headers,
+		ContentType:   msg.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: msg.CorrelationId,
+		MessageId:     msg.MessageId,
+		Timestamp:     msg.Timestamp,
+		Type:          msg.Type,
+		Body:          msg.Body,
+	}
+}
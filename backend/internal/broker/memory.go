@@ -0,0 +1,286 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// memoryQueueSize is how many messages an in-memory queue holds before
+// publishes to it fail
+const memoryQueueSize = 10000
+
+// MemoryBroker is an in-process Broker for development and tests. It follows
+// the routing set up by DeclareQueue without needing it declared: the default
+// exchange routes to the queue named by the key, retry queue names deliver to
+// their work queue once their delay has passed, and DeadLetterExchange routes
+// to the dead-letter queue of the key. Any other exchange is a fanout exchange
+// routing to the queues of its subscribers. Queues are created on first use
+// and messages do not survive a restart.
+type MemoryBroker struct {
+	mutex    sync.Mutex
+	queues   map[string]chan amqp.Delivery
+	bindings map[string][]string
+	tag      uint64
+	done     chan struct{}
+}
+
+// NewMemoryBroker creates an in-process broker with no queues
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		queues:   make(map[string]chan amqp.Delivery),
+		bindings: make(map[string][]string),
+		done:     make(chan struct{}),
+	}
+}
+
+// Publish routes a message to its queue
+func (b *MemoryBroker) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	switch exchange {
+	case "":
+		if queue, delay, ok := parseRetryQueueName(key); ok {
+			// Stands in for the delay queue's TTL and dead-letter routing
+			time.AfterFunc(delay, func() {
+				b.deliver(queue, msg, false)
+			})
+			return nil
+		}
+		return b.deliver(key, msg, false)
+	case DeadLetterExchange:
+		return b.deliver(DeadLetterQueueName(key), msg, false)
+	default:
+		b.mutex.Lock()
+		queues := b.bindings[exchange]
+		b.mutex.Unlock()
+
+		if len(queues) == 0 {
+			return fmt.Errorf("%w: exchange %q has no subscribers", ErrUnroutable, exchange)
+		}
+		for _, queue := range queues {
+			if err := b.deliver(queue, msg, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// PublishConfirmed publishes a message; in memory a publish is confirmed as
+// soon as it is queued
+func (b *MemoryBroker) PublishConfirmed(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return b.Publish(exchange, key, true, false, msg)
+}
+
+// DeclareTopology does nothing; queues are created as they are used
+func (b *MemoryBroker) DeclareTopology(fn TopologyFunc) error {
+	return nil
+}
+
+// Consume registers handler for deliveries from queue. Deliveries must be
+// acknowledged by the handler; nacked ones are requeued or dead-lettered.
+func (b *MemoryBroker) Consume(queue string, prefetch int, handler func(amqp.Delivery)) *Consumer {
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer := &Consumer{cancel: cancel}
+	msgs := b.queue(queue)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-b.done:
+				return
+			case msg := <-msgs:
+				if !consumer.begin() {
+					// Draining or cancelled: give the delivery back
+					msg.Nack(false, true)
+					return
+				}
+				handler(msg)
+				consumer.active.Done()
+			}
+		}
+	}()
+	return consumer
+}
+
+// Subscribe binds a new queue to the fanout exchange and consumes it with
+// handler
+func (b *MemoryBroker) Subscribe(exchange string, handler func(amqp.Delivery)) (*Consumer, error) {
+	queue := SubscriptionQueueName(exchange)
+
+	b.mutex.Lock()
+	b.bindings[exchange] = append(b.bindings[exchange], queue)
+	b.mutex.Unlock()
+
+	return b.Consume(queue, subscriptionPrefetch, handler), nil
+}
+
+// Channel returns a channel reading from and publishing to the broker
+func (b *MemoryBroker) Channel() (Channel, error) {
+	if !b.IsConnected() {
+		return nil, ErrConnectionClosed
+	}
+	return &memoryChannel{broker: b}, nil
+}
+
+// IsConnected reports whether the broker has not been closed
+func (b *MemoryBroker) IsConnected() bool {
+	select {
+	case <-b.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Close stops every consumer; queued messages are discarded
+func (b *MemoryBroker) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.IsConnected() {
+		close(b.done)
+	}
+	return nil
+}
+
+// Len returns the number of messages waiting in queue
+func (b *MemoryBroker) Len(queue string) int {
+	return len(b.queue(queue))
+}
+
+// queue returns the named queue, creating it if needed
+func (b *MemoryBroker) queue(name string) chan amqp.Delivery {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	msgs, ok := b.queues[name]
+	if !ok {
+		msgs = make(chan amqp.Delivery, memoryQueueSize)
+		b.queues[name] = msgs
+	}
+	return msgs
+}
+
+// deliver adds a message to the end of queue
+func (b *MemoryBroker) deliver(queue string, msg amqp.Publishing, redelivered bool) error {
+	if !b.IsConnected() {
+		return ErrConnectionClosed
+	}
+
+	msgs := b.queue(queue)
+	delivery := amqp.Delivery{
+		Acknowledger:    &memoryAcknowledger{broker: b, queue: queue, msg: msg},
+		Headers:         msg.Headers,
+		ContentType:     msg.ContentType,
+		ContentEncoding: msg.ContentEncoding,
+		DeliveryMode:    msg.DeliveryMode,
+		Priority:        msg.Priority,
+		CorrelationId:   msg.CorrelationId,
+		ReplyTo:         msg.ReplyTo,
+		Expiration:      msg.Expiration,
+		MessageId:       msg.MessageId,
+		Timestamp:       msg.Timestamp,
+		Type:            msg.Type,
+		UserId:          msg.UserId,
+		AppId:           msg.AppId,
+		DeliveryTag:     atomic.AddUint64(&b.tag, 1),
+		Redelivered:     redelivered,
+		RoutingKey:      queue,
+		Body:            msg.Body,
+	}
+
+	select {
+	case msgs <- delivery:
+		return nil
+	default:
+		return fmt.Errorf("in-memory queue %s is full", queue)
+	}
+}
+
+// memoryAcknowledger settles one in-memory delivery
+type memoryAcknowledger struct {
+	broker  *MemoryBroker
+	queue   string
+	msg     amqp.Publishing
+	settled atomic.Bool
+}
+
+// Ack drops the delivery
+func (a *memoryAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.settled.Store(true)
+	return nil
+}
+
+// Nack requeues the delivery, or dead-letters it like a work queue under
+// DeadLetterPolicy
+func (a *memoryAcknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	if a.settled.Swap(true) {
+		return nil
+	}
+	if requeue {
+		return a.broker.deliver(a.queue, a.msg, true)
+	}
+	if strings.HasSuffix(a.queue, ".dead") {
+		return nil
+	}
+	return a.broker.deliver(DeadLetterQueueName(a.queue), a.msg, false)
+}
+
+// Reject is Nack for a single delivery
+func (a *memoryAcknowledger) Reject(tag uint64, requeue bool) error {
+	return a.Nack(tag, false, requeue)
+}
+
+// memoryChannel reads queues of a MemoryBroker directly
+type memoryChannel struct {
+	broker *MemoryBroker
+}
+
+// Publish routes a message like MemoryBroker.Publish
+func (c *memoryChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	return c.broker.Publish(exchange, key, mandatory, immediate, msg)
+}
+
+// Get takes the next message from queue without waiting
+func (c *memoryChannel) Get(queue string, autoAck bool) (amqp.Delivery, bool, error) {
+	select {
+	case msg := <-c.broker.queue(queue):
+		if autoAck {
+			msg.Ack(false)
+		}
+		return msg, true, nil
+	default:
+		return amqp.Delivery{}, false, nil
+	}
+}
+
+// Close does nothing; in-memory channels hold no resources
+func (c *memoryChannel) Close() error {
+	return nil
+}
+
+// parseRetryQueueName splits a name made by RetryQueueName into its work
+// queue and delay
+func parseRetryQueueName(name string) (string, time.Duration, bool) {
+	i := strings.LastIndex(name, ".retry.")
+	if i < 0 || !strings.HasSuffix(name, "ms") {
+		return "", 0, false
+	}
+
+	millis, err := strconv.ParseInt(name[i+len(".retry."):len(name)-len("ms")], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return name[:i], time.Duration(millis) * time.Millisecond, true
+}
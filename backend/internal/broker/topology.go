@@ -0,0 +1,201 @@
+package broker
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// DeadLetterExchange receives messages that could not be processed. Each work
+// queue's dead letters are routed, by queue name, to DeadLetterQueueName(queue).
+const DeadLetterExchange = "dead_letters"
+
+// DeadLetterPolicy is the definition of the RabbitMQ policy that dead-letters
+// messages rejected from work queues, for example with
+//
+//	rabbitmqctl set_policy --apply-to queues dead-letters "^stock_(commands|responses)$" '{"dead-letter-exchange":"dead_letters"}'
+//
+// Work queues are declared without x-dead-letter arguments because RabbitMQ
+// refuses to redeclare an existing queue with different arguments, while a
+// policy applies to queues that already exist. Rejected messages keep their
+// routing key, the queue name, so they reach the queue's dead-letter queue.
+const DeadLetterPolicy = `{"dead-letter-exchange":"` + DeadLetterExchange + `"}`
+
+// RetryPolicy controls how failed deliveries are retried before being dead-lettered
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// Delay returns the backoff before retry attempt (starting at 1), doubling
+// from BaseDelay and capped at MaxDelay
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+const (
+	// subscriptionExpiry is how long a subscription queue outlives its
+	// consumer, so a subscriber that reconnects quickly does not miss messages
+	subscriptionExpiry = time.Minute
+
+	// subscriptionPrefetch limits the unacknowledged deliveries of a subscriber
+	subscriptionPrefetch = 50
+)
+
+// ConsumerOptions configures how a worker consumes its queue
+type ConsumerOptions struct {
+	// Prefetch limits the number of unacknowledged deliveries per consumer
+	Prefetch int
+	Retry    RetryPolicy
+
+	// Consumers is the number of competing consumers a worker registers;
+	// zero means one
+	Consumers int
+}
+
+// DeadLetterQueueName returns the queue holding dead letters for queue
+func DeadLetterQueueName(queue string) string {
+	return queue + ".dead"
+}
+
+// RetryQueueName returns the delay queue used for a retry after delay. The
+// delay is part of the name because RabbitMQ refuses to redeclare a queue
+// with a different TTL.
+func RetryQueueName(queue string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%dms", queue, delay.Milliseconds())
+}
+
+// DeclareQueue declares a durable work queue together with its dead-letter
+// queue and one TTL delay queue per retry attempt. Expired retries are routed
+// back to the work queue through the default exchange. Settle publishes dead
+// letters itself; messages rejected without requeueing are only dead-lettered
+// by the broker once DeadLetterPolicy is applied.
+func DeclareQueue(ch *amqp.Channel, queue string, policy RetryPolicy) error {
+	// Declare the shared dead-letter exchange
+	err := ch.ExchangeDeclare(
+		DeadLetterExchange, // name
+		"direct",           // type
+		true,               // durable
+		false,              // auto-deleted
+		false,              // internal
+		false,              // no-wait
+		nil,                // arguments
+	)
+	if err != nil {
+		return err
+	}
+
+	// Declare the dead-letter queue and bind it by the work queue name
+	deadLetterQueue := DeadLetterQueueName(queue)
+	_, err = ch.QueueDeclare(
+		deadLetterQueue, // name
+		true,            // durable
+		false,           // delete when unused
+		false,           // exclusive
+		false,           // no-wait
+		nil,             // arguments
+	)
+	if err != nil {
+		return err
+	}
+	err = ch.QueueBind(deadLetterQueue, queue, DeadLetterExchange, false, nil)
+	if err != nil {
+		return err
+	}
+
+	// Declare the work queue as earlier versions did, so existing queues
+	// are redeclared without PRECONDITION_FAILED
+	_, err = ch.QueueDeclare(
+		queue, // name
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		nil,   // arguments
+	)
+	if err != nil {
+		return err
+	}
+
+	// Declare one delay queue per retry attempt
+	for attempt := 1; attempt <= policy.MaxRetries; attempt++ {
+		delay := policy.Delay(attempt)
+		_, err = ch.QueueDeclare(
+			RetryQueueName(queue, delay), // name
+			true,                         // durable
+			false,                        // delete when unused
+			false,                        // exclusive
+			false,                        // no-wait
+			amqp.Table{
+				"x-message-ttl":             int64(delay / time.Millisecond),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": queue,
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SubscriptionQueueName returns a new queue name for one subscriber of a
+// fanout exchange
+func SubscriptionQueueName(exchange string) string {
+	suffix := make([]byte, 8)
+	rand.Read(suffix)
+	return exchange + ".sub." + hex.EncodeToString(suffix)
+}
+
+// DeclareSubscription declares a durable fanout exchange and binds a
+// subscriber's queue to it. The queue is not durable and is deleted once it
+// has had no consumer for subscriptionExpiry.
+func DeclareSubscription(ch *amqp.Channel, exchange, queue string) error {
+	err := ch.ExchangeDeclare(
+		exchange, // name
+		"fanout", // type
+		true,     // durable
+		false,    // auto-deleted
+		false,    // internal
+		false,    // no-wait
+		nil,      // arguments
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = ch.QueueDeclare(
+		queue, // name
+		false, // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		amqp.Table{
+			"x-expires": int64(subscriptionExpiry / time.Millisecond),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return ch.QueueBind(queue, "", exchange, false, nil)
+}
@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnknownCommand is returned when no command is registered under the invoked name
+	ErrUnknownCommand = errors.New("unknown command")
+
+	// ErrNotCommand is returned when the content is not a slash command
+	ErrNotCommand = errors.New("content is not a command")
+
+	// ErrNotEnqueued is returned by async commands whose work could not be
+	// durably handed off
+	ErrNotEnqueued = errors.New("command could not be queued, please try again later")
+)
+
+// HandlerFunc executes a parsed command invocation
+type HandlerFunc func(ctx context.Context, inv *Invocation) (*Result, error)
+
+// Arg describes a single positional argument of a command
+type Arg struct {
+	Name     string
+	Required bool
+
+	// Variadic arguments swallow all remaining words and must be last
+	Variadic bool
+}
+
+// Command is a slash command that can be registered with a Registry
+type Command struct {
+	Name        string
+	Args        []Arg
+	Description string
+
+	// Async commands hand the work off (e.g. to RabbitMQ) and reply later
+	// through the channel; their Result only acknowledges the request
+	Async bool
+
+	Handler HandlerFunc
+}
+
+// Invocation is a parsed command call
+type Invocation struct {
+	Name      string
+	Args      []string
+	Raw       string
+	ChannelID string
+	UserEmail string
+}
+
+// Arg returns the positional argument at index i, or "" when it was omitted
+func (inv *Invocation) Arg(i int) string {
+	if i < 0 || i >= len(inv.Args) {
+		return ""
+	}
+	return inv.Args[i]
+}
+
+// Result is the reply returned to the user who invoked a command
+type Result struct {
+	Message string `json:"message"`
+	Async   bool   `json:"async"`
+}
+
+// UsageError is returned when a command is invoked with arguments that do not
+// match its grammar
+type UsageError struct {
+	Command *Command
+	Reason  string
+}
+
+func (e *UsageError) Error() string {
+	return fmt.Sprintf("%s (usage: %s)", e.Reason, e.Command.Usage())
+}
+
+// ExecutionError wraps a failure returned by a command handler
+type ExecutionError struct {
+	Command *Command
+	Err     error
+}
+
+func (e *ExecutionError) Error() string {
+	return fmt.Sprintf("failed to process %s command: %v", e.Command.Name, e.Err)
+}
+
+func (e *ExecutionError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorMessage returns the text shown to the user for a failed command.
+// Handler failures are summarised so internal errors are not leaked.
+func ErrorMessage(err error) string {
+	if errors.Is(err, ErrNotEnqueued) {
+		return ErrNotEnqueued.Error()
+	}
+	var execErr *ExecutionError
+	if errors.As(err, &execErr) {
+		return "Failed to process " + execErr.Command.Name + " command"
+	}
+	return err.Error()
+}
+
+// Usage returns the command grammar, e.g. "/stock <stock_code>"
+func (c *Command) Usage() string {
+	var b strings.Builder
+	b.WriteString("/")
+	b.WriteString(c.Name)
+	for _, arg := range c.Args {
+		name := arg.Name
+		if arg.Variadic {
+			name += "..."
+		}
+		if arg.Required {
+			fmt.Fprintf(&b, " <%s>", name)
+		} else {
+			fmt.Fprintf(&b, " [%s]", name)
+		}
+	}
+	return b.String()
+}
+
+// validateArgs checks the invocation arguments against the command grammar
+func (c *Command) validateArgs(args []string) error {
+	variadic := len(c.Args) > 0 && c.Args[len(c.Args)-1].Variadic
+	for i, arg := range c.Args {
+		if arg.Required && i >= len(args) {
+			return &UsageError{Command: c, Reason: arg.Name + " is required"}
+		}
+	}
+	if !variadic && len(args) > len(c.Args) {
+		return &UsageError{Command: c, Reason: "too many arguments"}
+	}
+	return nil
+}
+
+// validate checks that the command definition itself is well formed
+func (c *Command) validate() error {
+	if !isValidName(c.Name) {
+		return fmt.Errorf("invalid command name %q", c.Name)
+	}
+	if c.Handler == nil {
+		return fmt.Errorf("command %q has no handler", c.Name)
+	}
+	optional := false
+	for i, arg := range c.Args {
+		if arg.Variadic && i != len(c.Args)-1 {
+			return fmt.Errorf("command %q: variadic argument %q must be last", c.Name, arg.Name)
+		}
+		if arg.Required && optional {
+			return fmt.Errorf("command %q: required argument %q follows an optional one", c.Name, arg.Name)
+		}
+		if !arg.Required {
+			optional = true
+		}
+	}
+	return nil
+}
+
+// isValidName reports whether name can be used as a command name
+func isValidName(name string) bool {
+	if name == "" || len(name) > 32 {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry holds the slash commands available to chat users
+type Registry struct {
+	mutex    sync.RWMutex
+	commands map[string]*Command
+}
+
+// NewRegistry creates a registry with the built-in /help command registered
+func NewRegistry() *Registry {
+	registry := &Registry{
+		commands: make(map[string]*Command),
+	}
+	registry.MustRegister(registry.helpCommand())
+	return registry
+}
+
+// Register adds a command to the registry
+func (r *Registry) Register(cmd *Command) error {
+	if err := cmd.validate(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.commands[cmd.Name]; exists {
+		return fmt.Errorf("command %q is already registered", cmd.Name)
+	}
+	r.commands[cmd.Name] = cmd
+	return nil
+}
+
+// MustRegister adds a command to the registry and panics on failure
+func (r *Registry) MustRegister(cmd *Command) {
+	if err := r.Register(cmd); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the command registered under name
+func (r *Registry) Lookup(name string) (*Command, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	cmd, ok := r.commands[strings.ToLower(name)]
+	return cmd, ok
+}
+
+// Commands returns all registered commands sorted by name
+func (r *Registry) Commands() []*Command {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	commands := make([]*Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+	return commands
+}
+
+// IsCommand reports whether content should be handled as a slash command
+// rather than posted as a chat message
+func (r *Registry) IsCommand(content string) bool {
+	_, _, ok := Parse(content)
+	return ok
+}
+
+// Execute parses content and runs the matching command on behalf of userEmail
+func (r *Registry) Execute(ctx context.Context, channelID, userEmail, content string) (*Result, error) {
+	name, args, ok := Parse(content)
+	if !ok {
+		return nil, ErrNotCommand
+	}
+
+	cmd, exists := r.Lookup(name)
+	if !exists {
+		return nil, fmt.Errorf("%w /%s, type /help to list available commands", ErrUnknownCommand, name)
+	}
+
+	if err := cmd.validateArgs(args); err != nil {
+		return nil, err
+	}
+
+	result, err := cmd.Handler(ctx, &Invocation{
+		Name:      name,
+		Args:      args,
+		Raw:       strings.TrimSpace(content),
+		ChannelID: channelID,
+		UserEmail: userEmail,
+	})
+	if err != nil {
+		// Handlers may reject their input with the same errors as the registry
+		var usageErr *UsageError
+		if errors.Is(err, ErrUnknownCommand) || errors.As(err, &usageErr) {
+			return nil, err
+		}
+		return nil, &ExecutionError{Command: cmd, Err: err}
+	}
+	if result == nil {
+		result = &Result{}
+	}
+	result.Async = cmd.Async
+	return result, nil
+}
+
+// Parse splits a slash command into its name and arguments. Both "/name arg"
+// and the legacy "/name=arg" forms are accepted. Content whose first word is
+// not a valid command name (e.g. "/usr/bin") is not treated as a command.
+func Parse(content string) (string, []string, bool) {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "/") {
+		return "", nil, false
+	}
+
+	rest := content[1:]
+	end := strings.IndexFunc(rest, func(r rune) bool {
+		return r == '=' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	name, argText := rest, ""
+	if end >= 0 {
+		name, argText = rest[:end], rest[end+1:]
+	}
+
+	name = strings.ToLower(name)
+	if !isValidName(name) {
+		return "", nil, false
+	}
+	return name, strings.Fields(argText), true
+}
+
+// helpCommand builds the /help command listing everything in the registry
+func (r *Registry) helpCommand() *Command {
+	return &Command{
+		Name:        "help",
+		Args:        []Arg{{Name: "command"}},
+		Description: "List available commands or show help for one command",
+		Handler: func(ctx context.Context, inv *Invocation) (*Result, error) {
+			if name := strings.TrimPrefix(inv.Arg(0), "/"); name != "" {
+				cmd, exists := r.Lookup(name)
+				if !exists {
+					return nil, fmt.Errorf("%w /%s", ErrUnknownCommand, name)
+				}
+				return &Result{Message: fmt.Sprintf("%s - %s", cmd.Usage(), cmd.Description)}, nil
+			}
+
+			lines := []string{"Available commands:"}
+			for _, cmd := range r.Commands() {
+				lines = append(lines, fmt.Sprintf("%s - %s", cmd.Usage(), cmd.Description))
+			}
+			return &Result{Message: strings.Join(lines, "\n")}, nil
+		},
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+import "time"
+
+// StockBotConfig holds configuration for the standalone stock bot (cmd/stockbot)
+type StockBotConfig struct {
+	HealthPort      string
+	Consumers       int64 // competing consumers on stock_commands
+	ShutdownTimeout time.Duration
+	Queue           QueueConfig
+	Quotes          QuoteConfig
+
+	// Price alerts and watchlists are read from MongoDB; users, channels and
+	// messages are not needed
+	Database DatabaseConfig
+	Alerts   AlertConfig
+	Watch    WatchConfig
+}
+
+// LoadStockBot loads the stock bot configuration from environment variables
+func LoadStockBot() *StockBotConfig {
+	return &StockBotConfig{
+		HealthPort:      getEnv("STOCKBOT_HEALTH_PORT", "3002"),
+		Consumers:       getEnvInt64("STOCKBOT_CONSUMERS", 1),
+		ShutdownTimeout: getEnvDuration("STOCKBOT_SHUTDOWN_TIMEOUT", 30*time.Second),
+		Queue:           loadQueueConfig(),
+		Quotes:          loadQuoteConfig(),
+		Database:        loadDatabaseConfig(),
+		Alerts:          loadAlertConfig(),
+		Watch:           loadWatchConfig(),
+	}
+}
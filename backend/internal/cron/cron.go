@@ -0,0 +1,202 @@
+// Package cron parses standard five-field cron expressions and computes
+// when they next fire.
+//
+// Fields are minute (0-59), hour (0-23), day of month (1-31), month (1-12)
+// and day of week (0-6, Sunday is 0 or 7). Each field accepts *, single
+// values, ranges (1-5), lists (1,3,5) and steps (*/15, 9-17/2). As in
+// classic cron, when both day fields are restricted a day matches if either
+// does. The descriptors @hourly, @daily, @weekly and @monthly are accepted.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// descriptors maps the supported @ shorthands to their expressions
+var descriptors = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// maxYears bounds the search for the next run of schedules such as Feb 30
+const maxYears = 5
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	expr    string
+	minutes uint64
+	hours   uint64
+	doms    uint64
+	months  uint64
+	dows    uint64
+
+	// domAny and dowAny record unrestricted day fields, which decide
+	// whether the two day fields are combined with AND or OR
+	domAny bool
+	dowAny bool
+}
+
+// fieldRange is the allowed range of a field
+type fieldRange struct {
+	name     string
+	min, max int
+}
+
+var (
+	minuteRange = fieldRange{"minute", 0, 59}
+	hourRange   = fieldRange{"hour", 0, 23}
+	domRange    = fieldRange{"day of month", 1, 31}
+	monthRange  = fieldRange{"month", 1, 12}
+	dowRange    = fieldRange{"day of week", 0, 7}
+)
+
+// Parse parses a five-field cron expression or an @ descriptor
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	spec := expr
+	if descriptor, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+
+	s := &Schedule{expr: expr}
+	var err error
+	if s.minutes, _, err = parseField(fields[0], minuteRange); err != nil {
+		return nil, err
+	}
+	if s.hours, _, err = parseField(fields[1], hourRange); err != nil {
+		return nil, err
+	}
+	if s.doms, s.domAny, err = parseField(fields[2], domRange); err != nil {
+		return nil, err
+	}
+	if s.months, _, err = parseField(fields[3], monthRange); err != nil {
+		return nil, err
+	}
+	if s.dows, s.dowAny, err = parseField(fields[4], dowRange); err != nil {
+		return nil, err
+	}
+
+	// Sunday may be written as 7
+	if s.dows&(1<<7) != 0 {
+		s.dows |= 1
+	}
+	return s, nil
+}
+
+// String returns the expression the schedule was parsed from
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first time after t at which the schedule fires, in t's
+// location, or the zero time if it never fires
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxYears, 0, 0)
+
+	for t.Before(limit) {
+		if s.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchesDay reports whether the day fields allow t's date
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dom := s.doms&(1<<uint(t.Day())) != 0
+	dow := s.dows&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// parseField parses one comma-separated field into a bit set, reporting
+// whether the field was an unrestricted *
+func parseField(field string, r fieldRange) (uint64, bool, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		partBits, err := parsePart(part, r)
+		if err != nil {
+			return 0, false, err
+		}
+		bits |= partBits
+	}
+	return bits, field == "*", nil
+}
+
+// parsePart parses a single value, range or step of a field
+func parsePart(part string, r fieldRange) (uint64, error) {
+	rangeText, stepText, hasStep := strings.Cut(part, "/")
+
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepText)
+		if err != nil || step <= 0 {
+			return 0, fmt.Errorf("invalid step %q in %s field", stepText, r.name)
+		}
+	}
+
+	low, high := r.min, r.max
+	if rangeText != "*" {
+		lowText, highText, isRange := strings.Cut(rangeText, "-")
+		var err error
+		low, err = parseValue(lowText, r)
+		if err != nil {
+			return 0, err
+		}
+		high = low
+		if isRange {
+			if high, err = parseValue(highText, r); err != nil {
+				return 0, err
+			}
+			if high < low {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangeText, r.name)
+			}
+		} else if hasStep {
+			// "5/15" means every 15 starting at 5
+			high = r.max
+		}
+	}
+
+	var bits uint64
+	for v := low; v <= high; v += step {
+		bits |= 1 << uint(v)
+	}
+	return bits, nil
+}
+
+// parseValue parses a number within the field's range
+func parseValue(text string, r fieldRange) (int, error) {
+	v, err := strconv.Atoi(text)
+	if err != nil || v < r.min || v > r.max {
+		return 0, fmt.Errorf("invalid %s %q, expected %d-%d", r.name, text, r.min, r.max)
+	}
+	return v, nil
+}
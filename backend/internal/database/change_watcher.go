@@ -0,0 +1,323 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jobsity-backend/pkg/domain"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// resumeTokensCollection records how far each watcher has read each
+	// change stream
+	resumeTokensCollection = "change_stream_tokens"
+
+	// watchRetryDelay is the wait before a failed change stream is reopened
+	watchRetryDelay = 2 * time.Second
+)
+
+// MongoDB server error codes
+const (
+	namespaceNotFound       = 26
+	namespaceExists         = 48
+	changeStreamFatalError  = 280
+	changeStreamHistoryLost = 286
+)
+
+// changeEvent is a change stream event on a watched collection
+type changeEvent struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		ID string `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument             bson.Raw `bson:"fullDocument"`
+	FullDocumentBeforeChange bson.Raw `bson:"fullDocumentBeforeChange"`
+}
+
+// resumeToken is a record in the change_stream_tokens collection
+type resumeToken struct {
+	Key       string    `bson:"_id"`
+	Token     bson.Raw  `bson:"token"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// ChangeWatcher tails the change streams of the messages and channels
+// collections and turns every change into the WebSocket event the message
+// and channel services would send, so events reach the clients of every
+// server whichever server made the change. It saves the resume token of
+// each stream after every event and continues from it after a restart.
+//
+// Change streams need MongoDB 6 or later running as a replica set.
+type ChangeWatcher struct {
+	db         *mongo.Database
+	name       string
+	notifyFunc func(channelID string, messageType string, data interface{})
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewChangeWatcher creates a watcher broadcasting with notifyFunc. name
+// identifies the watcher's resume tokens and must be unique to each server.
+func NewChangeWatcher(db *mongo.Database, name string, notifyFunc func(channelID string, messageType string, data interface{})) *ChangeWatcher {
+	return &ChangeWatcher{
+		db:         db,
+		name:       name,
+		notifyFunc: notifyFunc,
+	}
+}
+
+// Start opens the change streams and tails them in the background
+func (w *ChangeWatcher) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	// The channel of a deleted message is only in its delete event if
+	// MongoDB keeps pre-images of messages
+	if err := w.enablePreImages(ctx, "messages"); err != nil {
+		cancel()
+		return fmt.Errorf("failed to enable pre-images on messages: %w", err)
+	}
+
+	watched := []struct {
+		collection string
+		handle     func(event *changeEvent) error
+	}{
+		{"messages", w.handleMessageChange},
+		{"channels", w.handleChannelChange},
+	}
+
+	// Open every stream before tailing any, so a failure leaves none running
+	streams := make([]*mongo.ChangeStream, len(watched))
+	for i, watch := range watched {
+		stream, err := w.open(ctx, watch.collection)
+		if err != nil {
+			for _, opened := range streams[:i] {
+				opened.Close(ctx)
+			}
+			cancel()
+			return fmt.Errorf("failed to watch %s: %w", watch.collection, err)
+		}
+		streams[i] = stream
+	}
+
+	for i, watch := range watched {
+		w.wg.Add(1)
+		go w.tail(ctx, watch.collection, streams[i], watch.handle)
+	}
+	return nil
+}
+
+// Close stops tailing and waits for the event being handled
+func (w *ChangeWatcher) Close() error {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.wg.Wait()
+	return nil
+}
+
+// enablePreImages makes MongoDB keep the documents of collection as they
+// were before each change, creating the collection if needed
+func (w *ChangeWatcher) enablePreImages(ctx context.Context, collection string) error {
+	preImages := bson.M{"enabled": true}
+	collMod := func() error {
+		return w.db.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: collection},
+			{Key: "changeStreamPreAndPostImages", Value: preImages},
+		}).Err()
+	}
+
+	err := collMod()
+	if commandErrorCode(err) != namespaceNotFound {
+		return err
+	}
+	err = w.db.CreateCollection(ctx, collection, options.CreateCollection().SetChangeStreamPreAndPostImages(preImages))
+	if commandErrorCode(err) == namespaceExists {
+		// Created by another server in the meantime
+		return collMod()
+	}
+	return err
+}
+
+// open opens the change stream of collection after the saved resume token,
+// or from now if there is none
+func (w *ChangeWatcher) open(ctx context.Context, collection string) (*mongo.ChangeStream, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"operationType": bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}},
+		}}},
+	}
+	streamOptions := func() *options.ChangeStreamOptions {
+		return options.ChangeStream().
+			SetFullDocument(options.UpdateLookup).
+			SetFullDocumentBeforeChange(options.WhenAvailable)
+	}
+
+	token, err := w.loadToken(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	if token != nil {
+		stream, err := w.db.Collection(collection).Watch(ctx, pipeline, streamOptions().SetStartAfter(token))
+		if !historyLost(err) {
+			return stream, err
+		}
+		// The oplog no longer reaches back to the token
+		log.Printf("Change stream of %s cannot resume from its saved token; changes since were missed", collection)
+	}
+	return w.db.Collection(collection).Watch(ctx, pipeline, streamOptions())
+}
+
+// tail handles the events of a change stream until ctx is cancelled,
+// reopening the stream after errors
+func (w *ChangeWatcher) tail(ctx context.Context, collection string, stream *mongo.ChangeStream, handle func(event *changeEvent) error) {
+	defer w.wg.Done()
+
+	for {
+		for stream.Next(ctx) {
+			var event changeEvent
+			if err := stream.Decode(&event); err != nil {
+				log.Printf("Invalid change event on %s: %v", collection, err)
+			} else if err := handle(&event); err != nil {
+				log.Printf("Failed to handle %s change on %s: %v", event.OperationType, collection, err)
+			}
+
+			if err := w.saveToken(ctx, collection, stream.ResumeToken()); err != nil {
+				log.Printf("Failed to save change stream token of %s: %v", collection, err)
+			}
+		}
+		err := stream.Err()
+		stream.Close(context.Background())
+
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Printf("Change stream of %s failed, reopening: %v", collection, err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(watchRetryDelay):
+			}
+
+			stream, err = w.open(ctx, collection)
+			if err == nil {
+				break
+			}
+		}
+	}
+}
+
+// handleMessageChange broadcasts the message event for a change to a message
+func (w *ChangeWatcher) handleMessageChange(event *changeEvent) error {
+	var (
+		eventType string
+		document  bson.Raw
+	)
+	switch event.OperationType {
+	case "insert":
+		eventType, document = domain.EventNewMessage, event.FullDocument
+	case "update", "replace":
+		eventType, document = domain.EventMessageUpdated, event.FullDocument
+	case "delete":
+		eventType, document = domain.EventMessageDeleted, event.FullDocumentBeforeChange
+	default:
+		return nil
+	}
+	if document == nil {
+		// Deleted before the update was looked up, or the pre-image expired
+		return nil
+	}
+
+	var message domain.Message
+	if err := bson.Unmarshal(document, &message); err != nil {
+		return err
+	}
+	if message.AuthorType == "" {
+		message.AuthorType = domain.AuthorUser
+	}
+
+	if eventType == domain.EventMessageDeleted {
+		w.notifyFunc(message.ChannelID, eventType, domain.MessageDeletedEventData(&message))
+	} else {
+		w.notifyFunc(message.ChannelID, eventType, domain.MessageEventData(&message))
+	}
+	return nil
+}
+
+// handleChannelChange tells the clients of a channel that it was updated or
+// deleted. New channels have no clients yet.
+func (w *ChangeWatcher) handleChannelChange(event *changeEvent) error {
+	switch event.OperationType {
+	case "update", "replace":
+		if event.FullDocument == nil {
+			return nil
+		}
+		var channel domain.Channel
+		if err := bson.Unmarshal(event.FullDocument, &channel); err != nil {
+			return err
+		}
+		w.notifyFunc(channel.ID, domain.EventChannelUpdated, &channel)
+	case "delete":
+		w.notifyFunc(event.DocumentKey.ID, domain.EventChannelDeleted, map[string]interface{}{
+			"id": event.DocumentKey.ID,
+		})
+	}
+	return nil
+}
+
+// loadToken returns the saved resume token of collection, or nil
+func (w *ChangeWatcher) loadToken(ctx context.Context, collection string) (bson.Raw, error) {
+	var saved resumeToken
+	err := w.db.Collection(resumeTokensCollection).FindOne(ctx, bson.M{"_id": w.tokenKey(collection)}).Decode(&saved)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return saved.Token, nil
+}
+
+// saveToken records how far the change stream of collection has been read
+func (w *ChangeWatcher) saveToken(ctx context.Context, collection string, token bson.Raw) error {
+	key := w.tokenKey(collection)
+	_, err := w.db.Collection(resumeTokensCollection).ReplaceOne(ctx, bson.M{"_id": key}, resumeToken{
+		Key:       key,
+		Token:     token,
+		UpdatedAt: time.Now(),
+	}, options.Replace().SetUpsert(true))
+	return err
+}
+
+// tokenKey identifies the resume token of one collection for this watcher
+func (w *ChangeWatcher) tokenKey(collection string) string {
+	return w.name + "/" + collection
+}
+
+// historyLost reports whether err means a change stream cannot resume
+// because the oplog was truncated past its resume token
+func historyLost(err error) bool {
+	code := commandErrorCode(err)
+	return code == changeStreamHistoryLost || code == changeStreamFatalError
+}
+
+// commandErrorCode returns the server error code of err, or 0
+func commandErrorCode(err error) int32 {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code
+	}
+	return 0
+}
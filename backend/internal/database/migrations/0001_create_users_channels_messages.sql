@@ -0,0 +1,37 @@
+-- Users, channels and messages. IDs are 24 character hex strings, the same
+-- format MongoDB ObjectIDs use, so clients see one ID format on any backend.
+-- Times are stored in UTC.
+
+CREATE TABLE users (
+    id         TEXT PRIMARY KEY,
+    email      TEXT NOT NULL UNIQUE,
+    password   TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE channels (
+    id          TEXT PRIMARY KEY,
+    name        TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_by  TEXT NOT NULL,
+    created_at  TIMESTAMP NOT NULL,
+    updated_at  TIMESTAMP NOT NULL
+);
+
+-- Attachments and link previews are embedded as JSON, like in MongoDB
+CREATE TABLE messages (
+    id             TEXT PRIMARY KEY,
+    channel_id     TEXT NOT NULL,
+    user_email     TEXT NOT NULL,
+    author_type    TEXT NOT NULL,
+    correlation_id TEXT,
+    content        TEXT NOT NULL,
+    content_html   TEXT NOT NULL DEFAULT '',
+    content_text   TEXT NOT NULL DEFAULT '',
+    attachments    TEXT,
+    previews       TEXT,
+    created_at     TIMESTAMP NOT NULL
+);
+
+CREATE INDEX messages_channel_id_created_at ON messages (channel_id, created_at);
+CREATE INDEX messages_correlation_id ON messages (correlation_id);
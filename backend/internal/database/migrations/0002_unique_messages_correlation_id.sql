@@ -0,0 +1,6 @@
+-- A bot reply is stored once per correlation ID, even when two servers
+-- handle redeliveries of the same reply at once. User messages have no
+-- correlation ID, and NULLs do not conflict.
+
+DROP INDEX messages_correlation_id;
+CREATE UNIQUE INDEX messages_correlation_id ON messages (correlation_id);
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrationsCollection records the MongoDB migrations that have been applied
+const migrationsCollection = "schema_migrations"
+
+// mongoMigration is a versioned change to the MongoDB schema
+type mongoMigration struct {
+	Version     string
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// mongoMigrations are applied in order. Shipped migrations must not be
+// changed; add a new one instead.
+var mongoMigrations = []mongoMigration{
+	{
+		Version:     "0001",
+		Description: "unique index on users.email",
+		Up:          createIndex("users", bson.D{{Key: "email", Value: 1}}, true),
+	},
+	{
+		Version:     "0002",
+		Description: "unique index on channels.name",
+		Up:          createIndex("channels", bson.D{{Key: "name", Value: 1}}, true),
+	},
+	{
+		Version:     "0003",
+		Description: "index on messages.channel_id and created_at",
+		Up:          createIndex("messages", bson.D{{Key: "channel_id", Value: 1}, {Key: "created_at", Value: -1}}, false),
+	},
+	{
+		Version:     "0004",
+		Description: "index on outbox.delivered_at and created_at",
+		Up:          createIndex("outbox", bson.D{{Key: "delivered_at", Value: 1}, {Key: "created_at", Value: 1}}, false),
+	},
+	{
+		Version:     "0005",
+		Description: "expire delivered outbox events after a day",
+		Up:          createTTLIndex("outbox", "delivered_at", 24*time.Hour),
+	},
+	{
+		Version:     "0006",
+		Description: "sparse unique index on messages.correlation_id",
+		Up:          createSparseUniqueIndex("messages", "correlation_id"),
+	},
+}
+
+// appliedMigration is a record in the schema_migrations collection
+type appliedMigration struct {
+	Version     string    `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// MigrateMongo applies the migrations that have not been applied to db yet and
+// records each one. Migrations are idempotent, so servers starting at the same
+// time may both run one without harm.
+func MigrateMongo(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection(migrationsCollection)
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	var applied []appliedMigration
+	if err := cursor.All(ctx, &applied); err != nil {
+		return err
+	}
+	done := make(map[string]bool, len(applied))
+	for _, migration := range applied {
+		done[migration.Version] = true
+	}
+
+	for _, migration := range mongoMigrations {
+		if done[migration.Version] {
+			continue
+		}
+
+		if err := migration.Up(ctx, db); err != nil {
+			return fmt.Errorf("failed to apply migration %s (%s): %w", migration.Version, migration.Description, err)
+		}
+
+		_, err := collection.InsertOne(ctx, appliedMigration{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+		})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+		log.Printf("Applied MongoDB migration %s: %s", migration.Version, migration.Description)
+	}
+	return nil
+}
+
+// createIndex returns a migration step that creates an index on collection
+func createIndex(collection string, keys bson.D, unique bool) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    keys,
+			Options: options.Index().SetUnique(unique),
+		})
+		return err
+	}
+}
+
+// createSparseUniqueIndex returns a migration step that makes field unique
+// among the documents of collection that have it
+func createSparseUniqueIndex(collection, field string) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: field, Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true),
+		})
+		return err
+	}
+}
+
+// createTTLIndex returns a migration step that makes documents of collection
+// expire once field is older than ttl
+func createTTLIndex(collection, field string, ttl time.Duration) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: field, Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(ttl / time.Second)),
+		})
+		return err
+	}
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	// Registers the "pgx" database/sql driver
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// ConnectPostgres connects to PostgreSQL and returns the connection pool
+func ConnectPostgres(url string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", url)
+	if err != nil {
+		return nil, err
+	}
+
+	// Test the connection
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	log.Println("Connected to PostgreSQL successfully!")
+	return db, nil
+}
+
+// DisconnectPostgres closes the PostgreSQL connection pool
+func DisconnectPostgres(db *sql.DB) {
+	if db != nil {
+		db.Close()
+		log.Println("Disconnected from PostgreSQL")
+	}
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sqlMigrations holds the SQL schema migrations. Files are applied in name
+// order and must stay valid for both PostgreSQL and SQLite, which the
+// repository tests run against.
+//
+//go:embed migrations/*.sql
+var sqlMigrations embed.FS
+
+// MigrateSQL applies the SQL schema migrations that have not been applied to
+// db yet, each in its own transaction, and records them in schema_migrations
+func MigrateSQL(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	applied, err := appliedSQLMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(sqlMigrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version := strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+		if applied[version] {
+			continue
+		}
+
+		script, err := sqlMigrations.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err := applySQLMigration(ctx, db, version, string(script)); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", version, err)
+		}
+		log.Printf("Applied SQL migration %s", version)
+	}
+	return nil
+}
+
+// appliedSQLMigrations returns the versions recorded in schema_migrations
+func appliedSQLMigrations(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// applySQLMigration runs a migration script and records its version atomically
+func applySQLMigration(ctx context.Context, db *sql.DB, version, script string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES ($1, $2)`, version, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
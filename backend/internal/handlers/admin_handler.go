@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/service"
+	"jobsity-backend/pkg/domain"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminHandler handles HTTP requests for operational endpoints
+type AdminHandler struct {
+	deadLetterService service.DeadLetterService
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(deadLetterService service.DeadLetterService) *AdminHandler {
+	return &AdminHandler{
+		deadLetterService: deadLetterService,
+	}
+}
+
+// GetDeadLetters handles listing the dead letters of a queue
+func (h *AdminHandler) GetDeadLetters(c *fiber.Ctx) error {
+	queue := c.Params("queue")
+
+	// Parse limit parameter
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+
+	deadLetters, err := h.deadLetterService.ListDeadLetters(queue, limit)
+	if err != nil {
+		return c.Status(deadLetterErrorStatus(err)).JSON(domain.DeadLettersResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(domain.DeadLettersResponse{
+		Success:     true,
+		Message:     "Dead letters retrieved successfully",
+		DeadLetters: deadLetters,
+	})
+}
+
+// RequeueDeadLetters handles moving dead letters back to their work queue
+func (h *AdminHandler) RequeueDeadLetters(c *fiber.Ctx) error {
+	queue := c.Params("queue")
+
+	var req domain.RequeueDeadLettersRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(domain.RequeueDeadLettersResponse{
+				Success: false,
+				Message: "Invalid request body",
+			})
+		}
+	}
+
+	requeued, err := h.deadLetterService.RequeueDeadLetters(queue, req.IDs, req.Limit)
+	if err != nil {
+		return c.Status(deadLetterErrorStatus(err)).JSON(domain.RequeueDeadLettersResponse{
+			Success:  false,
+			Message:  err.Error(),
+			Requeued: requeued,
+		})
+	}
+
+	return c.JSON(domain.RequeueDeadLettersResponse{
+		Success:  true,
+		Message:  "Dead letters requeued successfully",
+		Requeued: requeued,
+	})
+}
+
+// deadLetterErrorStatus maps dead letter service errors to HTTP status codes
+func deadLetterErrorStatus(err error) int {
+	if err == service.ErrUnknownQueue {
+		return fiber.StatusNotFound
+	}
+	if errors.Is(err, broker.ErrDisconnected) {
+		return fiber.StatusServiceUnavailable
+	}
+	return fiber.StatusInternalServerError
+}
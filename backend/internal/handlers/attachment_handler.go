@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"jobsity-backend/internal/service"
+	"jobsity-backend/pkg/domain"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AttachmentHandler handles HTTP requests for file attachments
+type AttachmentHandler struct {
+	attachmentService service.AttachmentService
+}
+
+// NewAttachmentHandler creates a new attachment handler
+func NewAttachmentHandler(attachmentService service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+	}
+}
+
+// Upload handles multipart file uploads
+func (h *AttachmentHandler) Upload(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(domain.AttachmentResponse{
+			Success: false,
+			Message: "File is required",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(domain.AttachmentResponse{
+			Success: false,
+			Message: "Invalid file",
+		})
+	}
+	defer file.Close()
+
+	// Get user email from context
+	userEmail := c.Locals("userEmail").(string)
+
+	req := &domain.UploadAttachmentRequest{
+		ChannelID: c.FormValue("channel_id"),
+		FileName:  fileHeader.Filename,
+		Size:      fileHeader.Size,
+		Content:   file,
+	}
+
+	attachment, err := h.attachmentService.UploadAttachment(c.Context(), req, userEmail)
+	if err != nil {
+		status := errorStatus(err)
+		return c.Status(status).JSON(domain.AttachmentResponse{
+			Success: false,
+			Message: errorMessage(status, err),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(domain.AttachmentResponse{
+		Success:    true,
+		Message:    "File uploaded successfully",
+		Attachment: attachment,
+	})
+}
+
+// GetDownloadURL handles issuing a signed download URL for an attachment
+func (h *AttachmentHandler) GetDownloadURL(c *fiber.Ctx) error {
+	attachmentID := c.Params("id")
+	if attachmentID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(domain.DownloadURLResponse{
+			Success: false,
+			Message: "Attachment ID is required",
+		})
+	}
+
+	// Get user email from context
+	userEmail := c.Locals("userEmail").(string)
+
+	download, err := h.attachmentService.GetDownloadURL(c.Context(), attachmentID, c.Query("variant"), userEmail)
+	if err != nil {
+		status := errorStatus(err)
+		return c.Status(status).JSON(domain.DownloadURLResponse{
+			Success: false,
+			Message: errorMessage(status, err),
+		})
+	}
+
+	return c.JSON(domain.DownloadURLResponse{
+		Success:  true,
+		Message:  "Download URL created successfully",
+		Download: download,
+	})
+}
+
+// Download handles streaming an attachment through a signed URL
+func (h *AttachmentHandler) Download(c *fiber.Ctx) error {
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"message": service.ErrInvalidDownloadURL.Error(),
+		})
+	}
+
+	attachment, download, err := h.attachmentService.OpenAttachment(c.Context(), c.Params("id"), c.Query("variant"), c.Query("user"), expires, c.Query("signature"))
+	if err != nil {
+		status := errorStatus(err)
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"message": errorMessage(status, err),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, download.ContentType)
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="`+attachment.FileName+`"`)
+	c.Set("X-Content-Type-Options", "nosniff")
+	return c.SendStream(download.Content, int(download.Size))
+}
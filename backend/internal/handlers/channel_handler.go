@@ -37,9 +37,10 @@ func (h *ChannelHandler) CreateChannel(c *fiber.Ctx) error {
 	// Call service
 	channel, err := h.channelService.CreateChannel(c.Context(), &req, userEmail)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.ChannelResponse{
+		status := errorStatus(err)
+		return c.Status(status).JSON(domain.ChannelResponse{
 			Success: false,
-			Message: err.Error(),
+			Message: errorMessage(status, err),
 		})
 	}
 
@@ -62,9 +63,14 @@ func (h *ChannelHandler) GetChannel(c *fiber.Ctx) error {
 
 	channel, err := h.channelService.GetChannel(c.Context(), channelID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(domain.ChannelResponse{
+		status := errorStatus(err)
+		text := errorMessage(status, err)
+		if status == fiber.StatusNotFound {
+			text = "Channel not found"
+		}
+		return c.Status(status).JSON(domain.ChannelResponse{
 			Success: false,
-			Message: "Channel not found",
+			Message: text,
 		})
 	}
 
@@ -87,9 +93,14 @@ func (h *ChannelHandler) GetChannelByName(c *fiber.Ctx) error {
 
 	channel, err := h.channelService.GetChannelByName(c.Context(), channelName)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(domain.ChannelResponse{
+		status := errorStatus(err)
+		text := errorMessage(status, err)
+		if status == fiber.StatusNotFound {
+			text = "Channel not found"
+		}
+		return c.Status(status).JSON(domain.ChannelResponse{
 			Success: false,
-			Message: "Channel not found",
+			Message: text,
 		})
 	}
 
@@ -140,9 +151,10 @@ func (h *ChannelHandler) UpdateChannel(c *fiber.Ctx) error {
 
 	channel, err := h.channelService.UpdateChannel(c.Context(), channelID, &req, userEmail)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.ChannelResponse{
+		status := errorStatus(err)
+		return c.Status(status).JSON(domain.ChannelResponse{
 			Success: false,
-			Message: err.Error(),
+			Message: errorMessage(status, err),
 		})
 	}
 
@@ -168,9 +180,10 @@ func (h *ChannelHandler) DeleteChannel(c *fiber.Ctx) error {
 
 	err := h.channelService.DeleteChannel(c.Context(), channelID, userEmail)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.ChannelResponse{
+		status := errorStatus(err)
+		return c.Status(status).JSON(domain.ChannelResponse{
 			Success: false,
-			Message: err.Error(),
+			Message: errorMessage(status, err),
 		})
 	}
 
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"jobsity-backend/pkg/domain"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errorStatus maps domain errors returned by services to HTTP status codes.
+// Errors that are not domain errors are unexpected failures, e.g. the
+// database being unreachable.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidID), errors.Is(err, domain.ErrInvalidInput):
+		return fiber.StatusBadRequest
+	case errors.Is(err, domain.ErrForbidden):
+		return fiber.StatusForbidden
+	case errors.Is(err, domain.ErrNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return fiber.StatusConflict
+	case errors.Is(err, domain.ErrTooLarge):
+		return fiber.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrUnsupported):
+		return fiber.StatusUnsupportedMediaType
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// errorMessage returns the message shown to clients for an error with the
+// given status. Details of unexpected failures are logged instead of shown.
+func errorMessage(status int, err error) string {
+	if status == fiber.StatusInternalServerError {
+		log.Printf("Request failed: %v", err)
+		return "Internal server error"
+	}
+	return err.Error()
+}
@@ -1,26 +1,36 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"jobsity-backend/internal/commands"
+	"jobsity-backend/internal/middleware"
+	"jobsity-backend/internal/ratelimit"
 	"jobsity-backend/internal/service"
 	"jobsity-backend/pkg/domain"
 	"strconv"
-	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/streadway/amqp"
 )
 
 // MessageHandler handles HTTP requests for message operations
 type MessageHandler struct {
 	messageService service.MessageService
-	rabbitMQ       *amqp.Channel
+	commands       *commands.Registry
+	sendEphemeral  service.EphemeralSender
+	limiter        *ratelimit.Limiter
 }
 
-// NewMessageHandler creates a new message handler
-func NewMessageHandler(messageService service.MessageService, rabbitMQ *amqp.Channel) *MessageHandler {
+// NewMessageHandler creates a new message handler. Command replies are also
+// sent to the user's WebSocket connections through sendEphemeral, which may
+// be nil. Posting and commands are rate limited per user by limiter, which
+// may also be nil.
+func NewMessageHandler(messageService service.MessageService, commandRegistry *commands.Registry, sendEphemeral service.EphemeralSender, limiter *ratelimit.Limiter) *MessageHandler {
 	return &MessageHandler{
 		messageService: messageService,
-		rabbitMQ:       rabbitMQ,
+		commands:       commandRegistry,
+		sendEphemeral:  sendEphemeral,
+		limiter:        limiter,
 	}
 }
 
@@ -39,55 +49,74 @@ func (h *MessageHandler) CreateMessage(c *fiber.Ctx) error {
 	// Get user email from context
 	userEmail := c.Locals("userEmail").(string)
 
-	// Check if it's a stock command
-	if strings.HasPrefix(req.Content, "/stock=") {
-		stockCode := strings.TrimPrefix(req.Content, "/stock=")
-		if stockCode == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(domain.MessageResponse{
+	// Slash commands are executed instead of being stored as messages
+	if h.commands.IsCommand(req.Content) {
+		decision := h.limiter.Allow(c.Context(), ratelimit.ActionCommand, userEmail)
+		if !decision.Allowed {
+			service.SendCommandReply(h.sendEphemeral, req.ChannelID, userEmail,
+				fmt.Sprintf("You are sending commands too quickly, try again in %ds", decision.RetryAfterSeconds()))
+			return middleware.TooManyRequests(c, decision)
+		}
+		return h.executeCommand(c, req.ChannelID, userEmail, req.Content)
+	}
+
+	if decision := h.limiter.Allow(c.Context(), ratelimit.ActionPost, userEmail); !decision.Allowed {
+		return middleware.TooManyRequests(c, decision)
+	}
+
+	// Call service for regular messages
+	message, err := h.messageService.CreateMessage(c.Context(), &req, userEmail)
+	if err != nil {
+		status := errorStatus(err)
+		return c.Status(status).JSON(domain.MessageResponse{
+			Success: false,
+			Message: errorMessage(status, err),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(domain.MessageResponse{
+		Success: true,
+		Message: "Message created successfully",
+		Data:    message,
+	})
+}
+
+// executeCommand runs a slash command and replies to the sender only, both in
+// the response and as an ephemeral message in the channel. Acknowledgements
+// of async commands are not echoed; their answer follows through the channel.
+func (h *MessageHandler) executeCommand(c *fiber.Ctx, channelID, userEmail, content string) error {
+	result, err := h.commands.Execute(c.Context(), channelID, userEmail, content)
+	if err != nil {
+		service.SendCommandReply(h.sendEphemeral, channelID, userEmail, commands.ErrorMessage(err))
+
+		if errors.Is(err, commands.ErrNotEnqueued) {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(domain.MessageResponse{
 				Success: false,
-				Message: "Stock code is required",
+				Message: commands.ErrorMessage(err),
 			})
 		}
 
-		// Publish to RabbitMQ for stock bot processing
-		command := req.ChannelID + "|" + userEmail + "|" + stockCode
-		err := h.rabbitMQ.Publish(
-			"",               // exchange
-			"stock_commands", // routing key
-			false,            // mandatory
-			false,            // immediate
-			amqp.Publishing{
-				ContentType: "text/plain",
-				Body:        []byte(command),
-			},
-		)
-		if err != nil {
+		var execErr *commands.ExecutionError
+		if errors.As(err, &execErr) {
 			return c.Status(fiber.StatusInternalServerError).JSON(domain.MessageResponse{
 				Success: false,
-				Message: "Failed to process stock command",
+				Message: commands.ErrorMessage(err),
 			})
 		}
 
-		// Return success but don't save the command to database
-		return c.Status(fiber.StatusOK).JSON(domain.MessageResponse{
-			Success: true,
-			Message: "Stock command processed",
-		})
-	}
-
-	// Call service for regular messages
-	message, err := h.messageService.CreateMessage(c.Context(), &req, userEmail)
-	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(domain.MessageResponse{
 			Success: false,
 			Message: err.Error(),
 		})
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(domain.MessageResponse{
+	if !result.Async {
+		service.SendCommandReply(h.sendEphemeral, channelID, userEmail, result.Message)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.MessageResponse{
 		Success: true,
-		Message: "Message created successfully",
-		Data:    message,
+		Message: result.Message,
 	})
 }
 
@@ -103,9 +132,14 @@ func (h *MessageHandler) GetMessage(c *fiber.Ctx) error {
 
 	message, err := h.messageService.GetMessage(c.Context(), messageID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(domain.MessageResponse{
+		status := errorStatus(err)
+		text := errorMessage(status, err)
+		if status == fiber.StatusNotFound {
+			text = "Message not found"
+		}
+		return c.Status(status).JSON(domain.MessageResponse{
 			Success: false,
-			Message: "Message not found",
+			Message: text,
 		})
 	}
 
@@ -135,9 +169,10 @@ func (h *MessageHandler) GetMessagesByChannel(c *fiber.Ctx) error {
 
 	messages, err := h.messageService.GetMessagesByChannel(c.Context(), channelID, limit)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.MessagesResponse{
+		status := errorStatus(err)
+		return c.Status(status).JSON(domain.MessagesResponse{
 			Success: false,
-			Message: err.Error(),
+			Message: errorMessage(status, err),
 		})
 	}
 
@@ -180,9 +215,10 @@ func (h *MessageHandler) UpdateMessage(c *fiber.Ctx) error {
 
 	message, err := h.messageService.UpdateMessage(c.Context(), messageID, req.Content, userEmail)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.MessageResponse{
+		status := errorStatus(err)
+		return c.Status(status).JSON(domain.MessageResponse{
 			Success: false,
-			Message: err.Error(),
+			Message: errorMessage(status, err),
 		})
 	}
 
@@ -208,9 +244,10 @@ func (h *MessageHandler) DeleteMessage(c *fiber.Ctx) error {
 
 	err := h.messageService.DeleteMessage(c.Context(), messageID, userEmail)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.MessageResponse{
+		status := errorStatus(err)
+		return c.Status(status).JSON(domain.MessageResponse{
 			Success: false,
-			Message: err.Error(),
+			Message: errorMessage(status, err),
 		})
 	}
 
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"jobsity-backend/internal/quotes"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StockHandler handles HTTP requests about the stock bot
+type StockHandler struct {
+	quoteCache *quotes.CachingProvider
+}
+
+// NewStockHandler creates a new stock handler
+func NewStockHandler(quoteCache *quotes.CachingProvider) *StockHandler {
+	return &StockHandler{
+		quoteCache: quoteCache,
+	}
+}
+
+// GetStats returns the quote cache hit/miss counters
+func (h *StockHandler) GetStats(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"provider":    h.quoteCache.Name(),
+			"quote_cache": h.quoteCache.Stats(),
+		},
+	})
+}
@@ -63,9 +63,10 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 	// Call service
 	user, err := h.userService.CreateUser(c.Context(), &req)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		status := errorStatus(err)
+		return c.Status(status).JSON(fiber.Map{
 			"success": false,
-			"message": err.Error(),
+			"message": errorMessage(status, err),
 		})
 	}
 
@@ -88,9 +89,14 @@ func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 
 	user, err := h.userService.GetUserByEmail(c.Context(), email)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		status := errorStatus(err)
+		text := errorMessage(status, err)
+		if status == fiber.StatusNotFound {
+			text = "User not found"
+		}
+		return c.Status(status).JSON(fiber.Map{
 			"success": false,
-			"message": "User not found",
+			"message": text,
 		})
 	}
 
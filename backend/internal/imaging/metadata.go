@@ -0,0 +1,250 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// ErrInvalidImage is returned when image data cannot be parsed
+var ErrInvalidImage = errors.New("invalid image data")
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// exifHeader prefixes the payload of a JPEG APP1 segment carrying EXIF data
+var exifHeader = []byte("Exif\x00\x00")
+
+// JPEG markers
+const (
+	markerSOI  = 0xD8
+	markerEOI  = 0xD9
+	markerSOS  = 0xDA
+	markerAPP1 = 0xE1
+	markerAPPD = 0xED // Photoshop/IPTC
+	markerCOM  = 0xFE
+)
+
+// StripMetadata removes EXIF, XMP, IPTC and text metadata from a JPEG or PNG
+// file without re-encoding the pixel data. For JPEG the EXIF orientation is
+// preserved so the image still displays the right way up. Other formats are
+// returned unchanged.
+func StripMetadata(contentType string, data []byte) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	default:
+		return data, nil
+	}
+}
+
+// Orientation returns the EXIF orientation (1-8) of a JPEG file, or 1 if unknown
+func Orientation(data []byte) int {
+	orientation := 1
+	walkJPEG(data, func(marker byte, segment []byte, offset int) bool {
+		if marker == markerAPP1 && bytes.HasPrefix(segment, exifHeader) {
+			orientation = parseOrientation(segment[len(exifHeader):])
+			return false
+		}
+		return marker != markerSOS
+	})
+	return orientation
+}
+
+// stripJPEG drops metadata segments that precede the image scan
+func stripJPEG(data []byte) ([]byte, error) {
+	orientation := Orientation(data)
+
+	var out bytes.Buffer
+	out.Write([]byte{0xFF, markerSOI})
+
+	wroteOrientation := false
+	scan := -1
+	ok := walkJPEG(data, func(marker byte, segment []byte, offset int) bool {
+		switch {
+		case marker == markerSOS:
+			// Everything from the first scan onwards is image data
+			scan = offset
+			return false
+		case marker == markerAPP1, marker == markerAPPD, marker == markerCOM:
+			if marker == markerAPP1 && bytes.HasPrefix(segment, exifHeader) && orientation != 1 && !wroteOrientation {
+				writeSegment(&out, markerAPP1, orientationEXIF(orientation))
+				wroteOrientation = true
+			}
+			return true
+		default:
+			writeSegment(&out, marker, segment)
+			return true
+		}
+	})
+	if !ok || scan < 0 {
+		return nil, ErrInvalidImage
+	}
+	out.Write(data[scan:])
+
+	return out.Bytes(), nil
+}
+
+// walkJPEG calls fn for each marker segment up to and including the first scan,
+// passing the offset at which the marker starts. It returns false if the data
+// is not a well-formed JPEG header.
+func walkJPEG(data []byte, fn func(marker byte, segment []byte, offset int) bool) bool {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != markerSOI {
+		return false
+	}
+
+	pos := 2
+	for pos < len(data) {
+		if data[pos] != 0xFF {
+			return false
+		}
+		offset := pos
+		// Skip fill bytes
+		for pos < len(data) && data[pos] == 0xFF {
+			pos++
+		}
+		if pos >= len(data) {
+			return false
+		}
+		marker := data[pos]
+		pos++
+
+		// Standalone markers carry no length
+		if marker == markerEOI || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 {
+			if !fn(marker, nil, offset) {
+				return true
+			}
+			continue
+		}
+
+		if pos+2 > len(data) {
+			return false
+		}
+		length := int(binary.BigEndian.Uint16(data[pos:]))
+		if length < 2 || pos+length > len(data) {
+			return false
+		}
+		segment := data[pos+2 : pos+length]
+		pos += length
+
+		if !fn(marker, segment, offset) {
+			return true
+		}
+	}
+	return true
+}
+
+// writeSegment writes a JPEG marker segment
+func writeSegment(out *bytes.Buffer, marker byte, segment []byte) {
+	out.Write([]byte{0xFF, marker})
+	if segment == nil && (marker == markerEOI || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01) {
+		return
+	}
+	var length [2]byte
+	binary.BigEndian.PutUint16(length[:], uint16(len(segment)+2))
+	out.Write(length[:])
+	out.Write(segment)
+}
+
+// parseOrientation reads the orientation tag from a TIFF-encoded EXIF payload
+func parseOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			value := int(order.Uint16(tiff[entry+8:]))
+			if value >= 1 && value <= 8 {
+				return value
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// orientationEXIF builds a minimal EXIF payload containing only the orientation tag
+func orientationEXIF(orientation int) []byte {
+	var buf bytes.Buffer
+	buf.Write(exifHeader)
+	buf.WriteString("MM")
+	binary.Write(&buf, binary.BigEndian, uint16(42))
+	binary.Write(&buf, binary.BigEndian, uint32(8)) // offset of IFD0
+	binary.Write(&buf, binary.BigEndian, uint16(1)) // one entry
+	binary.Write(&buf, binary.BigEndian, uint16(0x0112))
+	binary.Write(&buf, binary.BigEndian, uint16(3)) // SHORT
+	binary.Write(&buf, binary.BigEndian, uint32(1))
+	binary.Write(&buf, binary.BigEndian, uint16(orientation))
+	binary.Write(&buf, binary.BigEndian, uint16(0))
+	binary.Write(&buf, binary.BigEndian, uint32(0)) // no next IFD
+	return buf.Bytes()
+}
+
+// pngMetadataChunks lists ancillary chunks that may carry personal metadata
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+// stripPNG drops metadata chunks from a PNG file
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrInvalidImage
+	}
+
+	var out bytes.Buffer
+	out.Write(pngSignature)
+
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, ErrInvalidImage
+		}
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		chunkType := string(data[pos+4 : pos+8])
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, ErrInvalidImage
+		}
+
+		chunk := data[pos:end]
+		if crc32.ChecksumIEEE(chunk[4:8+length]) != binary.BigEndian.Uint32(chunk[8+length:]) {
+			return nil, ErrInvalidImage
+		}
+		if !pngMetadataChunks[chunkType] {
+			out.Write(chunk)
+		}
+
+		pos = end
+		if chunkType == "IEND" {
+			break
+		}
+	}
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,149 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// maxPixels guards against decompression bombs
+const maxPixels = 50_000_000
+
+// ErrImageTooLarge is returned when an image has too many pixels to process safely
+var ErrImageTooLarge = errors.New("image dimensions are too large")
+
+// Thumbnail holds an encoded thumbnail and the dimensions of both images
+type Thumbnail struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+	// Dimensions of the original, after applying its EXIF orientation
+	SourceWidth  int
+	SourceHeight int
+}
+
+// IsSupported reports whether thumbnails can be generated for the content type
+func IsSupported(contentType string) bool {
+	switch contentType {
+	case "image/png", "image/jpeg", "image/gif":
+		return true
+	default:
+		return false
+	}
+}
+
+// MakeThumbnail decodes a PNG, JPEG or GIF image and produces a thumbnail that
+// fits within maxSize x maxSize. JPEG sources produce JPEG thumbnails; PNG and
+// GIF sources produce PNG thumbnails to keep transparency. Thumbnails are
+// freshly encoded and therefore carry no metadata.
+func MakeThumbnail(contentType string, data []byte, maxSize int) (*Thumbnail, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	var src image.Image
+	switch contentType {
+	case "image/jpeg":
+		src, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		src, err = png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		// Only the first frame is used
+		src, err = gif.Decode(bytes.NewReader(data))
+	default:
+		return nil, ErrInvalidImage
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType == "image/jpeg" {
+		src = applyOrientation(src, Orientation(data))
+	}
+
+	bounds := src.Bounds()
+	width, height := fit(bounds.Dx(), bounds.Dy(), maxSize)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var out bytes.Buffer
+	thumbnailType := "image/png"
+	if contentType == "image/jpeg" {
+		thumbnailType = "image/jpeg"
+		err = jpeg.Encode(&out, dst, &jpeg.Options{Quality: 82})
+	} else {
+		err = png.Encode(&out, dst)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Thumbnail{
+		Data:         out.Bytes(),
+		ContentType:  thumbnailType,
+		Width:        width,
+		Height:       height,
+		SourceWidth:  bounds.Dx(),
+		SourceHeight: bounds.Dy(),
+	}, nil
+}
+
+// fit scales width x height down to fit within maxSize, preserving aspect ratio
+func fit(width, height, maxSize int) (int, int) {
+	if width <= maxSize && height <= maxSize {
+		return width, height
+	}
+	if width >= height {
+		return maxSize, max(1, height*maxSize/width)
+	}
+	return max(1, width*maxSize/height), maxSize
+}
+
+// applyOrientation returns img transformed according to an EXIF orientation value
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirror horizontal
+				sx, sy = w-1-x, y
+			case 3: // rotate 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirror vertical
+				sx, sy = x, h-1-y
+			case 5: // transpose
+				sx, sy = y, x
+			case 6: // rotate 90 clockwise
+				sx, sy = y, h-1-x
+			case 7: // transverse
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotate 90 counter-clockwise
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return dst
+}
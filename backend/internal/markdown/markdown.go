@@ -0,0 +1,395 @@
+// Package markdown renders the Markdown subset supported in chat messages.
+//
+// Supported syntax: **bold**, *italic* or _italic_, `inline code`, fenced
+// ``` code blocks ```, [links](https://example.com), bare http(s) URLs and
+// > quotes. Everything else, including raw HTML, is escaped. The HTML output
+// is built only from a fixed set of tags, so it is safe to insert into a page
+// without further sanitization.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxQuoteDepth limits how deeply quotes may be nested
+const maxQuoteDepth = 3
+
+// Rendered holds the HTML and plain-text renderings of a message
+type Rendered struct {
+	HTML string
+	Text string
+}
+
+// Render converts message content into sanitized HTML and a plain-text fallback
+func Render(content string) *Rendered {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	blocks := parseBlocks(strings.Split(content, "\n"), 0)
+
+	var htmlOut, textOut strings.Builder
+	for i, b := range blocks {
+		if i > 0 {
+			textOut.WriteString("\n\n")
+		}
+		b.writeHTML(&htmlOut)
+		b.writeText(&textOut)
+	}
+
+	return &Rendered{
+		HTML: htmlOut.String(),
+		Text: textOut.String(),
+	}
+}
+
+// block is a paragraph, quote or code block
+type block interface {
+	writeHTML(out *strings.Builder)
+	writeText(out *strings.Builder)
+}
+
+type paragraph struct {
+	lines [][]*inline
+}
+
+type quote struct {
+	blocks []block
+}
+
+type codeBlock struct {
+	language string
+	code     string
+}
+
+var languagePattern = regexp.MustCompile(`^[A-Za-z0-9_+#.-]{1,20}$`)
+
+// parseBlocks splits lines into blocks
+func parseBlocks(lines []string, depth int) []block {
+	var blocks []block
+	var para [][]*inline
+
+	flush := func() {
+		if len(para) > 0 {
+			blocks = append(blocks, &paragraph{lines: para})
+			para = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			language := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			if !languagePattern.MatchString(language) {
+				language = ""
+			}
+
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.TrimSpace(lines[i]) == "```" {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, &codeBlock{language: language, code: strings.Join(code, "\n")})
+
+		case strings.HasPrefix(trimmed, ">") && depth < maxQuoteDepth:
+			flush()
+			var quoted []string
+			for ; i < len(lines); i++ {
+				t := strings.TrimSpace(lines[i])
+				if !strings.HasPrefix(t, ">") {
+					i--
+					break
+				}
+				t = strings.TrimPrefix(t, ">")
+				quoted = append(quoted, strings.TrimPrefix(t, " "))
+			}
+			blocks = append(blocks, &quote{blocks: parseBlocks(quoted, depth+1)})
+
+		case trimmed == "":
+			flush()
+
+		default:
+			para = append(para, parseInline(line))
+		}
+	}
+	flush()
+
+	return blocks
+}
+
+func (p *paragraph) writeHTML(out *strings.Builder) {
+	out.WriteString("<p>")
+	for i, line := range p.lines {
+		if i > 0 {
+			out.WriteString("<br>")
+		}
+		writeInlineHTML(out, line)
+	}
+	out.WriteString("</p>")
+}
+
+func (p *paragraph) writeText(out *strings.Builder) {
+	for i, line := range p.lines {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		writeInlineText(out, line)
+	}
+}
+
+func (q *quote) writeHTML(out *strings.Builder) {
+	out.WriteString("<blockquote>")
+	for _, b := range q.blocks {
+		b.writeHTML(out)
+	}
+	out.WriteString("</blockquote>")
+}
+
+func (q *quote) writeText(out *strings.Builder) {
+	var inner strings.Builder
+	for i, b := range q.blocks {
+		if i > 0 {
+			inner.WriteString("\n\n")
+		}
+		b.writeText(&inner)
+	}
+	for i, line := range strings.Split(inner.String(), "\n") {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		out.WriteString("> " + line)
+	}
+}
+
+func (c *codeBlock) writeHTML(out *strings.Builder) {
+	if c.language != "" {
+		out.WriteString(`<pre><code class="language-` + html.EscapeString(c.language) + `">`)
+	} else {
+		out.WriteString("<pre><code>")
+	}
+	out.WriteString(html.EscapeString(c.code))
+	out.WriteString("</code></pre>")
+}
+
+func (c *codeBlock) writeText(out *strings.Builder) {
+	out.WriteString(c.code)
+}
+
+// inline kinds
+const (
+	inlineText = iota
+	inlineBold
+	inlineItalic
+	inlineCode
+	inlineLink
+)
+
+// inline is a span of formatted text within a line
+type inline struct {
+	kind     int
+	text     string
+	href     string
+	children []*inline
+}
+
+// parseInline parses the inline formatting of a single line
+func parseInline(s string) []*inline {
+	var nodes []*inline
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, &inline{kind: inlineText, text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_[]()>#", s[i+1]) >= 0:
+			// Backslash escapes a markup character
+			text.WriteByte(s[i+1])
+			i += 2
+			continue
+
+		case c == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end >= 0 {
+				flush()
+				nodes = append(nodes, &inline{kind: inlineCode, text: s[i+1 : i+1+end]})
+				i += end + 2
+				continue
+			}
+
+		case strings.HasPrefix(s[i:], "**"):
+			if end := strings.Index(s[i+2:], "**"); end > 0 {
+				flush()
+				nodes = append(nodes, &inline{kind: inlineBold, children: parseInline(s[i+2 : i+2+end])})
+				i += end + 4
+				continue
+			}
+
+		case c == '*' || c == '_':
+			if end := findEmphasisEnd(s, i); end > 0 {
+				flush()
+				nodes = append(nodes, &inline{kind: inlineItalic, children: parseInline(s[i+1 : end])})
+				i = end + 1
+				continue
+			}
+
+		case c == '[':
+			if label, href, n, ok := parseLink(s[i:]); ok {
+				flush()
+				if safe, ok := safeURL(href); ok {
+					nodes = append(nodes, &inline{kind: inlineLink, href: safe, children: parseInline(label)})
+				} else {
+					// Unsafe link targets are shown as plain text
+					nodes = append(nodes, &inline{kind: inlineText, text: s[i : i+n]})
+				}
+				i += n
+				continue
+			}
+
+		case c == 'h' && (strings.HasPrefix(s[i:], "http://") || strings.HasPrefix(s[i:], "https://")) && (i == 0 || !isWordByte(s[i-1])):
+			end := i
+			for end < len(s) && !isURLTerminator(s[end]) {
+				end++
+			}
+			raw := strings.TrimRight(s[i:end], ".,;:!?")
+			if safe, ok := safeURL(raw); ok {
+				flush()
+				nodes = append(nodes, &inline{kind: inlineLink, href: safe, children: []*inline{{kind: inlineText, text: raw}}})
+				i += len(raw)
+				continue
+			}
+		}
+
+		text.WriteByte(c)
+		i++
+	}
+	flush()
+
+	return nodes
+}
+
+// findEmphasisEnd returns the index of the delimiter closing the emphasis that
+// opens at start, or -1. Underscores inside words (snake_case) are ignored.
+func findEmphasisEnd(s string, start int) int {
+	delim := s[start]
+	if start+1 >= len(s) || s[start+1] == ' ' || s[start+1] == delim {
+		return -1
+	}
+	if delim == '_' && start > 0 && isWordByte(s[start-1]) {
+		return -1
+	}
+
+	for j := start + 1; j < len(s); j++ {
+		if s[j] == '\\' {
+			j++
+			continue
+		}
+		if s[j] != delim || s[j-1] == ' ' {
+			continue
+		}
+		if delim == '_' && j+1 < len(s) && isWordByte(s[j+1]) {
+			continue
+		}
+		return j
+	}
+	return -1
+}
+
+// parseLink parses [label](href) at the start of s, returning the consumed length
+func parseLink(s string) (label string, href string, n int, ok bool) {
+	closeLabel := strings.Index(s, "](")
+	if closeLabel < 1 {
+		return "", "", 0, false
+	}
+	closeHref := strings.IndexByte(s[closeLabel+2:], ')')
+	if closeHref < 1 {
+		return "", "", 0, false
+	}
+
+	label = s[1:closeLabel]
+	href = strings.TrimSpace(s[closeLabel+2 : closeLabel+2+closeHref])
+	if strings.ContainsAny(href, " \t") {
+		return "", "", 0, false
+	}
+	return label, href, closeLabel + 3 + closeHref, true
+}
+
+// safeURL returns the normalized URL if it uses an allowed scheme
+func safeURL(raw string) (string, bool) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		if parsed.Host == "" {
+			return "", false
+		}
+	case "mailto":
+	default:
+		// Rejects javascript:, data:, vbscript: and scheme-relative URLs
+		return "", false
+	}
+	return parsed.String(), true
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isURLTerminator(c byte) bool {
+	return c == ' ' || c == '\t' || c == '<' || c == '>' || c == '"' || c == '\'' || c == '`' || c == ')' || c == ']'
+}
+
+func writeInlineHTML(out *strings.Builder, nodes []*inline) {
+	for _, node := range nodes {
+		switch node.kind {
+		case inlineText:
+			out.WriteString(html.EscapeString(node.text))
+		case inlineCode:
+			out.WriteString("<code>" + html.EscapeString(node.text) + "</code>")
+		case inlineBold:
+			out.WriteString("<strong>")
+			writeInlineHTML(out, node.children)
+			out.WriteString("</strong>")
+		case inlineItalic:
+			out.WriteString("<em>")
+			writeInlineHTML(out, node.children)
+			out.WriteString("</em>")
+		case inlineLink:
+			out.WriteString(`<a href="` + html.EscapeString(node.href) + `" rel="nofollow noopener noreferrer" target="_blank">`)
+			writeInlineHTML(out, node.children)
+			out.WriteString("</a>")
+		}
+	}
+}
+
+func writeInlineText(out *strings.Builder, nodes []*inline) {
+	for _, node := range nodes {
+		switch node.kind {
+		case inlineText, inlineCode:
+			out.WriteString(node.text)
+		case inlineBold, inlineItalic:
+			writeInlineText(out, node.children)
+		case inlineLink:
+			var label strings.Builder
+			writeInlineText(&label, node.children)
+			out.WriteString(label.String())
+			if label.String() != node.href {
+				out.WriteString(" (" + node.href + ")")
+			}
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -35,3 +37,23 @@ func OptionalAuthMiddleware() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// AdminMiddleware only lets through users listed in adminEmails. It must run
+// after AuthMiddleware.
+func AdminMiddleware(adminEmails []string) fiber.Handler {
+	admins := make(map[string]bool, len(adminEmails))
+	for _, email := range adminEmails {
+		admins[strings.ToLower(email)] = true
+	}
+
+	return func(c *fiber.Ctx) error {
+		userEmail, _ := c.Locals("userEmail").(string)
+		if !admins[strings.ToLower(userEmail)] {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"message": "Admin access required",
+			})
+		}
+		return c.Next()
+	}
+}
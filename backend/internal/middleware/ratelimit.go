@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"jobsity-backend/internal/ratelimit"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimitMiddleware rejects requests once the caller has used up their
+// limit for action. Authenticated requests are limited per user, others per
+// client IP; it must run after AuthMiddleware on authenticated routes.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, action ratelimit.Action) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key, _ := c.Locals("userEmail").(string)
+		if key == "" {
+			key = "ip:" + c.IP()
+		}
+
+		decision := limiter.Allow(c.Context(), action, key)
+		if !decision.Allowed {
+			return TooManyRequests(c, decision)
+		}
+		return c.Next()
+	}
+}
+
+// LoginRateLimitMiddleware limits login attempts per submitted email and
+// client IP, so users sharing an address, such as a proxy or an office NAT,
+// do not use up each other's attempts. Requests without an email are limited
+// per client IP.
+func LoginRateLimitMiddleware(limiter *ratelimit.Limiter) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// The handler parses the body again; only the email is needed here
+		var req struct {
+			Email string `json:"email" form:"email"`
+		}
+		c.BodyParser(&req)
+
+		key := "ip:" + c.IP()
+		if email := strings.ToLower(strings.TrimSpace(req.Email)); email != "" {
+			key = "email:" + email + "|" + key
+		}
+
+		decision := limiter.Allow(c.Context(), ratelimit.ActionLogin, key)
+		if !decision.Allowed {
+			return TooManyRequests(c, decision)
+		}
+		return c.Next()
+	}
+}
+
+// TooManyRequests replies 429 with a Retry-After header for a rejected request
+func TooManyRequests(c *fiber.Ctx, decision ratelimit.Decision) error {
+	retryAfter := decision.RetryAfterSeconds()
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"success":     false,
+		"message":     "Too many requests, please try again in " + strconv.Itoa(retryAfter) + "s",
+		"retry_after": retryAfter,
+	})
+}
@@ -0,0 +1,183 @@
+package quotes
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheOptions configures a CachingProvider
+type CacheOptions struct {
+	TTL         time.Duration // how long quotes are served from memory
+	NegativeTTL time.Duration // how long unknown symbols are remembered
+	MaxEntries  int
+}
+
+// CacheStats are the counters of a CachingProvider
+type CacheStats struct {
+	Hits         int64 `json:"hits"`
+	NegativeHits int64 `json:"negative_hits"`
+	Misses       int64 `json:"misses"`
+	Coalesced    int64 `json:"coalesced"`
+	Entries      int   `json:"entries"`
+}
+
+// quoteEntry is a cached quote or a cached unknown symbol
+type quoteEntry struct {
+	symbol    string
+	quote     *Quote
+	expiresAt time.Time
+}
+
+// CachingProvider wraps a QuoteProvider with a bounded in-memory TTL cache.
+// Concurrent lookups of the same symbol share a single upstream request, and
+// unknown symbols are cached so they are not looked up on every command.
+type CachingProvider struct {
+	provider QuoteProvider
+	options  CacheOptions
+	group    singleflight.Group
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used at the front
+
+	hits         atomic.Int64
+	negativeHits atomic.Int64
+	misses       atomic.Int64
+	coalesced    atomic.Int64
+}
+
+// NewCachingProvider creates a caching quote provider
+func NewCachingProvider(provider QuoteProvider, options CacheOptions) *CachingProvider {
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = 1000
+	}
+	return &CachingProvider{
+		provider: provider,
+		options:  options,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Name returns the name of the wrapped provider
+func (c *CachingProvider) Name() string {
+	return c.provider.Name()
+}
+
+// Quote returns the cached quote for symbol, looking it up on a miss
+func (c *CachingProvider) Quote(ctx context.Context, symbol string) (*Quote, error) {
+	key := strings.ToLower(symbol)
+
+	if entry, ok := c.get(key); ok {
+		if entry.quote == nil {
+			c.negativeHits.Add(1)
+			return nil, ErrSymbolNotFound
+		}
+		c.hits.Add(1)
+		return copyQuote(entry.quote), nil
+	}
+
+	// The shared lookup must not fail for everyone when the caller that
+	// started it goes away, so it runs detached from ctx
+	started := false
+	results := c.group.DoChan(key, func() (interface{}, error) {
+		started = true
+		c.misses.Add(1)
+		return c.fetch(context.WithoutCancel(ctx), key)
+	})
+
+	select {
+	case result := <-results:
+		if !started {
+			c.coalesced.Add(1)
+		}
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return copyQuote(result.Val.(*Quote)), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Stats returns the cache counters
+func (c *CachingProvider) Stats() CacheStats {
+	c.mutex.Lock()
+	entries := c.order.Len()
+	c.mutex.Unlock()
+
+	return CacheStats{
+		Hits:         c.hits.Load(),
+		NegativeHits: c.negativeHits.Load(),
+		Misses:       c.misses.Load(),
+		Coalesced:    c.coalesced.Load(),
+		Entries:      entries,
+	}
+}
+
+// fetch looks up symbol upstream and caches the outcome
+func (c *CachingProvider) fetch(ctx context.Context, symbol string) (*Quote, error) {
+	quote, err := c.provider.Quote(ctx, symbol)
+	switch {
+	case err == nil:
+		if c.options.TTL > 0 {
+			c.set(&quoteEntry{symbol: symbol, quote: quote, expiresAt: time.Now().Add(c.options.TTL)})
+		}
+	case errors.Is(err, ErrSymbolNotFound):
+		if c.options.NegativeTTL > 0 {
+			c.set(&quoteEntry{symbol: symbol, expiresAt: time.Now().Add(c.options.NegativeTTL)})
+		}
+	}
+	// Other failures are not cached; the provider may be back on the next try
+	return quote, err
+}
+
+func (c *CachingProvider) get(symbol string) (*quoteEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[symbol]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*quoteEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, symbol)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry, true
+}
+
+func (c *CachingProvider) set(entry *quoteEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[entry.symbol]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[entry.symbol] = c.order.PushFront(entry)
+	for c.order.Len() > c.options.MaxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*quoteEntry).symbol)
+	}
+}
+
+// copyQuote returns a copy so callers cannot modify the cached quote
+func copyQuote(quote *Quote) *Quote {
+	copied := *quote
+	return &copied
+}
@@ -0,0 +1,74 @@
+package quotes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// chain asks each provider in turn until one returns a quote
+type chain struct {
+	providers []QuoteProvider
+}
+
+// NewChain creates a provider that fails over between providers in order.
+// A symbol is only reported as not found when every provider agrees.
+func NewChain(providers ...QuoteProvider) QuoteProvider {
+	return &chain{providers: providers}
+}
+
+// NewChainByName creates a failover chain of the named providers, e.g.
+// "stooq", in order
+func NewChainByName(names []string, stooqOptions StooqOptions) (QuoteProvider, error) {
+	var providers []QuoteProvider
+	for _, name := range names {
+		switch name {
+		case "stooq":
+			providers = append(providers, NewStooqProvider(stooqOptions))
+		default:
+			return nil, fmt.Errorf("unknown quote provider %q", name)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, ErrNoProviders
+	}
+	return NewChain(providers...), nil
+}
+
+// Name lists the providers in the chain
+func (c *chain) Name() string {
+	names := make([]string, len(c.providers))
+	for i, provider := range c.providers {
+		names[i] = provider.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Quote returns the first quote found by a provider in the chain
+func (c *chain) Quote(ctx context.Context, symbol string) (*Quote, error) {
+	if len(c.providers) == 0 {
+		return nil, ErrNoProviders
+	}
+
+	var failures []error
+	for _, provider := range c.providers {
+		quote, err := provider.Quote(ctx, symbol)
+		if err == nil {
+			return quote, nil
+		}
+		if errors.Is(err, ErrSymbolNotFound) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		failures = append(failures, fmt.Errorf("%s: %w", provider.Name(), err))
+	}
+
+	if len(failures) == 0 {
+		return nil, ErrSymbolNotFound
+	}
+	// At least one provider could not be reached, so the symbol may still exist
+	return nil, errors.Join(failures...)
+}
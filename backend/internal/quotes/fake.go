@@ -0,0 +1,78 @@
+package quotes
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// FakeProvider serves quotes from memory, for tests and local development
+type FakeProvider struct {
+	mutex  sync.Mutex
+	name   string
+	quotes map[string]*Quote
+	errors map[string]error
+	calls  map[string]int
+}
+
+// NewFakeProvider creates an empty fake provider
+func NewFakeProvider(name string) *FakeProvider {
+	return &FakeProvider{
+		name:   name,
+		quotes: make(map[string]*Quote),
+		errors: make(map[string]error),
+		calls:  make(map[string]int),
+	}
+}
+
+// Set makes the provider return quote for its symbol
+func (p *FakeProvider) Set(quote *Quote) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	symbol := strings.ToLower(quote.Symbol)
+	p.quotes[symbol] = quote
+	delete(p.errors, symbol)
+}
+
+// Fail makes the provider return err for symbol
+func (p *FakeProvider) Fail(symbol string, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.errors[strings.ToLower(symbol)] = err
+}
+
+// Calls returns how many times symbol was looked up
+func (p *FakeProvider) Calls(symbol string) int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.calls[strings.ToLower(symbol)]
+}
+
+// Name returns the provider name
+func (p *FakeProvider) Name() string {
+	return p.name
+}
+
+// Quote returns the configured quote or error for symbol
+func (p *FakeProvider) Quote(ctx context.Context, symbol string) (*Quote, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	symbol = strings.ToLower(symbol)
+	p.calls[symbol]++
+
+	if err, ok := p.errors[symbol]; ok {
+		return nil, err
+	}
+	quote, ok := p.quotes[symbol]
+	if !ok {
+		return nil, ErrSymbolNotFound
+	}
+
+	copied := *quote
+	copied.Provider = p.name
+	return &copied, nil
+}
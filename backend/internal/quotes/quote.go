@@ -0,0 +1,36 @@
+package quotes
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrSymbolNotFound is returned when a provider has no data for a symbol
+	ErrSymbolNotFound = errors.New("stock symbol not found")
+
+	// ErrNoProviders is returned by a chain with nothing to ask
+	ErrNoProviders = errors.New("no quote providers configured")
+)
+
+// Quote is a snapshot of a stock's trading day
+type Quote struct {
+	Symbol   string
+	Time     time.Time
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+	Volume   int64
+	Provider string
+}
+
+// QuoteProvider looks up stock quotes from a market data source
+type QuoteProvider interface {
+	// Name identifies the provider in logs and errors
+	Name() string
+
+	// Quote returns the latest quote for symbol, or ErrSymbolNotFound
+	Quote(ctx context.Context, symbol string) (*Quote, error)
+}
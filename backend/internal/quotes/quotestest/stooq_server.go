@@ -0,0 +1,87 @@
+// Package quotestest provides stand-ins for market data services
+package quotestest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"jobsity-backend/internal/quotes"
+)
+
+// StooqServer is an httptest server that answers like the stooq.com CSV API
+type StooqServer struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	quotes   map[string]*quotes.Quote
+	status   int
+	requests int
+}
+
+// NewStooqServer starts a stooq stand-in serving the given quotes
+func NewStooqServer(initial ...*quotes.Quote) *StooqServer {
+	s := &StooqServer{
+		quotes: make(map[string]*quotes.Quote),
+		status: http.StatusOK,
+	}
+	for _, quote := range initial {
+		s.Set(quote)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Set adds or replaces the quote for a symbol
+func (s *StooqServer) Set(quote *quotes.Quote) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.quotes[strings.ToLower(quote.Symbol)] = quote
+}
+
+// FailWith makes every request answer with status; use http.StatusOK to recover
+func (s *StooqServer) FailWith(status int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.status = status
+}
+
+// Requests returns how many quote requests were served
+func (s *StooqServer) Requests() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests
+}
+
+func (s *StooqServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests++
+
+	if r.URL.Path != "/q/l/" {
+		http.NotFound(w, r)
+		return
+	}
+	if s.status != http.StatusOK {
+		w.WriteHeader(s.status)
+		return
+	}
+
+	symbol := strings.ToLower(r.URL.Query().Get("s"))
+	w.Header().Set("Content-Type", "text/csv")
+	fmt.Fprint(w, "Symbol,Date,Time,Open,High,Low,Close,Volume\r\n")
+
+	quote, ok := s.quotes[symbol]
+	if !ok {
+		// stooq answers unknown symbols with a row of N/D values
+		fmt.Fprintf(w, "%s,N/D,N/D,N/D,N/D,N/D,N/D,N/D\r\n", strings.ToUpper(symbol))
+		return
+	}
+	fmt.Fprintf(w, "%s,%s,%s,%g,%g,%g,%g,%d\r\n",
+		strings.ToUpper(quote.Symbol),
+		quote.Time.Format("2006-01-02"),
+		quote.Time.Format("15:04:05"),
+		quote.Open, quote.High, quote.Low, quote.Close, quote.Volume)
+}
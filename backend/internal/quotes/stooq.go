@@ -0,0 +1,150 @@
+package quotes
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultStooqURL is the public stooq.com endpoint
+	DefaultStooqURL = "https://stooq.com"
+
+	// stooqNoData marks fields stooq has no value for, e.g. for unknown symbols
+	stooqNoData = "N/D"
+
+	// stooqMaxBytes bounds the size of a quote response
+	stooqMaxBytes = 64 << 10
+)
+
+// StooqOptions configures the stooq provider
+type StooqOptions struct {
+	BaseURL string
+	Timeout time.Duration
+}
+
+// stooqProvider fetches quotes from the stooq.com CSV API
+type stooqProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewStooqProvider creates a quote provider backed by stooq.com
+func NewStooqProvider(options StooqOptions) QuoteProvider {
+	if options.BaseURL == "" {
+		options.BaseURL = DefaultStooqURL
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+
+	return &stooqProvider{
+		baseURL: strings.TrimRight(options.BaseURL, "/"),
+		client:  &http.Client{Timeout: options.Timeout},
+	}
+}
+
+// Name returns the provider name
+func (p *stooqProvider) Name() string {
+	return "stooq"
+}
+
+// Quote fetches the latest quote for symbol
+func (p *stooqProvider) Quote(ctx context.Context, symbol string) (*Quote, error) {
+	// f selects symbol, date, time, open, high, low, close and volume; h adds a header row
+	endpoint := fmt.Sprintf("%s/q/l/?s=%s&f=sd2t2ohlcv&h&e=csv", p.baseURL, url.QueryEscape(strings.ToLower(symbol)))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("stooq returned status code: %d", resp.StatusCode)
+	}
+
+	quote, err := ParseStooqCSV(io.LimitReader(resp.Body, stooqMaxBytes))
+	if err != nil {
+		return nil, err
+	}
+	quote.Provider = p.Name()
+	return quote, nil
+}
+
+// ParseStooqCSV parses a stooq quote response. Columns are located by the
+// header row; a row of N/D values means the symbol is unknown.
+func ParseStooqCSV(r io.Reader) (*Quote, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV response: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("invalid CSV response: expected a header and a data row")
+	}
+
+	columns := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	row := records[1]
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(row) {
+			return stooqNoData
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	if _, ok := columns["close"]; !ok {
+		return nil, fmt.Errorf("invalid CSV response: no close column")
+	}
+	if field("close") == stooqNoData {
+		return nil, ErrSymbolNotFound
+	}
+	closePrice, ok := parseStooqFloat(field("close"))
+	if !ok {
+		return nil, fmt.Errorf("invalid price format: %s", field("close"))
+	}
+
+	quote := &Quote{
+		Symbol: strings.ToUpper(field("symbol")),
+		Close:  closePrice,
+	}
+	quote.Open, _ = parseStooqFloat(field("open"))
+	quote.High, _ = parseStooqFloat(field("high"))
+	quote.Low, _ = parseStooqFloat(field("low"))
+	if volume, ok := parseStooqFloat(field("volume")); ok {
+		quote.Volume = int64(volume)
+	}
+	if t, err := time.Parse("2006-01-02 15:04:05", field("date")+" "+field("time")); err == nil {
+		quote.Time = t
+	}
+
+	return quote, nil
+}
+
+// parseStooqFloat parses a numeric stooq field, reporting false for N/D
+func parseStooqFloat(value string) (float64, bool) {
+	if value == "" || value == stooqNoData {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many takes pass between removals of full buckets
+const sweepEvery = 1024
+
+// bucket is the state of one token bucket
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // when the bucket will be full again
+}
+
+// MemoryStore keeps token buckets in process memory. Limits are only shared
+// by requests served by the same process.
+type MemoryStore struct {
+	mutex   sync.Mutex
+	buckets map[string]*bucket
+	takes   int
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Take takes a token from the bucket under key
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Decision, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.takes++
+	if s.takes%sweepEvery == 0 {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	decision, tokens := take(b.tokens, b.updated, limit, now)
+	b.tokens = tokens
+	if now.After(b.updated) {
+		b.updated = now
+	}
+	b.full = b.updated.Add(time.Duration((float64(limit.Burst) - tokens) * float64(limit.interval())))
+	return decision, nil
+}
+
+// Len returns the number of buckets held
+func (s *MemoryStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.buckets)
+}
+
+// sweep removes buckets that have refilled, since a new bucket starts full
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !b.full.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+}
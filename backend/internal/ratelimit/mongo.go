@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore keeps token buckets in a MongoDB collection so every server
+// replica enforces the same limits. Each take is a single atomic update.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a store backed by collection
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{
+		collection: collection,
+	}
+}
+
+// EnsureIndexes creates the TTL index that removes buckets once they have
+// refilled
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Take takes a token from the bucket under key
+func (s *MongoStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Decision, error) {
+	burst := float64(limit.Burst)
+	intervalMillis := float64(limit.interval()) / float64(time.Millisecond)
+	updatedAt := bson.M{"$ifNull": bson.A{"$updated_at", now}}
+
+	// Refill since the last take, then take a token if there is one; a
+	// missing bucket starts full
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"tokens": bson.M{"$min": bson.A{burst, bson.M{"$add": bson.A{
+				bson.M{"$ifNull": bson.A{"$tokens", burst}},
+				bson.M{"$divide": bson.A{
+					bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{now, updatedAt}}}},
+					intervalMillis,
+				}},
+			}}}},
+			"updated_at": bson.M{"$max": bson.A{now, updatedAt}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"allowed": bson.M{"$gte": bson.A{"$tokens", 1}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"tokens": bson.M{"$cond": bson.A{"$allowed", bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"expires_at": bson.M{"$add": bson.A{"$updated_at", bson.M{"$multiply": bson.A{
+				bson.M{"$subtract": bson.A{burst, "$tokens"}},
+				intervalMillis,
+			}}}},
+		}}},
+	}
+
+	var result struct {
+		Tokens  float64 `bson:"tokens"`
+		Allowed bool    `bson:"allowed"`
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := s.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&result)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	if !result.Allowed {
+		return Decision{RetryAfter: time.Duration((1 - result.Tokens) * float64(limit.interval()))}, nil
+	}
+	return Decision{Allowed: true, Remaining: int(result.Tokens)}, nil
+}
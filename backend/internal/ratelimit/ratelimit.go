@@ -0,0 +1,150 @@
+// Package ratelimit limits how often each user may perform an action, using
+// token buckets kept in a Store. A bucket holds up to Limit.Burst tokens and
+// refills continuously at Limit.Burst tokens per Limit.Per; every request
+// takes one token and is rejected when the bucket is empty.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Action identifies what a user is rate limited for
+type Action string
+
+// Rate limited actions
+const (
+	ActionPost    Action = "post"
+	ActionCommand Action = "command"
+	ActionUpload  Action = "upload"
+	ActionLogin   Action = "login"
+)
+
+// Limit is a token bucket allowing Burst requests per Per. The zero Limit
+// allows everything.
+type Limit struct {
+	Burst int
+	Per   time.Duration
+}
+
+// Unlimited reports whether the limit lets every request through
+func (l Limit) Unlimited() bool {
+	return l.Burst <= 0 || l.Per <= 0
+}
+
+// interval returns how long the bucket takes to regain one token
+func (l Limit) interval() time.Duration {
+	return l.Per / time.Duration(l.Burst)
+}
+
+// String formats the limit as accepted by ParseLimit
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Burst, l.Per)
+}
+
+// ParseLimit parses a limit written as "<requests>/<duration>", e.g. "30/1m".
+// "off" and "0" disable the limit.
+func ParseLimit(text string) (Limit, error) {
+	text = strings.TrimSpace(text)
+	if text == "off" || text == "0" {
+		return Limit{}, nil
+	}
+
+	burstText, perText, ok := strings.Cut(text, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected e.g. 30/1m", text)
+	}
+	burst, err := strconv.Atoi(burstText)
+	if err != nil || burst <= 0 {
+		return Limit{}, fmt.Errorf("invalid request count in rate limit %q", text)
+	}
+	per, err := time.ParseDuration(perText)
+	if err != nil || per <= 0 {
+		return Limit{}, fmt.Errorf("invalid period in rate limit %q", text)
+	}
+	return Limit{Burst: burst, Per: per}, nil
+}
+
+// Decision is the outcome of taking a token
+type Decision struct {
+	Allowed   bool
+	Remaining int
+
+	// RetryAfter is how long until the next request would be allowed; it is
+	// zero for allowed requests
+	RetryAfter time.Duration
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds, as used by the
+// Retry-After header
+func (d Decision) RetryAfterSeconds() int {
+	return int(math.Ceil(d.RetryAfter.Seconds()))
+}
+
+// Store keeps token buckets
+type Store interface {
+	// Take takes a token from the bucket under key
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Decision, error)
+}
+
+// Limiter applies a limit per action to each user
+type Limiter struct {
+	store  Store
+	limits map[Action]Limit
+	now    func() time.Time
+}
+
+// NewLimiter creates a limiter enforcing limits in store. Actions without a
+// limit are not limited.
+func NewLimiter(store Store, limits map[Action]Limit) *Limiter {
+	return &Limiter{
+		store:  store,
+		limits: limits,
+		now:    time.Now,
+	}
+}
+
+// Allow takes a token for key (usually the user's email) performing action.
+// A failing store lets the request through rather than locking users out, as
+// does a nil Limiter.
+func (l *Limiter) Allow(ctx context.Context, action Action, key string) Decision {
+	if l == nil {
+		return Decision{Allowed: true}
+	}
+	limit := l.limits[action]
+	if limit.Unlimited() {
+		return Decision{Allowed: true}
+	}
+
+	decision, err := l.store.Take(ctx, string(action)+":"+key, limit, l.now())
+	if err != nil {
+		log.Printf("Rate limit store failed for %s, allowing request: %v", action, err)
+		return Decision{Allowed: true}
+	}
+	return decision
+}
+
+// take applies the token bucket algorithm to a bucket holding tokens at
+// updated, returning the decision and the bucket's new token count
+func take(tokens float64, updated time.Time, limit Limit, now time.Time) (Decision, float64) {
+	if elapsed := now.Sub(updated); elapsed > 0 {
+		tokens += float64(elapsed) / float64(limit.interval())
+	}
+	tokens = math.Min(tokens, float64(limit.Burst))
+
+	if tokens < 1 {
+		wait := time.Duration((1 - tokens) * float64(limit.interval()))
+		return Decision{RetryAfter: wait}, tokens
+	}
+
+	tokens--
+	return Decision{Allowed: true, Remaining: int(tokens)}, tokens
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"jobsity-backend/pkg/domain"
+	"time"
+)
+
+// AttachmentRepository defines the interface for attachment metadata operations
+type AttachmentRepository interface {
+	// Create creates a new attachment
+	Create(ctx context.Context, attachment *domain.Attachment) error
+
+	// FindByID finds an attachment by ID
+	FindByID(ctx context.Context, id string) (*domain.Attachment, error)
+
+	// FindUnattachedBefore finds attachments not linked to any message that were uploaded before the given time
+	FindUnattachedBefore(ctx context.Context, before time.Time) ([]*domain.Attachment, error)
+
+	// SwapMessage links an attachment to newMessageID if it is still linked
+	// to oldMessageID, "" meaning no message. It fails with ErrConflict if
+	// the attachment is linked to another message and ErrNotFound if it
+	// does not exist.
+	SwapMessage(ctx context.Context, id string, oldMessageID string, newMessageID string) error
+
+	// UpdateProcessed stores the results of background processing for an attachment
+	UpdateProcessed(ctx context.Context, attachment *domain.Attachment) error
+
+	// Delete deletes an attachment by ID
+	Delete(ctx context.Context, id string) error
+
+	// DeleteUnattached deletes an attachment unless it was linked to a
+	// message, failing with ErrConflict if it was
+	DeleteUnattached(ctx context.Context, id string) error
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"jobsity-backend/pkg/domain"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// parseID converts a hex ID to an ObjectID, failing with domain.ErrInvalidID
+func parseID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return objectID, domain.InvalidID(fmt.Sprintf("invalid id %q", id))
+	}
+	return objectID, nil
+}
+
+// mongoError translates MongoDB driver errors to domain errors so services do
+// not depend on the driver
+func mongoError(err error) error {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return domain.ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return conflict(err)
+	}
+	return err
+}
+
+// sqlError translates database/sql errors to domain errors, like mongoError
+func sqlError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return domain.ErrNotFound
+	case uniqueViolation(err):
+		return conflict(err)
+	}
+	return err
+}
+
+// conflict returns ErrConflict for a duplicate key error. The driver's
+// message names collections and indexes, so it is logged rather than
+// returned to clients.
+func conflict(err error) error {
+	log.Printf("Duplicate key: %v", err)
+	return domain.Conflict("already exists")
+}
+
+// uniqueViolation reports whether err is a unique constraint violation from
+// PostgreSQL (SQLSTATE 23505) or SQLite (SQLITE_CONSTRAINT_UNIQUE), without
+// depending on either driver
+func uniqueViolation(err error) bool {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		return pgErr.SQLState() == "23505"
+	}
+	var sqliteErr interface{ Code() int }
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == 2067
+	}
+	return false
+}
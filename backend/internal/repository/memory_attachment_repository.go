@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"jobsity-backend/pkg/domain"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryAttachmentRepository implements AttachmentRepository in process
+// memory, with the same semantics as MongoAttachmentRepository
+type MemoryAttachmentRepository struct {
+	mutex       sync.RWMutex
+	attachments []*domain.Attachment
+}
+
+// NewMemoryAttachmentRepository creates an empty in-memory attachment repository
+func NewMemoryAttachmentRepository() *MemoryAttachmentRepository {
+	return &MemoryAttachmentRepository{}
+}
+
+// Create creates a new attachment
+func (r *MemoryAttachmentRepository) Create(ctx context.Context, attachment *domain.Attachment) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	attachment.CreatedAt = time.Now()
+	attachment.ID = primitive.NewObjectID().Hex()
+
+	stored := *attachment
+	r.attachments = append(r.attachments, &stored)
+	return nil
+}
+
+// FindByID finds an attachment by ID
+func (r *MemoryAttachmentRepository) FindByID(ctx context.Context, id string) (*domain.Attachment, error) {
+	if _, err := parseID(id); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if i := r.index(id); i >= 0 {
+		found := *r.attachments[i]
+		return &found, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+// FindUnattachedBefore finds attachments not linked to any message that were uploaded before the given time
+func (r *MemoryAttachmentRepository) FindUnattachedBefore(ctx context.Context, before time.Time) ([]*domain.Attachment, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var attachments []*domain.Attachment
+	for _, attachment := range r.attachments {
+		if attachment.MessageID == "" && attachment.CreatedAt.Before(before) {
+			found := *attachment
+			attachments = append(attachments, &found)
+		}
+	}
+	return attachments, nil
+}
+
+// SwapMessage links an attachment to newMessageID if it is still linked to
+// oldMessageID
+func (r *MemoryAttachmentRepository) SwapMessage(ctx context.Context, id string, oldMessageID string, newMessageID string) error {
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	i, err := r.linkedTo(id, oldMessageID)
+	if err != nil {
+		return err
+	}
+	r.attachments[i].MessageID = newMessageID
+	return nil
+}
+
+// UpdateProcessed stores the results of background processing for an attachment
+func (r *MemoryAttachmentRepository) UpdateProcessed(ctx context.Context, attachment *domain.Attachment) error {
+	if _, err := parseID(attachment.ID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	i := r.index(attachment.ID)
+	if i < 0 {
+		return nil
+	}
+	stored := r.attachments[i]
+	stored.Size = attachment.Size
+	stored.Width = attachment.Width
+	stored.Height = attachment.Height
+	stored.ThumbnailKey = attachment.ThumbnailKey
+	stored.ThumbnailType = attachment.ThumbnailType
+	stored.ThumbnailWidth = attachment.ThumbnailWidth
+	stored.ThumbnailHeight = attachment.ThumbnailHeight
+	stored.ProcessedAt = attachment.ProcessedAt
+	return nil
+}
+
+// Delete deletes an attachment by ID
+func (r *MemoryAttachmentRepository) Delete(ctx context.Context, id string) error {
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if i := r.index(id); i >= 0 {
+		r.attachments = append(r.attachments[:i], r.attachments[i+1:]...)
+	}
+	return nil
+}
+
+// DeleteUnattached deletes an attachment unless it was linked to a message
+func (r *MemoryAttachmentRepository) DeleteUnattached(ctx context.Context, id string) error {
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	i, err := r.linkedTo(id, "")
+	if err != nil {
+		return err
+	}
+	r.attachments = append(r.attachments[:i], r.attachments[i+1:]...)
+	return nil
+}
+
+// linkedTo returns the position of the attachment with id if it is linked
+// to messageID
+func (r *MemoryAttachmentRepository) linkedTo(id string, messageID string) (int, error) {
+	i := r.index(id)
+	if i < 0 {
+		return -1, domain.NotFound("attachment not found")
+	}
+	if r.attachments[i].MessageID != messageID {
+		return -1, domain.Conflict("attachment is already used by another message")
+	}
+	return i, nil
+}
+
+// index returns the position of the attachment with id, or -1
+func (r *MemoryAttachmentRepository) index(id string) int {
+	for i, attachment := range r.attachments {
+		if attachment.ID == id {
+			return i
+		}
+	}
+	return -1
+}
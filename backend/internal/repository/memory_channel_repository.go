@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"jobsity-backend/pkg/domain"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryChannelRepository implements ChannelRepository in process memory,
+// with the same semantics as MongoChannelRepository
+type MemoryChannelRepository struct {
+	mutex    sync.RWMutex
+	channels []*domain.Channel
+}
+
+// NewMemoryChannelRepository creates an empty in-memory channel repository
+func NewMemoryChannelRepository() *MemoryChannelRepository {
+	return &MemoryChannelRepository{}
+}
+
+// Create creates a new channel
+func (r *MemoryChannelRepository) Create(ctx context.Context, channel *domain.Channel) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	channel.CreatedAt = time.Now()
+	channel.UpdatedAt = time.Now()
+	channel.ID = primitive.NewObjectID().Hex()
+
+	stored := *channel
+	r.channels = append(r.channels, &stored)
+	return nil
+}
+
+// FindByID finds a channel by ID
+func (r *MemoryChannelRepository) FindByID(ctx context.Context, id string) (*domain.Channel, error) {
+	if _, err := parseID(id); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if i := r.index(id); i >= 0 {
+		found := *r.channels[i]
+		return &found, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+// FindByName finds a channel by name
+func (r *MemoryChannelRepository) FindByName(ctx context.Context, name string) (*domain.Channel, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, channel := range r.channels {
+		if channel.Name == name {
+			found := *channel
+			return &found, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+// FindAll returns all channels in the order they were created
+func (r *MemoryChannelRepository) FindAll(ctx context.Context) ([]*domain.Channel, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var channels []*domain.Channel
+	for _, channel := range r.channels {
+		found := *channel
+		channels = append(channels, &found)
+	}
+	return channels, nil
+}
+
+// Update updates an existing channel
+func (r *MemoryChannelRepository) Update(ctx context.Context, channel *domain.Channel) error {
+	if _, err := parseID(channel.ID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	channel.UpdatedAt = time.Now()
+	if i := r.index(channel.ID); i >= 0 {
+		r.channels[i].Name = channel.Name
+		r.channels[i].Description = channel.Description
+		r.channels[i].UpdatedAt = channel.UpdatedAt
+	}
+	return nil
+}
+
+// Delete deletes a channel by ID
+func (r *MemoryChannelRepository) Delete(ctx context.Context, id string) error {
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if i := r.index(id); i >= 0 {
+		r.channels = append(r.channels[:i], r.channels[i+1:]...)
+	}
+	return nil
+}
+
+// index returns the position of the channel with id, or -1
+func (r *MemoryChannelRepository) index(id string) int {
+	for i, channel := range r.channels {
+		if channel.ID == id {
+			return i
+		}
+	}
+	return -1
+}
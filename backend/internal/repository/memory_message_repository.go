@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"jobsity-backend/pkg/domain"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryMessageRepository implements MessageRepository in process memory,
+// with the same semantics as MongoMessageRepository
+type MemoryMessageRepository struct {
+	mutex    sync.RWMutex
+	messages []*domain.Message
+}
+
+// NewMemoryMessageRepository creates an empty in-memory message repository
+func NewMemoryMessageRepository() *MemoryMessageRepository {
+	return &MemoryMessageRepository{}
+}
+
+// Create creates a new message. Like the unique index of the other
+// repositories, it fails with ErrConflict if another message has the same
+// correlation ID.
+func (r *MemoryMessageRepository) Create(ctx context.Context, message *domain.Message) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if message.CorrelationID != "" {
+		for _, stored := range r.messages {
+			if stored.CorrelationID == message.CorrelationID {
+				return domain.Conflict("already exists")
+			}
+		}
+	}
+
+	message.CreatedAt = time.Now()
+	message.ID = primitive.NewObjectID().Hex()
+
+	r.messages = append(r.messages, copyMessage(message))
+	return nil
+}
+
+// FindByID finds a message by ID
+func (r *MemoryMessageRepository) FindByID(ctx context.Context, id string) (*domain.Message, error) {
+	if _, err := parseID(id); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if i := r.index(id); i >= 0 {
+		return r.read(r.messages[i]), nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+// FindByCorrelationID finds the message created for a queued command's reply
+func (r *MemoryMessageRepository) FindByCorrelationID(ctx context.Context, correlationID string) (*domain.Message, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, message := range r.messages {
+		if message.CorrelationID == correlationID {
+			return r.read(message), nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+// FindByChannelID finds the newest limit messages of a channel, oldest first
+func (r *MemoryMessageRepository) FindByChannelID(ctx context.Context, channelID string, limit int) ([]*domain.Message, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var messages []*domain.Message
+	for _, message := range r.messages {
+		if message.ChannelID == channelID {
+			messages = append(messages, r.read(message))
+		}
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+	if limit > 0 && len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return messages, nil
+}
+
+// Update updates an existing message
+func (r *MemoryMessageRepository) Update(ctx context.Context, message *domain.Message) error {
+	if _, err := parseID(message.ID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if i := r.index(message.ID); i >= 0 {
+		r.messages[i].Content = message.Content
+		r.messages[i].ContentHTML = message.ContentHTML
+		r.messages[i].ContentText = message.ContentText
+	}
+	return nil
+}
+
+// UpdateAttachment replaces the copy of an attachment embedded in a message
+func (r *MemoryMessageRepository) UpdateAttachment(ctx context.Context, messageID string, attachment *domain.Attachment) error {
+	if _, err := parseID(messageID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	i := r.index(messageID)
+	if i < 0 {
+		return nil
+	}
+	for j, embedded := range r.messages[i].Attachments {
+		if embedded.ID == attachment.ID {
+			replaced := *attachment
+			r.messages[i].Attachments[j] = &replaced
+			break
+		}
+	}
+	return nil
+}
+
+// UpdatePreviews replaces the link previews of a message whose content is
+// still content
+func (r *MemoryMessageRepository) UpdatePreviews(ctx context.Context, messageID string, content string, previews []*domain.LinkPreview) error {
+	if _, err := parseID(messageID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	i := r.index(messageID)
+	if i < 0 || r.messages[i].Content != content {
+		return domain.NotFound("message not found or edited")
+	}
+	r.messages[i].Previews = copyPreviews(previews)
+	return nil
+}
+
+// Delete deletes a message by ID
+func (r *MemoryMessageRepository) Delete(ctx context.Context, id string) error {
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if i := r.index(id); i >= 0 {
+		r.messages = append(r.messages[:i], r.messages[i+1:]...)
+	}
+	return nil
+}
+
+// index returns the position of the message with id, or -1
+func (r *MemoryMessageRepository) index(id string) int {
+	for i, message := range r.messages {
+		if message.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// read copies a stored message for a caller, as decoding it from Mongo would
+func (r *MemoryMessageRepository) read(message *domain.Message) *domain.Message {
+	found := copyMessage(message)
+	defaultAuthorType(found)
+	return found
+}
+
+// copyMessage copies a message together with its attachments and previews so
+// stored messages never share memory with callers. Ephemeral is not stored.
+func copyMessage(message *domain.Message) *domain.Message {
+	copied := *message
+	copied.Ephemeral = false
+	copied.Previews = copyPreviews(message.Previews)
+
+	if message.Attachments != nil {
+		copied.Attachments = make([]*domain.Attachment, len(message.Attachments))
+		for i, attachment := range message.Attachments {
+			embedded := *attachment
+			copied.Attachments[i] = &embedded
+		}
+	}
+	return &copied
+}
+
+// copyPreviews copies a list of link previews
+func copyPreviews(previews []*domain.LinkPreview) []*domain.LinkPreview {
+	if previews == nil {
+		return nil
+	}
+
+	copied := make([]*domain.LinkPreview, len(previews))
+	for i, preview := range previews {
+		p := *preview
+		copied[i] = &p
+	}
+	return copied
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"jobsity-backend/pkg/domain"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryOutboxRepository implements OutboxRepository in process memory, with
+// the same semantics as MongoOutboxRepository. Delivered events are dropped
+// instead of being kept until they expire.
+type MemoryOutboxRepository struct {
+	mutex  sync.Mutex
+	events []*domain.OutboxEvent
+}
+
+// NewMemoryOutboxRepository creates an empty in-memory outbox repository
+func NewMemoryOutboxRepository() *MemoryOutboxRepository {
+	return &MemoryOutboxRepository{}
+}
+
+// Add stores a new undelivered event
+func (r *MemoryOutboxRepository) Add(ctx context.Context, event *domain.OutboxEvent) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	event.CreatedAt = time.Now()
+	event.ClaimedUntil = event.CreatedAt
+	event.DeliveredAt = nil
+	event.ID = primitive.NewObjectID().Hex()
+
+	stored := *event
+	r.events = append(r.events, &stored)
+	return nil
+}
+
+// ClaimPending claims up to limit undelivered events, oldest first
+func (r *MemoryOutboxRepository) ClaimPending(ctx context.Context, limit int, until time.Time) ([]*domain.OutboxEvent, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	var events []*domain.OutboxEvent
+	for _, event := range r.events {
+		if len(events) == limit {
+			break
+		}
+		if event.ClaimedUntil.After(now) {
+			continue
+		}
+		event.ClaimedUntil = until
+		claimed := *event
+		events = append(events, &claimed)
+	}
+	return events, nil
+}
+
+// MarkDelivered drops a published event
+func (r *MemoryOutboxRepository) MarkDelivered(ctx context.Context, id string, deliveredAt time.Time) error {
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, event := range r.events {
+		if event.ID == id {
+			r.events = append(r.events[:i], r.events[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Len returns the number of undelivered events
+func (r *MemoryOutboxRepository) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.events)
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"jobsity-backend/pkg/domain"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryStockAlertRepository implements StockAlertRepository in process
+// memory, with the same semantics as MongoStockAlertRepository
+type MemoryStockAlertRepository struct {
+	mutex  sync.RWMutex
+	alerts []*domain.StockAlert
+}
+
+// NewMemoryStockAlertRepository creates an empty in-memory stock alert repository
+func NewMemoryStockAlertRepository() *MemoryStockAlertRepository {
+	return &MemoryStockAlertRepository{}
+}
+
+// Create creates a new alert
+func (r *MemoryStockAlertRepository) Create(ctx context.Context, alert *domain.StockAlert) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	alert.CreatedAt = time.Now()
+	alert.ID = primitive.NewObjectID().Hex()
+
+	stored := *alert
+	r.alerts = append(r.alerts, &stored)
+	return nil
+}
+
+// FindByID finds an alert by ID
+func (r *MemoryStockAlertRepository) FindByID(ctx context.Context, id string) (*domain.StockAlert, error) {
+	if _, err := parseID(id); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if i := r.index(id); i >= 0 {
+		found := *r.alerts[i]
+		return &found, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+// FindActive returns every alert that has not been triggered yet
+func (r *MemoryStockAlertRepository) FindActive(ctx context.Context) ([]*domain.StockAlert, error) {
+	return r.find(func(alert *domain.StockAlert) bool {
+		return alert.TriggeredAt == nil
+	}), nil
+}
+
+// FindActiveByUser returns a user's untriggered alerts in a channel
+func (r *MemoryStockAlertRepository) FindActiveByUser(ctx context.Context, channelID, userEmail string) ([]*domain.StockAlert, error) {
+	return r.find(func(alert *domain.StockAlert) bool {
+		return alert.ChannelID == channelID && alert.UserEmail == userEmail && alert.TriggeredAt == nil
+	}), nil
+}
+
+// CountActiveByUser counts a user's untriggered alerts across all channels
+func (r *MemoryStockAlertRepository) CountActiveByUser(ctx context.Context, userEmail string) (int64, error) {
+	alerts := r.find(func(alert *domain.StockAlert) bool {
+		return alert.UserEmail == userEmail && alert.TriggeredAt == nil
+	})
+	return int64(len(alerts)), nil
+}
+
+// MarkTriggered marks an alert as triggered unless it already was
+func (r *MemoryStockAlertRepository) MarkTriggered(ctx context.Context, id string, triggeredAt time.Time) (bool, error) {
+	if _, err := parseID(id); err != nil {
+		return false, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	i := r.index(id)
+	if i < 0 || r.alerts[i].TriggeredAt != nil {
+		return false, nil
+	}
+	r.alerts[i].TriggeredAt = &triggeredAt
+	return true, nil
+}
+
+// Rearm clears the triggered mark of an alert
+func (r *MemoryStockAlertRepository) Rearm(ctx context.Context, id string) error {
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if i := r.index(id); i >= 0 {
+		r.alerts[i].TriggeredAt = nil
+	}
+	return nil
+}
+
+// Delete deletes an alert by ID
+func (r *MemoryStockAlertRepository) Delete(ctx context.Context, id string) error {
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if i := r.index(id); i >= 0 {
+		r.alerts = append(r.alerts[:i], r.alerts[i+1:]...)
+	}
+	return nil
+}
+
+// find returns copies of the alerts matching match, oldest first
+func (r *MemoryStockAlertRepository) find(match func(alert *domain.StockAlert) bool) []*domain.StockAlert {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var alerts []*domain.StockAlert
+	for _, alert := range r.alerts {
+		if match(alert) {
+			found := *alert
+			alerts = append(alerts, &found)
+		}
+	}
+
+	sort.SliceStable(alerts, func(i, j int) bool {
+		return alerts[i].CreatedAt.Before(alerts[j].CreatedAt)
+	})
+	return alerts
+}
+
+// index returns the position of the alert with id, or -1
+func (r *MemoryStockAlertRepository) index(id string) int {
+	for i, alert := range r.alerts {
+		if alert.ID == id {
+			return i
+		}
+	}
+	return -1
+}
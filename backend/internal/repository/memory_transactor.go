@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryTransactor implements Transactor for the in-memory repositories. It
+// runs one transaction at a time but cannot roll back, so writes made before
+// fn fails are kept.
+type MemoryTransactor struct {
+	mutex sync.Mutex
+}
+
+// NewMemoryTransactor creates an in-memory transactor
+func NewMemoryTransactor() *MemoryTransactor {
+	return &MemoryTransactor{}
+}
+
+// WithTransaction runs fn once, after any transaction already running
+func (t *MemoryTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return fn(ctx)
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"jobsity-backend/pkg/domain"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryUserRepository implements UserRepository in process memory. It
+// behaves like MongoUserRepository: lookups that match nothing fail with
+// domain.ErrNotFound and malformed IDs with domain.ErrInvalidID.
+type MemoryUserRepository struct {
+	mutex sync.RWMutex
+	users []*domain.User
+}
+
+// NewMemoryUserRepository creates an empty in-memory user repository
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{}
+}
+
+// FindByEmail finds a user by email
+func (r *MemoryUserRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+// Create creates a new user
+func (r *MemoryUserRepository) Create(ctx context.Context, user *domain.User) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	user.CreatedAt = time.Now()
+	user.ID = primitive.NewObjectID().Hex()
+
+	stored := *user
+	r.users = append(r.users, &stored)
+	return nil
+}
+
+// FindByID finds a user by ID
+func (r *MemoryUserRepository) FindByID(ctx context.Context, id string) (*domain.User, error) {
+	if _, err := parseID(id); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if i := r.index(id); i >= 0 {
+		found := *r.users[i]
+		return &found, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+// Update updates an existing user
+func (r *MemoryUserRepository) Update(ctx context.Context, user *domain.User) error {
+	if _, err := parseID(user.ID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if i := r.index(user.ID); i >= 0 {
+		r.users[i].Email = user.Email
+		r.users[i].Password = user.Password
+	}
+	return nil
+}
+
+// Delete deletes a user by ID
+func (r *MemoryUserRepository) Delete(ctx context.Context, id string) error {
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if i := r.index(id); i >= 0 {
+		r.users = append(r.users[:i], r.users[i+1:]...)
+	}
+	return nil
+}
+
+// index returns the position of the user with id, or -1
+func (r *MemoryUserRepository) index(id string) int {
+	for i, user := range r.users {
+		if user.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"jobsity-backend/pkg/domain"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryWatchlistRepository implements WatchlistRepository in process
+// memory, with the same semantics as MongoWatchlistRepository
+type MemoryWatchlistRepository struct {
+	mutex      sync.RWMutex
+	watchlists []*domain.Watchlist
+}
+
+// NewMemoryWatchlistRepository creates an empty in-memory watchlist repository
+func NewMemoryWatchlistRepository() *MemoryWatchlistRepository {
+	return &MemoryWatchlistRepository{}
+}
+
+// FindByChannel finds the watchlist of a channel
+func (r *MemoryWatchlistRepository) FindByChannel(ctx context.Context, channelID string) (*domain.Watchlist, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if i := r.indexByChannel(channelID); i >= 0 {
+		return copyWatchlist(r.watchlists[i]), nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+// Save creates or replaces the watchlist of a channel
+func (r *MemoryWatchlistRepository) Save(ctx context.Context, watchlist *domain.Watchlist) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	watchlist.UpdatedAt = now
+
+	i := r.indexByChannel(watchlist.ChannelID)
+	if i < 0 {
+		watchlist.ID = primitive.NewObjectID().Hex()
+		watchlist.CreatedAt = now
+		r.watchlists = append(r.watchlists, copyWatchlist(watchlist))
+		return nil
+	}
+
+	stored := r.watchlists[i]
+	stored.StockCodes = append([]string(nil), watchlist.StockCodes...)
+	stored.Schedule = watchlist.Schedule
+	stored.NextRunAt = watchlist.NextRunAt
+	stored.UpdatedBy = watchlist.UpdatedBy
+	stored.UpdatedAt = watchlist.UpdatedAt
+	return nil
+}
+
+// FindDue returns the watchlists whose next run is at or before now
+func (r *MemoryWatchlistRepository) FindDue(ctx context.Context, now time.Time) ([]*domain.Watchlist, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var watchlists []*domain.Watchlist
+	for _, watchlist := range r.watchlists {
+		if !watchlist.NextRunAt.After(now) {
+			watchlists = append(watchlists, copyWatchlist(watchlist))
+		}
+	}
+	return watchlists, nil
+}
+
+// ClaimRun moves a watchlist from its scheduled run to nextRunAt
+func (r *MemoryWatchlistRepository) ClaimRun(ctx context.Context, id string, scheduledAt, ranAt, nextRunAt time.Time) (bool, error) {
+	if _, err := parseID(id); err != nil {
+		return false, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, watchlist := range r.watchlists {
+		if watchlist.ID == id && watchlist.NextRunAt.Equal(scheduledAt) {
+			watchlist.NextRunAt = nextRunAt
+			watchlist.LastRunAt = &ranAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Delete deletes the watchlist of a channel
+func (r *MemoryWatchlistRepository) Delete(ctx context.Context, channelID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if i := r.indexByChannel(channelID); i >= 0 {
+		r.watchlists = append(r.watchlists[:i], r.watchlists[i+1:]...)
+	}
+	return nil
+}
+
+// indexByChannel returns the position of a channel's watchlist, or -1
+func (r *MemoryWatchlistRepository) indexByChannel(channelID string) int {
+	for i, watchlist := range r.watchlists {
+		if watchlist.ChannelID == channelID {
+			return i
+		}
+	}
+	return -1
+}
+
+// copyWatchlist copies a watchlist together with its stock codes
+func copyWatchlist(watchlist *domain.Watchlist) *domain.Watchlist {
+	copied := *watchlist
+	copied.StockCodes = append([]string(nil), watchlist.StockCodes...)
+	return &copied
+}
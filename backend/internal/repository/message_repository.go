@@ -13,12 +13,23 @@ type MessageRepository interface {
 	// FindByID finds a message by ID
 	FindByID(ctx context.Context, id string) (*domain.Message, error)
 
+	// FindByCorrelationID finds the message created for a queued command's reply
+	FindByCorrelationID(ctx context.Context, correlationID string) (*domain.Message, error)
+
 	// FindByChannelID finds all messages for a specific channel
 	FindByChannelID(ctx context.Context, channelID string, limit int) ([]*domain.Message, error)
 
 	// Update updates an existing message
 	Update(ctx context.Context, message *domain.Message) error
 
+	// UpdateAttachment replaces the copy of an attachment embedded in a message
+	UpdateAttachment(ctx context.Context, messageID string, attachment *domain.Attachment) error
+
+	// UpdatePreviews replaces the link previews of a message whose content
+	// is still content. It fails with ErrNotFound if the message was deleted
+	// or edited since.
+	UpdatePreviews(ctx context.Context, messageID string, content string, previews []*domain.LinkPreview) error
+
 	// Delete deletes a message by ID
 	Delete(ctx context.Context, id string) error
 }
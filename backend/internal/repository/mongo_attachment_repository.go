@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"jobsity-backend/pkg/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoAttachmentRepository implements AttachmentRepository using MongoDB
+type MongoAttachmentRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoAttachmentRepository creates a new MongoDB attachment repository
+func NewMongoAttachmentRepository(collection *mongo.Collection) *MongoAttachmentRepository {
+	return &MongoAttachmentRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new attachment
+func (r *MongoAttachmentRepository) Create(ctx context.Context, attachment *domain.Attachment) error {
+	attachment.CreatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, attachment)
+	if err != nil {
+		return mongoError(err)
+	}
+
+	// Convert ObjectID to string
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		attachment.ID = oid.Hex()
+	}
+
+	return nil
+}
+
+// FindByID finds an attachment by ID
+func (r *MongoAttachmentRepository) FindByID(ctx context.Context, id string) (*domain.Attachment, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachment domain.Attachment
+	filter := bson.M{"_id": objectID}
+	err = r.collection.FindOne(ctx, filter).Decode(&attachment)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	return &attachment, nil
+}
+
+// FindUnattachedBefore finds attachments not linked to any message that were uploaded before the given time
+func (r *MongoAttachmentRepository) FindUnattachedBefore(ctx context.Context, before time.Time) ([]*domain.Attachment, error) {
+	filter := bson.M{
+		"message_id": messageIDFilter(""),
+		"created_at": bson.M{"$lt": before},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var attachments []*domain.Attachment
+	for cursor.Next(ctx) {
+		var attachment domain.Attachment
+		if err := cursor.Decode(&attachment); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, &attachment)
+	}
+
+	return attachments, nil
+}
+
+// SwapMessage links an attachment to newMessageID if it is still linked to
+// oldMessageID
+func (r *MongoAttachmentRepository) SwapMessage(ctx context.Context, id string, oldMessageID string, newMessageID string) error {
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": objectID, "message_id": messageIDFilter(oldMessageID)}
+	update := bson.M{"$set": bson.M{
+		"message_id": newMessageID,
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return r.missOrConflict(ctx, objectID)
+	}
+	return nil
+}
+
+// UpdateProcessed stores the results of background processing for an attachment
+func (r *MongoAttachmentRepository) UpdateProcessed(ctx context.Context, attachment *domain.Attachment) error {
+	objectID, err := parseID(attachment.ID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": objectID}
+	update := bson.M{"$set": bson.M{
+		"size":             attachment.Size,
+		"width":            attachment.Width,
+		"height":           attachment.Height,
+		"thumbnail_key":    attachment.ThumbnailKey,
+		"thumbnail_type":   attachment.ThumbnailType,
+		"thumbnail_width":  attachment.ThumbnailWidth,
+		"thumbnail_height": attachment.ThumbnailHeight,
+		"processed_at":     attachment.ProcessedAt,
+	}}
+
+	_, err = r.collection.UpdateOne(ctx, filter, update)
+	return mongoError(err)
+}
+
+// Delete deletes an attachment by ID
+func (r *MongoAttachmentRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": objectID}
+	_, err = r.collection.DeleteOne(ctx, filter)
+	return mongoError(err)
+}
+
+// DeleteUnattached deletes an attachment unless it was linked to a message
+func (r *MongoAttachmentRepository) DeleteUnattached(ctx context.Context, id string) error {
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": objectID, "message_id": messageIDFilter("")}
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.DeletedCount == 0 {
+		return r.missOrConflict(ctx, objectID)
+	}
+	return nil
+}
+
+// missOrConflict explains why a conditional write matched no attachment
+func (r *MongoAttachmentRepository) missOrConflict(ctx context.Context, objectID primitive.ObjectID) error {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return mongoError(err)
+	}
+	if count == 0 {
+		return domain.NotFound("attachment not found")
+	}
+	return domain.Conflict("attachment is already used by another message")
+}
+
+// messageIDFilter matches attachments linked to messageID, or to no message
+// if it is ""
+func messageIDFilter(messageID string) interface{} {
+	if messageID == "" {
+		// $in with nil also matches documents where message_id is missing
+		return bson.M{"$in": bson.A{nil, ""}}
+	}
+	return messageID
+}
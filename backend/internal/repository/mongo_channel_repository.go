@@ -29,7 +29,7 @@ func (r *MongoChannelRepository) Create(ctx context.Context, channel *domain.Cha
 
 	result, err := r.collection.InsertOne(ctx, channel)
 	if err != nil {
-		return err
+		return mongoError(err)
 	}
 
 	// Convert ObjectID to string
@@ -42,7 +42,7 @@ func (r *MongoChannelRepository) Create(ctx context.Context, channel *domain.Cha
 
 // FindByID finds a channel by ID
 func (r *MongoChannelRepository) FindByID(ctx context.Context, id string) (*domain.Channel, error) {
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := parseID(id)
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +51,7 @@ func (r *MongoChannelRepository) FindByID(ctx context.Context, id string) (*doma
 	filter := bson.M{"_id": objectID}
 	err = r.collection.FindOne(ctx, filter).Decode(&channel)
 	if err != nil {
-		return nil, err
+		return nil, mongoError(err)
 	}
 	return &channel, nil
 }
@@ -62,7 +62,7 @@ func (r *MongoChannelRepository) FindByName(ctx context.Context, name string) (*
 	filter := bson.M{"name": name}
 	err := r.collection.FindOne(ctx, filter).Decode(&channel)
 	if err != nil {
-		return nil, err
+		return nil, mongoError(err)
 	}
 	return &channel, nil
 }
@@ -71,7 +71,7 @@ func (r *MongoChannelRepository) FindByName(ctx context.Context, name string) (*
 func (r *MongoChannelRepository) FindAll(ctx context.Context) ([]*domain.Channel, error) {
 	cursor, err := r.collection.Find(ctx, bson.M{})
 	if err != nil {
-		return nil, err
+		return nil, mongoError(err)
 	}
 	defer cursor.Close(ctx)
 
@@ -89,7 +89,7 @@ func (r *MongoChannelRepository) FindAll(ctx context.Context) ([]*domain.Channel
 
 // Update updates an existing channel
 func (r *MongoChannelRepository) Update(ctx context.Context, channel *domain.Channel) error {
-	objectID, err := primitive.ObjectIDFromHex(channel.ID)
+	objectID, err := parseID(channel.ID)
 	if err != nil {
 		return err
 	}
@@ -103,17 +103,17 @@ func (r *MongoChannelRepository) Update(ctx context.Context, channel *domain.Cha
 	}}
 
 	_, err = r.collection.UpdateOne(ctx, filter, update)
-	return err
+	return mongoError(err)
 }
 
 // Delete deletes a channel by ID
 func (r *MongoChannelRepository) Delete(ctx context.Context, id string) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := parseID(id)
 	if err != nil {
 		return err
 	}
 
 	filter := bson.M{"_id": objectID}
 	_, err = r.collection.DeleteOne(ctx, filter)
-	return err
+	return mongoError(err)
 }
@@ -29,7 +29,7 @@ func (r *MongoMessageRepository) Create(ctx context.Context, message *domain.Mes
 
 	result, err := r.collection.InsertOne(ctx, message)
 	if err != nil {
-		return err
+		return mongoError(err)
 	}
 
 	// Convert ObjectID to string
@@ -42,7 +42,7 @@ func (r *MongoMessageRepository) Create(ctx context.Context, message *domain.Mes
 
 // FindByID finds a message by ID
 func (r *MongoMessageRepository) FindByID(ctx context.Context, id string) (*domain.Message, error) {
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := parseID(id)
 	if err != nil {
 		return nil, err
 	}
@@ -51,8 +51,21 @@ func (r *MongoMessageRepository) FindByID(ctx context.Context, id string) (*doma
 	filter := bson.M{"_id": objectID}
 	err = r.collection.FindOne(ctx, filter).Decode(&message)
 	if err != nil {
-		return nil, err
+		return nil, mongoError(err)
 	}
+	defaultAuthorType(&message)
+	return &message, nil
+}
+
+// FindByCorrelationID finds the message created for a queued command's reply
+func (r *MongoMessageRepository) FindByCorrelationID(ctx context.Context, correlationID string) (*domain.Message, error) {
+	var message domain.Message
+	filter := bson.M{"correlation_id": correlationID}
+	err := r.collection.FindOne(ctx, filter).Decode(&message)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defaultAuthorType(&message)
 	return &message, nil
 }
 
@@ -68,7 +81,7 @@ func (r *MongoMessageRepository) FindByChannelID(ctx context.Context, channelID
 
 	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
-		return nil, err
+		return nil, mongoError(err)
 	}
 	defer cursor.Close(ctx)
 
@@ -78,6 +91,7 @@ func (r *MongoMessageRepository) FindByChannelID(ctx context.Context, channelID
 		if err := cursor.Decode(&message); err != nil {
 			return nil, err
 		}
+		defaultAuthorType(&message)
 		messages = append(messages, &message)
 	}
 
@@ -92,28 +106,77 @@ func (r *MongoMessageRepository) FindByChannelID(ctx context.Context, channelID
 
 // Update updates an existing message
 func (r *MongoMessageRepository) Update(ctx context.Context, message *domain.Message) error {
-	objectID, err := primitive.ObjectIDFromHex(message.ID)
+	objectID, err := parseID(message.ID)
 	if err != nil {
 		return err
 	}
 
 	filter := bson.M{"_id": objectID}
 	update := bson.M{"$set": bson.M{
-		"content": message.Content,
+		"content":      message.Content,
+		"content_html": message.ContentHTML,
+		"content_text": message.ContentText,
 	}}
 
 	_, err = r.collection.UpdateOne(ctx, filter, update)
-	return err
+	return mongoError(err)
+}
+
+// UpdateAttachment replaces the copy of an attachment embedded in a message
+func (r *MongoMessageRepository) UpdateAttachment(ctx context.Context, messageID string, attachment *domain.Attachment) error {
+	objectID, err := parseID(messageID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": objectID, "attachments._id": attachment.ID}
+	update := bson.M{"$set": bson.M{
+		"attachments.$": attachment,
+	}}
+
+	_, err = r.collection.UpdateOne(ctx, filter, update)
+	return mongoError(err)
+}
+
+// UpdatePreviews replaces the link previews of a message whose content is
+// still content
+func (r *MongoMessageRepository) UpdatePreviews(ctx context.Context, messageID string, content string, previews []*domain.LinkPreview) error {
+	objectID, err := parseID(messageID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": objectID, "content": content}
+	update := bson.M{"$set": bson.M{
+		"previews": previews,
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.NotFound("message not found or edited")
+	}
+	return nil
 }
 
 // Delete deletes a message by ID
 func (r *MongoMessageRepository) Delete(ctx context.Context, id string) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := parseID(id)
 	if err != nil {
 		return err
 	}
 
 	filter := bson.M{"_id": objectID}
 	_, err = r.collection.DeleteOne(ctx, filter)
-	return err
+	return mongoError(err)
+}
+
+// defaultAuthorType marks messages stored before author types existed as
+// user messages
+func defaultAuthorType(message *domain.Message) {
+	if message.AuthorType == "" {
+		message.AuthorType = domain.AuthorUser
+	}
 }
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"jobsity-backend/pkg/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoOutboxRepository implements OutboxRepository using MongoDB
+type MongoOutboxRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoOutboxRepository creates a new MongoDB outbox repository
+func NewMongoOutboxRepository(collection *mongo.Collection) *MongoOutboxRepository {
+	return &MongoOutboxRepository{
+		collection: collection,
+	}
+}
+
+// Add stores a new undelivered event
+func (r *MongoOutboxRepository) Add(ctx context.Context, event *domain.OutboxEvent) error {
+	event.CreatedAt = time.Now()
+	event.ClaimedUntil = event.CreatedAt
+	event.DeliveredAt = nil
+
+	result, err := r.collection.InsertOne(ctx, event)
+	if err != nil {
+		return mongoError(err)
+	}
+
+	// Convert ObjectID to string
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		event.ID = oid.Hex()
+	}
+
+	return nil
+}
+
+// ClaimPending claims up to limit undelivered events, oldest first
+func (r *MongoOutboxRepository) ClaimPending(ctx context.Context, limit int, until time.Time) ([]*domain.OutboxEvent, error) {
+	filter := bson.M{
+		"delivered_at":  nil,
+		"claimed_until": bson.M{"$lte": time.Now()},
+	}
+	update := bson.M{"$set": bson.M{"claimed_until": until}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	// Claim one event at a time so concurrent relays never share one
+	var events []*domain.OutboxEvent
+	for len(events) < limit {
+		var event domain.OutboxEvent
+		err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&event)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+		if err != nil {
+			return events, mongoError(err)
+		}
+		events = append(events, &event)
+	}
+
+	return events, nil
+}
+
+// MarkDelivered marks an event as published
+func (r *MongoOutboxRepository) MarkDelivered(ctx context.Context, id string, deliveredAt time.Time) error {
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": objectID}
+	update := bson.M{"$set": bson.M{"delivered_at": deliveredAt}}
+
+	_, err = r.collection.UpdateOne(ctx, filter, update)
+	return mongoError(err)
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"jobsity-backend/pkg/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStockAlertRepository implements StockAlertRepository using MongoDB
+type MongoStockAlertRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStockAlertRepository creates a new MongoDB stock alert repository
+func NewMongoStockAlertRepository(collection *mongo.Collection) *MongoStockAlertRepository {
+	return &MongoStockAlertRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new alert
+func (r *MongoStockAlertRepository) Create(ctx context.Context, alert *domain.StockAlert) error {
+	alert.CreatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, alert)
+	if err != nil {
+		return mongoError(err)
+	}
+
+	// Convert ObjectID to string
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		alert.ID = oid.Hex()
+	}
+
+	return nil
+}
+
+// FindByID finds an alert by ID
+func (r *MongoStockAlertRepository) FindByID(ctx context.Context, id string) (*domain.StockAlert, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var alert domain.StockAlert
+	filter := bson.M{"_id": objectID}
+	err = r.collection.FindOne(ctx, filter).Decode(&alert)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	return &alert, nil
+}
+
+// FindActive returns every alert that has not been triggered yet
+func (r *MongoStockAlertRepository) FindActive(ctx context.Context) ([]*domain.StockAlert, error) {
+	return r.find(ctx, bson.M{"triggered_at": nil})
+}
+
+// FindActiveByUser returns a user's untriggered alerts in a channel
+func (r *MongoStockAlertRepository) FindActiveByUser(ctx context.Context, channelID, userEmail string) ([]*domain.StockAlert, error) {
+	return r.find(ctx, bson.M{
+		"channel_id":   channelID,
+		"user_email":   userEmail,
+		"triggered_at": nil,
+	})
+}
+
+// CountActiveByUser counts a user's untriggered alerts across all channels
+func (r *MongoStockAlertRepository) CountActiveByUser(ctx context.Context, userEmail string) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"user_email": userEmail, "triggered_at": nil})
+	return count, mongoError(err)
+}
+
+// MarkTriggered marks an alert as triggered unless it already was
+func (r *MongoStockAlertRepository) MarkTriggered(ctx context.Context, id string, triggeredAt time.Time) (bool, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return false, err
+	}
+
+	filter := bson.M{"_id": objectID, "triggered_at": nil}
+	update := bson.M{"$set": bson.M{"triggered_at": triggeredAt}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, mongoError(err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// Rearm clears the triggered mark of an alert
+func (r *MongoStockAlertRepository) Rearm(ctx context.Context, id string) error {
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": objectID}
+	update := bson.M{"$unset": bson.M{"triggered_at": ""}}
+
+	_, err = r.collection.UpdateOne(ctx, filter, update)
+	return mongoError(err)
+}
+
+// Delete deletes an alert by ID
+func (r *MongoStockAlertRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": objectID}
+	_, err = r.collection.DeleteOne(ctx, filter)
+	return mongoError(err)
+}
+
+// find returns the alerts matching filter, oldest first
+func (r *MongoStockAlertRepository) find(ctx context.Context, filter bson.M) ([]*domain.StockAlert, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var alerts []*domain.StockAlert
+	for cursor.Next(ctx) {
+		var alert domain.StockAlert
+		if err := cursor.Decode(&alert); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, &alert)
+	}
+
+	return alerts, nil
+}
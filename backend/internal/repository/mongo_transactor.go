@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoTransactor implements Transactor with MongoDB multi-document
+// transactions, which need a replica set or sharded cluster
+type MongoTransactor struct {
+	client *mongo.Client
+}
+
+// NewMongoTransactor creates a transactor for the databases of client
+func NewMongoTransactor(client *mongo.Client) *MongoTransactor {
+	return &MongoTransactor{
+		client: client,
+	}
+}
+
+// WithTransaction runs fn in a transaction, retrying it on transient errors
+func (t *MongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	// Operations given the session context join the transaction
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
@@ -28,7 +28,7 @@ func (r *MongoUserRepository) FindByEmail(ctx context.Context, email string) (*d
 	filter := bson.M{"email": email}
 	err := r.collection.FindOne(ctx, filter).Decode(&user)
 	if err != nil {
-		return nil, err
+		return nil, mongoError(err)
 	}
 	return &user, nil
 }
@@ -38,7 +38,7 @@ func (r *MongoUserRepository) Create(ctx context.Context, user *domain.User) err
 	user.CreatedAt = time.Now()
 	result, err := r.collection.InsertOne(ctx, user)
 	if err != nil {
-		return err
+		return mongoError(err)
 	}
 
 	// Convert ObjectID to string
@@ -51,7 +51,7 @@ func (r *MongoUserRepository) Create(ctx context.Context, user *domain.User) err
 
 // FindByID finds a user by ID
 func (r *MongoUserRepository) FindByID(ctx context.Context, id string) (*domain.User, error) {
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := parseID(id)
 	if err != nil {
 		return nil, err
 	}
@@ -60,14 +60,14 @@ func (r *MongoUserRepository) FindByID(ctx context.Context, id string) (*domain.
 	filter := bson.M{"_id": objectID}
 	err = r.collection.FindOne(ctx, filter).Decode(&user)
 	if err != nil {
-		return nil, err
+		return nil, mongoError(err)
 	}
 	return &user, nil
 }
 
 // Update updates an existing user
 func (r *MongoUserRepository) Update(ctx context.Context, user *domain.User) error {
-	objectID, err := primitive.ObjectIDFromHex(user.ID)
+	objectID, err := parseID(user.ID)
 	if err != nil {
 		return err
 	}
@@ -79,17 +79,17 @@ func (r *MongoUserRepository) Update(ctx context.Context, user *domain.User) err
 	}}
 
 	_, err = r.collection.UpdateOne(ctx, filter, update)
-	return err
+	return mongoError(err)
 }
 
 // Delete deletes a user by ID
 func (r *MongoUserRepository) Delete(ctx context.Context, id string) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := parseID(id)
 	if err != nil {
 		return err
 	}
 
 	filter := bson.M{"_id": objectID}
 	_, err = r.collection.DeleteOne(ctx, filter)
-	return err
+	return mongoError(err)
 }
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"jobsity-backend/pkg/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoWatchlistRepository implements WatchlistRepository using MongoDB
+type MongoWatchlistRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoWatchlistRepository creates a new MongoDB watchlist repository
+func NewMongoWatchlistRepository(collection *mongo.Collection) *MongoWatchlistRepository {
+	return &MongoWatchlistRepository{
+		collection: collection,
+	}
+}
+
+// FindByChannel finds the watchlist of a channel
+func (r *MongoWatchlistRepository) FindByChannel(ctx context.Context, channelID string) (*domain.Watchlist, error) {
+	var watchlist domain.Watchlist
+	filter := bson.M{"channel_id": channelID}
+	err := r.collection.FindOne(ctx, filter).Decode(&watchlist)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	return &watchlist, nil
+}
+
+// Save creates or replaces the watchlist of a channel
+func (r *MongoWatchlistRepository) Save(ctx context.Context, watchlist *domain.Watchlist) error {
+	now := time.Now()
+	watchlist.UpdatedAt = now
+
+	filter := bson.M{"channel_id": watchlist.ChannelID}
+	update := bson.M{
+		"$set": bson.M{
+			"stock_codes": watchlist.StockCodes,
+			"schedule":    watchlist.Schedule,
+			"next_run_at": watchlist.NextRunAt,
+			"updated_by":  watchlist.UpdatedBy,
+			"updated_at":  watchlist.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return mongoError(err)
+	}
+
+	// Convert ObjectID to string
+	if oid, ok := result.UpsertedID.(primitive.ObjectID); ok {
+		watchlist.ID = oid.Hex()
+		watchlist.CreatedAt = now
+	}
+
+	return nil
+}
+
+// FindDue returns the watchlists whose next run is at or before now
+func (r *MongoWatchlistRepository) FindDue(ctx context.Context, now time.Time) ([]*domain.Watchlist, error) {
+	filter := bson.M{"next_run_at": bson.M{"$lte": now}}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var watchlists []*domain.Watchlist
+	for cursor.Next(ctx) {
+		var watchlist domain.Watchlist
+		if err := cursor.Decode(&watchlist); err != nil {
+			return nil, err
+		}
+		watchlists = append(watchlists, &watchlist)
+	}
+
+	return watchlists, nil
+}
+
+// ClaimRun moves a watchlist from its scheduled run to nextRunAt
+func (r *MongoWatchlistRepository) ClaimRun(ctx context.Context, id string, scheduledAt, ranAt, nextRunAt time.Time) (bool, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return false, err
+	}
+
+	// Matching on the scheduled run makes the claim a compare-and-swap
+	filter := bson.M{"_id": objectID, "next_run_at": scheduledAt}
+	update := bson.M{"$set": bson.M{
+		"next_run_at": nextRunAt,
+		"last_run_at": ranAt,
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, mongoError(err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// Delete deletes the watchlist of a channel
+func (r *MongoWatchlistRepository) Delete(ctx context.Context, channelID string) error {
+	filter := bson.M{"channel_id": channelID}
+	_, err := r.collection.DeleteOne(ctx, filter)
+	return mongoError(err)
+}
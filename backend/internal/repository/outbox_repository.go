@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"jobsity-backend/pkg/domain"
+	"time"
+)
+
+// OutboxRepository defines the interface for outbox event data operations
+type OutboxRepository interface {
+	// Add stores a new undelivered event
+	Add(ctx context.Context, event *domain.OutboxEvent) error
+
+	// ClaimPending claims up to limit undelivered events, oldest first, that
+	// no other relay holds. The claim lasts until the given time; an event
+	// that is not marked delivered by then can be claimed again.
+	ClaimPending(ctx context.Context, limit int, until time.Time) ([]*domain.OutboxEvent, error)
+
+	// MarkDelivered marks an event as published
+	MarkDelivered(ctx context.Context, id string, deliveredAt time.Time) error
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"encoding/json"
+	"jobsity-backend/pkg/domain"
+	"time"
+)
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// sqlNow returns the current time as PostgreSQL stores it, in UTC with
+// microsecond precision, so records match what a later read returns
+func sqlNow() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// storedAttachment is the JSON form of an attachment embedded in a message
+// row. Unlike the API form it keeps the storage keys.
+type storedAttachment struct {
+	*domain.Attachment
+	StorageKey   string `json:"storage_key"`
+	ThumbnailKey string `json:"thumbnail_key,omitempty"`
+}
+
+// encodeJSON encodes values for a JSON column, storing NULL for empty lists
+func encodeJSON[T any](values []T) (interface{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// encodeAttachments encodes the attachments embedded in a message
+func encodeAttachments(attachments []*domain.Attachment) (interface{}, error) {
+	stored := make([]storedAttachment, len(attachments))
+	for i, attachment := range attachments {
+		stored[i] = storedAttachment{
+			Attachment:   attachment,
+			StorageKey:   attachment.StorageKey,
+			ThumbnailKey: attachment.ThumbnailKey,
+		}
+	}
+	return encodeJSON(stored)
+}
+
+// decodeAttachments decodes the attachments embedded in a message
+func decodeAttachments(column []byte) ([]*domain.Attachment, error) {
+	if column == nil {
+		return nil, nil
+	}
+
+	var stored []storedAttachment
+	if err := json.Unmarshal(column, &stored); err != nil {
+		return nil, err
+	}
+
+	attachments := make([]*domain.Attachment, len(stored))
+	for i, s := range stored {
+		attachment := s.Attachment
+		if attachment == nil {
+			attachment = &domain.Attachment{}
+		}
+		attachment.StorageKey = s.StorageKey
+		attachment.ThumbnailKey = s.ThumbnailKey
+		attachments[i] = attachment
+	}
+	return attachments, nil
+}
+
+// decodePreviews decodes the link previews of a message
+func decodePreviews(column []byte) ([]*domain.LinkPreview, error) {
+	if column == nil {
+		return nil, nil
+	}
+
+	var previews []*domain.LinkPreview
+	if err := json.Unmarshal(column, &previews); err != nil {
+		return nil, err
+	}
+	return previews, nil
+}
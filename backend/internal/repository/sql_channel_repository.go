@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"jobsity-backend/pkg/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// channelColumns are the columns scanChannel reads, in order
+const channelColumns = `id, name, description, created_by, created_at, updated_at`
+
+// SQLChannelRepository implements ChannelRepository on PostgreSQL, or any
+// database/sql database with the schema of database.MigrateSQL
+type SQLChannelRepository struct {
+	db *sql.DB
+}
+
+// NewSQLChannelRepository creates a new SQL channel repository
+func NewSQLChannelRepository(db *sql.DB) *SQLChannelRepository {
+	return &SQLChannelRepository{
+		db: db,
+	}
+}
+
+// Create creates a new channel
+func (r *SQLChannelRepository) Create(ctx context.Context, channel *domain.Channel) error {
+	id := primitive.NewObjectID().Hex()
+	now := sqlNow()
+
+	_, err := r.db.ExecContext(ctx, `INSERT INTO channels (`+channelColumns+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		id, channel.Name, channel.Description, channel.CreatedBy, now, now)
+	if err != nil {
+		return sqlError(err)
+	}
+
+	channel.ID = id
+	channel.CreatedAt = now
+	channel.UpdatedAt = now
+	return nil
+}
+
+// FindByID finds a channel by ID
+func (r *SQLChannelRepository) FindByID(ctx context.Context, id string) (*domain.Channel, error) {
+	if _, err := parseID(id); err != nil {
+		return nil, err
+	}
+
+	row := r.db.QueryRowContext(ctx, `SELECT `+channelColumns+` FROM channels WHERE id = $1`, id)
+	return scanChannel(row)
+}
+
+// FindByName finds a channel by name
+func (r *SQLChannelRepository) FindByName(ctx context.Context, name string) (*domain.Channel, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+channelColumns+` FROM channels WHERE name = $1`, name)
+	return scanChannel(row)
+}
+
+// FindAll returns all channels in the order they were created
+func (r *SQLChannelRepository) FindAll(ctx context.Context) ([]*domain.Channel, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+channelColumns+` FROM channels ORDER BY created_at, id`)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+	defer rows.Close()
+
+	var channels []*domain.Channel
+	for rows.Next() {
+		channel, err := scanChannel(rows)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+	return channels, sqlError(rows.Err())
+}
+
+// Update updates an existing channel
+func (r *SQLChannelRepository) Update(ctx context.Context, channel *domain.Channel) error {
+	if _, err := parseID(channel.ID); err != nil {
+		return err
+	}
+
+	channel.UpdatedAt = sqlNow()
+	_, err := r.db.ExecContext(ctx, `UPDATE channels SET name = $1, description = $2, updated_at = $3 WHERE id = $4`,
+		channel.Name, channel.Description, channel.UpdatedAt, channel.ID)
+	return sqlError(err)
+}
+
+// Delete deletes a channel by ID
+func (r *SQLChannelRepository) Delete(ctx context.Context, id string) error {
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM channels WHERE id = $1`, id)
+	return sqlError(err)
+}
+
+// scanChannel reads a channel selected by channelColumns
+func scanChannel(row rowScanner) (*domain.Channel, error) {
+	var channel domain.Channel
+	err := row.Scan(&channel.ID, &channel.Name, &channel.Description, &channel.CreatedBy, &channel.CreatedAt, &channel.UpdatedAt)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+	return &channel, nil
+}
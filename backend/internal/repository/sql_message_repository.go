@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"jobsity-backend/pkg/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// messageColumns are the columns scanMessage reads, in order
+const messageColumns = `id, channel_id, user_email, author_type, correlation_id, content, content_html, content_text, attachments, previews, created_at`
+
+// SQLMessageRepository implements MessageRepository on PostgreSQL, or any
+// database/sql database with the schema of database.MigrateSQL
+type SQLMessageRepository struct {
+	db *sql.DB
+}
+
+// NewSQLMessageRepository creates a new SQL message repository
+func NewSQLMessageRepository(db *sql.DB) *SQLMessageRepository {
+	return &SQLMessageRepository{
+		db: db,
+	}
+}
+
+// Create creates a new message
+func (r *SQLMessageRepository) Create(ctx context.Context, message *domain.Message) error {
+	attachments, err := encodeAttachments(message.Attachments)
+	if err != nil {
+		return err
+	}
+	previews, err := encodeJSON(message.Previews)
+	if err != nil {
+		return err
+	}
+
+	id := primitive.NewObjectID().Hex()
+	createdAt := sqlNow()
+
+	_, err = r.db.ExecContext(ctx, `INSERT INTO messages (`+messageColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		id, message.ChannelID, message.UserEmail, message.AuthorType, sql.NullString{String: message.CorrelationID, Valid: message.CorrelationID != ""},
+		message.Content, message.ContentHTML, message.ContentText, attachments, previews, createdAt)
+	if err != nil {
+		return sqlError(err)
+	}
+
+	message.ID = id
+	message.CreatedAt = createdAt
+	return nil
+}
+
+// FindByID finds a message by ID
+func (r *SQLMessageRepository) FindByID(ctx context.Context, id string) (*domain.Message, error) {
+	if _, err := parseID(id); err != nil {
+		return nil, err
+	}
+
+	row := r.db.QueryRowContext(ctx, `SELECT `+messageColumns+` FROM messages WHERE id = $1`, id)
+	return scanMessage(row)
+}
+
+// FindByCorrelationID finds the message created for a queued command's reply
+func (r *SQLMessageRepository) FindByCorrelationID(ctx context.Context, correlationID string) (*domain.Message, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+messageColumns+` FROM messages WHERE correlation_id = $1 LIMIT 1`, correlationID)
+	return scanMessage(row)
+}
+
+// FindByChannelID finds the newest limit messages of a channel, oldest first,
+// the same page MongoMessageRepository returns
+func (r *SQLMessageRepository) FindByChannelID(ctx context.Context, channelID string, limit int) ([]*domain.Message, error) {
+	// Sort newest first so the limit keeps the latest messages. The ID breaks
+	// ties between messages created in the same microsecond.
+	query := `SELECT ` + messageColumns + ` FROM messages WHERE channel_id = $1 ORDER BY created_at DESC, id DESC`
+	args := []interface{}{channelID}
+	if limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+	defer rows.Close()
+
+	var messages []*domain.Message
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, sqlError(err)
+	}
+
+	// Reverse the order to return oldest first for proper display
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	return messages, nil
+}
+
+// Update updates an existing message
+func (r *SQLMessageRepository) Update(ctx context.Context, message *domain.Message) error {
+	if _, err := parseID(message.ID); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx, `UPDATE messages SET content = $1, content_html = $2, content_text = $3 WHERE id = $4`,
+		message.Content, message.ContentHTML, message.ContentText, message.ID)
+	return sqlError(err)
+}
+
+// UpdateAttachment replaces the copy of an attachment embedded in a message
+func (r *SQLMessageRepository) UpdateAttachment(ctx context.Context, messageID string, attachment *domain.Attachment) error {
+	if _, err := parseID(messageID); err != nil {
+		return err
+	}
+
+	// Write the list back only if it did not change since it was read, so
+	// concurrent updates of other attachments of the message are not lost
+	for {
+		var column []byte
+		err := r.db.QueryRowContext(ctx, `SELECT attachments FROM messages WHERE id = $1`, messageID).Scan(&column)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return sqlError(err)
+		}
+
+		attachments, err := decodeAttachments(column)
+		if err != nil {
+			return err
+		}
+		replaced := false
+		for i, embedded := range attachments {
+			if embedded.ID == attachment.ID {
+				attachments[i] = attachment
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			return nil
+		}
+
+		encoded, err := encodeAttachments(attachments)
+		if err != nil {
+			return err
+		}
+		result, err := r.db.ExecContext(ctx, `UPDATE messages SET attachments = $1 WHERE id = $2 AND attachments = $3`,
+			encoded, messageID, string(column))
+		if err != nil {
+			return sqlError(err)
+		}
+		if updated, err := result.RowsAffected(); err != nil || updated > 0 {
+			return err
+		}
+	}
+}
+
+// UpdatePreviews replaces the link previews of a message whose content is
+// still content
+func (r *SQLMessageRepository) UpdatePreviews(ctx context.Context, messageID string, content string, previews []*domain.LinkPreview) error {
+	if _, err := parseID(messageID); err != nil {
+		return err
+	}
+
+	encoded, err := encodeJSON(previews)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx, `UPDATE messages SET previews = $1 WHERE id = $2 AND content = $3`, encoded, messageID, content)
+	if err != nil {
+		return sqlError(err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return domain.NotFound("message not found or edited")
+	}
+	return nil
+}
+
+// Delete deletes a message by ID
+func (r *SQLMessageRepository) Delete(ctx context.Context, id string) error {
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM messages WHERE id = $1`, id)
+	return sqlError(err)
+}
+
+// scanMessage reads a message selected by messageColumns
+func scanMessage(row rowScanner) (*domain.Message, error) {
+	var (
+		message       domain.Message
+		correlationID sql.NullString
+		attachments   []byte
+		previews      []byte
+	)
+	err := row.Scan(&message.ID, &message.ChannelID, &message.UserEmail, &message.AuthorType, &correlationID,
+		&message.Content, &message.ContentHTML, &message.ContentText, &attachments, &previews, &message.CreatedAt)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+
+	message.CorrelationID = correlationID.String
+	if message.Attachments, err = decodeAttachments(attachments); err != nil {
+		return nil, err
+	}
+	if message.Previews, err = decodePreviews(previews); err != nil {
+		return nil, err
+	}
+	defaultAuthorType(&message)
+	return &message, nil
+}
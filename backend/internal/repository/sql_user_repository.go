@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"jobsity-backend/pkg/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SQLUserRepository implements UserRepository on PostgreSQL, or any
+// database/sql database with the schema of database.MigrateSQL
+type SQLUserRepository struct {
+	db *sql.DB
+}
+
+// NewSQLUserRepository creates a new SQL user repository
+func NewSQLUserRepository(db *sql.DB) *SQLUserRepository {
+	return &SQLUserRepository{
+		db: db,
+	}
+}
+
+// FindByEmail finds a user by email
+func (r *SQLUserRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT id, email, password, created_at FROM users WHERE email = $1`, email)
+	return scanUser(row)
+}
+
+// Create creates a new user
+func (r *SQLUserRepository) Create(ctx context.Context, user *domain.User) error {
+	id := primitive.NewObjectID().Hex()
+	createdAt := sqlNow()
+
+	_, err := r.db.ExecContext(ctx, `INSERT INTO users (id, email, password, created_at) VALUES ($1, $2, $3, $4)`,
+		id, user.Email, user.Password, createdAt)
+	if err != nil {
+		return sqlError(err)
+	}
+
+	user.ID = id
+	user.CreatedAt = createdAt
+	return nil
+}
+
+// FindByID finds a user by ID
+func (r *SQLUserRepository) FindByID(ctx context.Context, id string) (*domain.User, error) {
+	if _, err := parseID(id); err != nil {
+		return nil, err
+	}
+
+	row := r.db.QueryRowContext(ctx, `SELECT id, email, password, created_at FROM users WHERE id = $1`, id)
+	return scanUser(row)
+}
+
+// Update updates an existing user
+func (r *SQLUserRepository) Update(ctx context.Context, user *domain.User) error {
+	if _, err := parseID(user.ID); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET email = $1, password = $2 WHERE id = $3`,
+		user.Email, user.Password, user.ID)
+	return sqlError(err)
+}
+
+// Delete deletes a user by ID
+func (r *SQLUserRepository) Delete(ctx context.Context, id string) error {
+	if _, err := parseID(id); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+	return sqlError(err)
+}
+
+// scanUser reads a user selected by id, email, password, created_at
+func scanUser(row rowScanner) (*domain.User, error) {
+	var user domain.User
+	if err := row.Scan(&user.ID, &user.Email, &user.Password, &user.CreatedAt); err != nil {
+		return nil, sqlError(err)
+	}
+	return &user, nil
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"jobsity-backend/pkg/domain"
+	"time"
+)
+
+// StockAlertRepository defines the interface for stock alert data operations
+type StockAlertRepository interface {
+	// Create creates a new alert
+	Create(ctx context.Context, alert *domain.StockAlert) error
+
+	// FindByID finds an alert by ID
+	FindByID(ctx context.Context, id string) (*domain.StockAlert, error)
+
+	// FindActive returns every alert that has not been triggered yet
+	FindActive(ctx context.Context) ([]*domain.StockAlert, error)
+
+	// FindActiveByUser returns a user's untriggered alerts in a channel
+	FindActiveByUser(ctx context.Context, channelID, userEmail string) ([]*domain.StockAlert, error)
+
+	// CountActiveByUser counts a user's untriggered alerts across all channels
+	CountActiveByUser(ctx context.Context, userEmail string) (int64, error)
+
+	// MarkTriggered marks an alert as triggered, reporting false if it
+	// already was, so an alert fires once even with several schedulers
+	MarkTriggered(ctx context.Context, id string, triggeredAt time.Time) (bool, error)
+
+	// Rearm clears the triggered mark of an alert whose message was not sent
+	Rearm(ctx context.Context, id string) error
+
+	// Delete deletes an alert by ID
+	Delete(ctx context.Context, id string) error
+}
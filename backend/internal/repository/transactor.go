@@ -0,0 +1,12 @@
+package repository
+
+import "context"
+
+// Transactor runs repository calls in a database transaction
+type Transactor interface {
+	// WithTransaction runs fn in a transaction that is committed if fn
+	// returns nil and aborted otherwise. Repository calls made with the
+	// context passed to fn take part in the transaction. fn may run more
+	// than once when the transaction is retried after a transient error.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
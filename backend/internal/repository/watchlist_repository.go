@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"jobsity-backend/pkg/domain"
+	"time"
+)
+
+// WatchlistRepository defines the interface for channel watchlist data operations
+type WatchlistRepository interface {
+	// FindByChannel finds the watchlist of a channel
+	FindByChannel(ctx context.Context, channelID string) (*domain.Watchlist, error)
+
+	// Save creates or replaces the watchlist of a channel
+	Save(ctx context.Context, watchlist *domain.Watchlist) error
+
+	// FindDue returns the watchlists whose next run is at or before now
+	FindDue(ctx context.Context, now time.Time) ([]*domain.Watchlist, error)
+
+	// ClaimRun moves a watchlist from its scheduled run to nextRunAt, reporting
+	// false if another scheduler claimed that run first
+	ClaimRun(ctx context.Context, id string, scheduledAt, ranAt, nextRunAt time.Time) (bool, error)
+
+	// Delete deletes the watchlist of a channel
+	Delete(ctx context.Context, channelID string) error
+}
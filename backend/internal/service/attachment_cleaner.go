@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// AttachmentCleaner periodically removes uploads that were never attached to a message
+type AttachmentCleaner struct {
+	attachmentService AttachmentService
+	interval          time.Duration
+	maxAge            time.Duration
+	done              chan struct{}
+}
+
+// NewAttachmentCleaner creates a new orphaned upload cleaner
+func NewAttachmentCleaner(attachmentService AttachmentService, interval time.Duration, maxAge time.Duration) *AttachmentCleaner {
+	return &AttachmentCleaner{
+		attachmentService: attachmentService,
+		interval:          interval,
+		maxAge:            maxAge,
+		done:              make(chan struct{}),
+	}
+}
+
+// Start begins the periodic cleanup in the background
+func (c *AttachmentCleaner) Start() {
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.runOnce()
+			case <-c.done:
+				return
+			}
+		}
+	}()
+}
+
+// runOnce performs a single cleanup pass
+func (c *AttachmentCleaner) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.interval)
+	defer cancel()
+
+	removed, err := c.attachmentService.CleanupOrphans(ctx, c.maxAge)
+	if err != nil {
+		log.Printf("Failed to clean up orphaned uploads: %v", err)
+		return
+	}
+	if removed > 0 {
+		log.Printf("Removed %d orphaned uploads", removed)
+	}
+}
+
+// Close stops the periodic cleanup
+func (c *AttachmentCleaner) Close() error {
+	close(c.done)
+	return nil
+}
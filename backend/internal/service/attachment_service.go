@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"io"
+	"jobsity-backend/pkg/domain"
+	"time"
+)
+
+var (
+	// ErrUploadTooLarge is returned when an upload exceeds the configured size limit
+	ErrUploadTooLarge = domain.TooLarge("file exceeds the maximum upload size")
+
+	// ErrUnsupportedMediaType is returned when an upload's content type is not allowed
+	ErrUnsupportedMediaType = domain.Unsupported("file type is not allowed")
+
+	// ErrNotChannelMember is returned when a user accesses a channel they do not belong to
+	ErrNotChannelMember = domain.Forbidden("user is not a member of this channel")
+
+	// ErrThumbnailNotReady is returned when a thumbnail is requested before it has been generated
+	ErrThumbnailNotReady = domain.NotFound("thumbnail is not available")
+
+	// ErrInvalidDownloadURL is returned when a download URL is expired or its signature does not match
+	ErrInvalidDownloadURL = domain.Forbidden("download URL is invalid or has expired")
+)
+
+// Attachment variants that can be downloaded
+const (
+	AttachmentVariantOriginal  = ""
+	AttachmentVariantThumbnail = "thumbnail"
+)
+
+// AttachmentProcessingQueue schedules background processing for new uploads
+type AttachmentProcessingQueue interface {
+	// Enqueue schedules processing for an attachment
+	Enqueue(attachment *domain.Attachment) error
+}
+
+// AttachmentService defines the interface for file attachment business logic
+type AttachmentService interface {
+	// UploadAttachment stores a new file for a channel
+	UploadAttachment(ctx context.Context, req *domain.UploadAttachmentRequest, userEmail string) (*domain.Attachment, error)
+
+	// GetDownloadURL issues a signed, time-limited download URL for an attachment variant
+	GetDownloadURL(ctx context.Context, id string, variant string, userEmail string) (*domain.DownloadURL, error)
+
+	// OpenAttachment verifies a signed download request and opens the contents of an attachment variant
+	OpenAttachment(ctx context.Context, id string, variant string, userEmail string, expires int64, signature string) (*domain.Attachment, *Download, error)
+
+	// CleanupOrphans deletes uploads older than maxAge that were never attached to a message
+	CleanupOrphans(ctx context.Context, maxAge time.Duration) (int, error)
+}
+
+// Download is an opened attachment variant ready to be streamed. Size is -1 when unknown.
+type Download struct {
+	Content     io.ReadCloser
+	ContentType string
+	Size        int64
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/internal/storage"
+	"jobsity-backend/pkg/domain"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// sniffLength is the number of bytes used to detect an upload's content type
+const sniffLength = 512
+
+// AttachmentOptions holds limits and signing settings for attachments
+type AttachmentOptions struct {
+	MaxSize      int64
+	AllowedTypes []string
+	URLSecret    []byte
+	URLTTL       time.Duration
+}
+
+// AttachmentServiceImpl implements AttachmentService
+type AttachmentServiceImpl struct {
+	attachmentRepo  repository.AttachmentRepository
+	blobStore       storage.BlobStore
+	membership      ChannelMembershipChecker
+	processingQueue AttachmentProcessingQueue
+	options         AttachmentOptions
+}
+
+// NewAttachmentService creates a new attachment service. processingQueue may be nil
+// when uploads need no background processing.
+func NewAttachmentService(attachmentRepo repository.AttachmentRepository, blobStore storage.BlobStore, membership ChannelMembershipChecker, processingQueue AttachmentProcessingQueue, options AttachmentOptions) AttachmentService {
+	if len(options.URLSecret) == 0 {
+		// Without a configured secret, URLs stop validating after a restart
+		options.URLSecret = make([]byte, 32)
+		rand.Read(options.URLSecret)
+		log.Println("No upload URL secret configured, using a random one")
+	}
+	if options.URLTTL <= 0 {
+		options.URLTTL = 15 * time.Minute
+	}
+
+	return &AttachmentServiceImpl{
+		attachmentRepo:  attachmentRepo,
+		blobStore:       blobStore,
+		membership:      membership,
+		processingQueue: processingQueue,
+		options:         options,
+	}
+}
+
+// UploadAttachment stores a new file for a channel
+func (s *AttachmentServiceImpl) UploadAttachment(ctx context.Context, req *domain.UploadAttachmentRequest, userEmail string) (*domain.Attachment, error) {
+	// Validate input
+	if req.ChannelID == "" {
+		return nil, domain.Invalid("channel ID is required")
+	}
+	if req.Content == nil {
+		return nil, domain.Invalid("file is required")
+	}
+	if req.Size > s.options.MaxSize {
+		return nil, ErrUploadTooLarge
+	}
+
+	if err := s.checkMembership(ctx, req.ChannelID, userEmail); err != nil {
+		return nil, err
+	}
+
+	// Detect the content type from the file contents rather than trusting the client
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(req.Content, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, domain.Invalid("file is empty")
+	}
+	head = head[:n]
+
+	contentType := normalizeContentType(http.DetectContentType(head))
+	if !s.isAllowedType(contentType) {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	fileName := sanitizeFileName(req.FileName)
+	key, err := newStorageKey(req.ChannelID, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enforce the size limit on the actual stream, not just the declared size
+	body := &io.LimitedReader{
+		R: io.MultiReader(bytes.NewReader(head), req.Content),
+		N: s.options.MaxSize + 1,
+	}
+	counter := &countingReader{r: body}
+
+	err = s.blobStore.Put(ctx, key, counter, req.Size, contentType)
+	if err != nil {
+		return nil, err
+	}
+	if counter.n > s.options.MaxSize {
+		s.deleteBlob(ctx, key)
+		return nil, ErrUploadTooLarge
+	}
+
+	attachment := &domain.Attachment{
+		ChannelID:   req.ChannelID,
+		UploadedBy:  userEmail,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        counter.n,
+		StorageKey:  key,
+	}
+
+	err = s.attachmentRepo.Create(ctx, attachment)
+	if err != nil {
+		s.deleteBlob(ctx, key)
+		return nil, err
+	}
+
+	// Processing is best effort; the original stays downloadable either way
+	if s.processingQueue != nil {
+		if err := s.processingQueue.Enqueue(attachment); err != nil {
+			log.Printf("Failed to enqueue processing for attachment %s: %v", attachment.ID, err)
+		}
+	}
+
+	return attachment, nil
+}
+
+// GetDownloadURL issues a signed, time-limited download URL for an attachment variant
+func (s *AttachmentServiceImpl) GetDownloadURL(ctx context.Context, id string, variant string, userEmail string) (*domain.DownloadURL, error) {
+	attachment, err := s.attachmentRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkMembership(ctx, attachment.ChannelID, userEmail); err != nil {
+		return nil, err
+	}
+
+	if _, _, err := variantBlob(attachment, variant); err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.options.URLTTL).Truncate(time.Second)
+	query := url.Values{}
+	if variant != AttachmentVariantOriginal {
+		query.Set("variant", variant)
+	}
+	query.Set("user", userEmail)
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("signature", s.sign(attachment.ID, variant, userEmail, expiresAt.Unix()))
+
+	return &domain.DownloadURL{
+		URL:       fmt.Sprintf("/api/v1/attachments/%s/download?%s", url.PathEscape(attachment.ID), query.Encode()),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// OpenAttachment verifies a signed download request and opens the contents of an attachment variant
+func (s *AttachmentServiceImpl) OpenAttachment(ctx context.Context, id string, variant string, userEmail string, expires int64, signature string) (*domain.Attachment, *Download, error) {
+	if time.Now().Unix() > expires {
+		return nil, nil, ErrInvalidDownloadURL
+	}
+
+	expected := s.sign(id, variant, userEmail, expires)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, nil, ErrInvalidDownloadURL
+	}
+
+	attachment, err := s.attachmentRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Membership may have changed since the URL was issued
+	if err := s.checkMembership(ctx, attachment.ChannelID, userEmail); err != nil {
+		return nil, nil, err
+	}
+
+	key, contentType, err := variantBlob(attachment, variant)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.blobStore.Get(ctx, key)
+	if err != nil {
+		if err == storage.ErrBlobNotFound {
+			return nil, nil, domain.NotFound("attachment file not found")
+		}
+		return nil, nil, err
+	}
+
+	size := attachment.Size
+	if variant != AttachmentVariantOriginal {
+		size = -1
+	}
+
+	return attachment, &Download{
+		Content:     content,
+		ContentType: contentType,
+		Size:        size,
+	}, nil
+}
+
+// variantBlob returns the storage key and content type of an attachment variant
+func variantBlob(attachment *domain.Attachment, variant string) (string, string, error) {
+	switch variant {
+	case AttachmentVariantOriginal:
+		return attachment.StorageKey, attachment.ContentType, nil
+	case AttachmentVariantThumbnail:
+		if !attachment.HasThumbnail() {
+			return "", "", ErrThumbnailNotReady
+		}
+		return attachment.ThumbnailKey, attachment.ThumbnailType, nil
+	default:
+		return "", "", domain.Invalid("unknown attachment variant")
+	}
+}
+
+// CleanupOrphans deletes uploads older than maxAge that were never attached to a message
+func (s *AttachmentServiceImpl) CleanupOrphans(ctx context.Context, maxAge time.Duration) (int, error) {
+	orphans, err := s.attachmentRepo.FindUnattachedBefore(ctx, time.Now().Add(-maxAge))
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, attachment := range orphans {
+		// The upload may have been attached since it was found, in which
+		// case the record is kept and so are its blobs
+		err := s.attachmentRepo.DeleteUnattached(ctx, attachment.ID)
+		if errors.Is(err, domain.ErrConflict) || errors.Is(err, domain.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to delete orphaned attachment %s: %v", attachment.ID, err)
+			continue
+		}
+		if attachment.HasThumbnail() {
+			s.deleteBlob(ctx, attachment.ThumbnailKey)
+		}
+		s.deleteBlob(ctx, attachment.StorageKey)
+		removed++
+	}
+
+	return removed, nil
+}
+
+// checkMembership returns ErrNotChannelMember unless the user belongs to the channel
+func (s *AttachmentServiceImpl) checkMembership(ctx context.Context, channelID string, userEmail string) error {
+	isMember, err := s.membership.IsMember(ctx, channelID, userEmail)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return ErrNotChannelMember
+	}
+	return nil
+}
+
+// isAllowedType reports whether the content type is on the allow list
+func (s *AttachmentServiceImpl) isAllowedType(contentType string) bool {
+	for _, allowed := range s.options.AllowedTypes {
+		if strings.EqualFold(allowed, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// sign computes the download URL signature for an attachment variant, user and expiry
+func (s *AttachmentServiceImpl) sign(id string, variant string, userEmail string, expires int64) string {
+	mac := hmac.New(sha256.New, s.options.URLSecret)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%d", id, variant, userEmail, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deleteBlob removes a blob that was stored for a failed upload
+func (s *AttachmentServiceImpl) deleteBlob(ctx context.Context, key string) {
+	if err := s.blobStore.Delete(ctx, key); err != nil {
+		log.Printf("Failed to delete blob %s: %v", key, err)
+	}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// normalizeContentType strips parameters such as charset from a content type
+func normalizeContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return mediaType
+}
+
+// sanitizeFileName strips directories and control characters from a client-supplied file name
+func sanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+
+	if name == "" || name == "." || name == "/" {
+		name = "file"
+	}
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return name
+}
+
+// newStorageKey generates a random, collision-resistant blob key for an upload
+func newStorageKey(channelID string, fileName string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if len(ext) > 10 || strings.ContainsAny(ext, "/\\ ") {
+		ext = ""
+	}
+	return channelID + "/" + hex.EncodeToString(random) + ext, nil
+}
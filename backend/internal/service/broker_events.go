@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/pkg/domain"
+	"log"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// BrokerEvents publishes WebSocket events that are not part of a message
+// write straight to the event exchange, for EventSubscriber to deliver to the
+// clients of every server. It serves change stream delivery, where only
+// persisted writes reach other servers through MongoDB. Unlike OutboxEvents,
+// an event is lost if the broker is down.
+type BrokerEvents struct {
+	conn broker.Broker
+}
+
+// NewBrokerEvents creates a broker event sender
+func NewBrokerEvents(conn broker.Broker) *BrokerEvents {
+	return &BrokerEvents{conn: conn}
+}
+
+// Broadcast publishes an event for every client in a channel
+func (e *BrokerEvents) Broadcast(channelID string, messageType string, data interface{}) {
+	e.publish(&domain.OutboxEvent{Type: messageType, ChannelID: channelID}, data)
+}
+
+// SendToUser publishes an event for one user's clients in a channel. It is
+// an EphemeralSender; the connections are reached on any server, so it
+// reports none.
+func (e *BrokerEvents) SendToUser(channelID, userEmail, messageType string, data interface{}) int {
+	e.publish(&domain.OutboxEvent{Type: messageType, ChannelID: channelID, UserEmail: userEmail}, data)
+	return 0
+}
+
+// publish sends event to the event exchange. The callers have nothing to
+// roll back, so a failure is only logged.
+func (e *BrokerEvents) publish(event *domain.OutboxEvent, data interface{}) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Failed to encode %s event for channel %s: %v", event.Type, event.ChannelID, err)
+		return
+	}
+	event.Data = encoded
+	event.CreatedAt = time.Now()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode %s event for channel %s: %v", event.Type, event.ChannelID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), outboxPublishTimeout)
+	defer cancel()
+
+	err = e.conn.PublishConfirmed(ctx, eventExchange, "", amqp.Publishing{
+		ContentType: "application/json",
+		Timestamp:   event.CreatedAt,
+		Body:        body,
+	})
+	// Without subscribers no server has clients to deliver to
+	if err != nil && !errors.Is(err, broker.ErrUnroutable) {
+		log.Printf("Failed to publish %s event for channel %s: %v", event.Type, event.ChannelID, err)
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/pkg/domain"
+)
+
+// ChannelMembershipChecker decides whether a user may access a channel's content
+type ChannelMembershipChecker interface {
+	// IsMember reports whether the user is a member of the channel
+	IsMember(ctx context.Context, channelID string, userEmail string) (bool, error)
+}
+
+// OpenChannelMembership treats every authenticated user as a member of every
+// existing channel, which matches how channels are currently listed and joined
+type OpenChannelMembership struct {
+	channelRepo repository.ChannelRepository
+}
+
+// NewOpenChannelMembership creates a membership checker for open channels
+func NewOpenChannelMembership(channelRepo repository.ChannelRepository) ChannelMembershipChecker {
+	return &OpenChannelMembership{
+		channelRepo: channelRepo,
+	}
+}
+
+// IsMember reports whether the user is a member of the channel
+func (m *OpenChannelMembership) IsMember(ctx context.Context, channelID string, userEmail string) (bool, error) {
+	if userEmail == "" {
+		return false, nil
+	}
+
+	_, err := m.channelRepo.FindByID(ctx, channelID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
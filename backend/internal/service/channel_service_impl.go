@@ -5,8 +5,6 @@ import (
 	"errors"
 	"jobsity-backend/internal/repository"
 	"jobsity-backend/pkg/domain"
-
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // ChannelServiceImpl implements ChannelService
@@ -25,16 +23,16 @@ func NewChannelService(channelRepo repository.ChannelRepository) ChannelService
 func (s *ChannelServiceImpl) CreateChannel(ctx context.Context, req *domain.CreateChannelRequest, userEmail string) (*domain.Channel, error) {
 	// Validate input
 	if req.Name == "" {
-		return nil, errors.New("channel name is required")
+		return nil, domain.Invalid("channel name is required")
 	}
 
 	// Check if channel with same name already exists
 	existingChannel, err := s.channelRepo.FindByName(ctx, req.Name)
-	if err != nil && err != mongo.ErrNoDocuments {
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
 		return nil, err
 	}
 	if existingChannel != nil {
-		return nil, errors.New("channel with this name already exists")
+		return nil, domain.Conflict("channel with this name already exists")
 	}
 
 	// Create new channel
@@ -77,7 +75,7 @@ func (s *ChannelServiceImpl) UpdateChannel(ctx context.Context, id string, req *
 
 	// Check if user is the creator
 	if channel.CreatedBy != userEmail {
-		return nil, errors.New("only the channel creator can update it")
+		return nil, domain.Forbidden("only the channel creator can update it")
 	}
 
 	// Update channel fields
@@ -85,11 +83,11 @@ func (s *ChannelServiceImpl) UpdateChannel(ctx context.Context, id string, req *
 		// Check if new name conflicts with existing channel
 		if req.Name != channel.Name {
 			existingChannel, err := s.channelRepo.FindByName(ctx, req.Name)
-			if err != nil && err != mongo.ErrNoDocuments {
+			if err != nil && !errors.Is(err, domain.ErrNotFound) {
 				return nil, err
 			}
 			if existingChannel != nil {
-				return nil, errors.New("channel with this name already exists")
+				return nil, domain.Conflict("channel with this name already exists")
 			}
 		}
 		channel.Name = req.Name
@@ -117,7 +115,7 @@ func (s *ChannelServiceImpl) DeleteChannel(ctx context.Context, id string, userE
 
 	// Check if user is the creator
 	if channel.CreatedBy != userEmail {
-		return errors.New("only the channel creator can delete it")
+		return domain.Forbidden("only the channel creator can delete it")
 	}
 
 	return s.channelRepo.Delete(ctx, id)
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/commands"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// CommandPublisher durably enqueues work for asynchronous slash commands.
+// Failures wrap commands.ErrNotEnqueued.
+type CommandPublisher interface {
+	// PublishCommand returns once the message is safely stored on queue
+	PublishCommand(ctx context.Context, queue string, msg amqp.Publishing) error
+}
+
+// brokerCommandPublisher publishes commands to RabbitMQ with publisher confirms
+type brokerCommandPublisher struct {
+	conn    broker.Broker
+	timeout time.Duration
+}
+
+// NewBrokerCommandPublisher creates a command publisher that waits up to
+// timeout for RabbitMQ to confirm each command
+func NewBrokerCommandPublisher(conn broker.Broker, timeout time.Duration) CommandPublisher {
+	return &brokerCommandPublisher{
+		conn:    conn,
+		timeout: timeout,
+	}
+}
+
+// PublishCommand publishes msg to queue and waits for the broker's confirmation
+func (p *brokerCommandPublisher) PublishCommand(ctx context.Context, queue string, msg amqp.Publishing) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	err := p.conn.PublishConfirmed(ctx, "", queue, msg)
+	if err != nil {
+		return fmt.Errorf("%w: %w", commands.ErrNotEnqueued, err)
+	}
+	return nil
+}
+
+// InMemoryCommandPublisher keeps published commands in memory. Publishing to
+// a queue that was not declared fails like an unroutable mandatory publish.
+type InMemoryCommandPublisher struct {
+	mutex  sync.Mutex
+	queues map[string][]amqp.Publishing
+}
+
+// NewInMemoryCommandPublisher creates an in-memory publisher with the given queues
+func NewInMemoryCommandPublisher(queues ...string) *InMemoryCommandPublisher {
+	publisher := &InMemoryCommandPublisher{
+		queues: make(map[string][]amqp.Publishing, len(queues)),
+	}
+	for _, queue := range queues {
+		publisher.queues[queue] = nil
+	}
+	return publisher
+}
+
+// PublishCommand appends msg to queue
+func (p *InMemoryCommandPublisher) PublishCommand(ctx context.Context, queue string, msg amqp.Publishing) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", commands.ErrNotEnqueued, err)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	published, ok := p.queues[queue]
+	if !ok {
+		return fmt.Errorf("%w: %w", commands.ErrNotEnqueued, broker.ErrUnroutable)
+	}
+	p.queues[queue] = append(published, msg)
+	return nil
+}
+
+// Published returns the messages published to queue so far
+func (p *InMemoryCommandPublisher) Published(queue string) []amqp.Publishing {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return append([]amqp.Publishing(nil), p.queues[queue]...)
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"errors"
+	"jobsity-backend/pkg/domain"
+)
+
+// ErrUnknownQueue is returned when a queue is not managed by the dead letter service
+var ErrUnknownQueue = errors.New("unknown queue")
+
+// DeadLetterService defines the interface for inspecting and replaying dead letters
+type DeadLetterService interface {
+	// ListDeadLetters returns up to limit dead letters of a work queue without removing them
+	ListDeadLetters(queue string, limit int) ([]*domain.DeadLetter, error)
+
+	// RequeueDeadLetters moves dead letters back to their work queue. Only
+	// messages whose message or correlation id is in ids are moved, unless ids
+	// is empty. It returns the number of messages requeued.
+	RequeueDeadLetters(queue string, ids []string, limit int) (int, error)
+}
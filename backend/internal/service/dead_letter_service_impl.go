@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/pkg/domain"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	// defaultDeadLetterLimit is used when no limit is requested
+	defaultDeadLetterLimit = 50
+
+	// maxDeadLetterScan bounds how many dead letters a single call reads
+	maxDeadLetterScan = 1000
+
+	// deadLetterPublishTimeout bounds the wait for the broker to confirm a
+	// requeued message
+	deadLetterPublishTimeout = 5 * time.Second
+)
+
+// deadLetterService implements DeadLetterService with a short-lived RabbitMQ channel per call
+type deadLetterService struct {
+	mutex  sync.Mutex
+	conn   broker.Broker
+	queues map[string]bool
+}
+
+// NewDeadLetterService creates a new dead letter service for the given work queues
+func NewDeadLetterService(conn broker.Broker, queues []string) (DeadLetterService, error) {
+	managed := make(map[string]bool, len(queues))
+	for _, queue := range queues {
+		managed[queue] = true
+	}
+
+	return &deadLetterService{
+		conn:   conn,
+		queues: managed,
+	}, nil
+}
+
+// ListDeadLetters returns up to limit dead letters of a work queue without removing them
+func (s *deadLetterService) ListDeadLetters(queue string, limit int) ([]*domain.DeadLetter, error) {
+	if !s.queues[queue] {
+		return nil, ErrUnknownQueue
+	}
+	limit = clampDeadLetterLimit(limit)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ch, err := s.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	// Read without acknowledging, then put everything back where it was
+	var deliveries []amqp.Delivery
+	defer func() {
+		for _, msg := range deliveries {
+			msg.Nack(false, true)
+		}
+	}()
+
+	deadLetters := []*domain.DeadLetter{}
+	for len(deliveries) < limit {
+		msg, ok, err := ch.Get(broker.DeadLetterQueueName(queue), false)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		deliveries = append(deliveries, msg)
+		deadLetters = append(deadLetters, toDeadLetter(queue, msg))
+	}
+
+	return deadLetters, nil
+}
+
+// RequeueDeadLetters moves matching dead letters back to their work queue
+func (s *deadLetterService) RequeueDeadLetters(queue string, ids []string, limit int) (int, error) {
+	if !s.queues[queue] {
+		return 0, ErrUnknownQueue
+	}
+	limit = clampDeadLetterLimit(limit)
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ch, err := s.conn.Channel()
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	// Messages that were read but not selected are returned to the dead-letter queue
+	var skipped []amqp.Delivery
+	defer func() {
+		for _, msg := range skipped {
+			msg.Nack(false, true)
+		}
+	}()
+
+	requeued := 0
+	for scanned := 0; requeued < limit && scanned < maxDeadLetterScan; scanned++ {
+		msg, ok, err := ch.Get(broker.DeadLetterQueueName(queue), false)
+		if err != nil {
+			return requeued, err
+		}
+		if !ok {
+			break
+		}
+
+		if len(wanted) > 0 && !wanted[msg.MessageId] && !wanted[msg.CorrelationId] {
+			skipped = append(skipped, msg)
+			continue
+		}
+
+		// Remove the dead letter only once the work queue holds its copy
+		ctx, cancel := context.WithTimeout(context.Background(), deadLetterPublishTimeout)
+		err = s.conn.PublishConfirmed(ctx, "", queue, replayPublishing(msg))
+		cancel()
+		if err != nil {
+			skipped = append(skipped, msg)
+			return requeued, err
+		}
+		if err := msg.Ack(false); err != nil {
+			return requeued, err
+		}
+		requeued++
+	}
+
+	return requeued, nil
+}
+
+// replayPublishing copies a dead letter for a fresh set of delivery attempts
+func replayPublishing(msg amqp.Delivery) amqp.Publishing {
+	headers := amqp.Table{}
+	for key, value := range msg.Headers {
+		switch key {
+		case broker.RetryCountHeader, broker.ErrorHeader, broker.DeadLetteredAtHeader,
+			"x-death", "x-first-death-exchange", "x-first-death-queue", "x-first-death-reason":
+			continue
+		}
+		headers[key] = value
+	}
+
+	return amqp.Publishing{
+		Headers:       headers,
+		ContentType:   msg.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: msg.CorrelationId,
+		MessageId:     msg.MessageId,
+		Timestamp:     msg.Timestamp,
+		Type:          msg.Type,
+		Body:          msg.Body,
+	}
+}
+
+// toDeadLetter converts a dead-lettered delivery for display
+func toDeadLetter(queue string, msg amqp.Delivery) *domain.DeadLetter {
+	deadLetter := &domain.DeadLetter{
+		Queue:         queue,
+		MessageID:     msg.MessageId,
+		CorrelationID: msg.CorrelationId,
+		Type:          msg.Type,
+		ContentType:   msg.ContentType,
+		Retries:       broker.RetryCount(msg),
+		Body:          string(msg.Body),
+	}
+
+	if reason, ok := msg.Headers[broker.ErrorHeader].(string); ok {
+		deadLetter.Error = reason
+	} else if reason, ok := msg.Headers["x-first-death-reason"].(string); ok {
+		// Rejected by the broker rather than by a consumer
+		deadLetter.Error = reason
+	}
+
+	if at, ok := msg.Headers[broker.DeadLetteredAtHeader].(string); ok {
+		deadLetter.DeadLetteredAt, _ = time.Parse(time.RFC3339, at)
+	}
+
+	return deadLetter
+}
+
+// clampDeadLetterLimit applies the default and maximum dead letter limits
+func clampDeadLetterLimit(limit int) int {
+	if limit <= 0 {
+		return defaultDeadLetterLimit
+	}
+	if limit > maxDeadLetterScan {
+		return maxDeadLetterScan
+	}
+	return limit
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"jobsity-backend/pkg/domain"
+	"time"
+)
+
+// systemAuthor is the author shown on replies from the server itself
+const systemAuthor = "system"
+
+// EphemeralSender delivers a frame only to one user's connections in a
+// channel, returning how many connections it reached, or 0 if they are
+// reached later
+type EphemeralSender func(channelID, userEmail, messageType string, data interface{}) int
+
+// NewEphemeralMessage creates a message shown only to the user it is sent to.
+// Ephemeral messages are never stored, so the id only lets clients tell them
+// apart.
+func NewEphemeralMessage(channelID, author, authorType, content string) *domain.Message {
+	id, _ := newCorrelationID()
+	message := &domain.Message{
+		ID:         "ephemeral-" + id,
+		ChannelID:  channelID,
+		UserEmail:  author,
+		AuthorType: authorType,
+		Content:    content,
+		CreatedAt:  time.Now().UTC(),
+		Ephemeral:  true,
+	}
+	renderContent(message)
+	return message
+}
+
+// SendEphemeral delivers an ephemeral message to userEmail as a new_message
+// frame, so clients show it inline with the channel history
+func SendEphemeral(send EphemeralSender, userEmail string, message *domain.Message) int {
+	if send == nil || userEmail == "" {
+		return 0
+	}
+	return send(message.ChannelID, userEmail, "new_message", message)
+}
+
+// SendCommandReply delivers a slash command's reply or error to the user who
+// ran it
+func SendCommandReply(send EphemeralSender, channelID, userEmail, content string) int {
+	if content == "" {
+		return 0
+	}
+	return SendEphemeral(send, userEmail, NewEphemeralMessage(channelID, systemAuthor, domain.AuthorSystem, content))
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/pkg/domain"
+	"log"
+
+	"github.com/streadway/amqp"
+)
+
+// EventSubscriber delivers the events published by OutboxRelay to the
+// WebSocket clients of this server. An event can arrive more than once, so
+// clients must handle repeats idempotently.
+type EventSubscriber struct {
+	conn       broker.Broker
+	consumer   *broker.Consumer
+	notifyFunc func(channelID string, messageType string, data interface{})
+	sendFunc   EphemeralSender
+}
+
+// NewEventSubscriber creates a subscriber that broadcasts each event with
+// notifyFunc, or sends it with sendFunc if it is for a single user
+func NewEventSubscriber(conn broker.Broker, notifyFunc func(channelID string, messageType string, data interface{}), sendFunc EphemeralSender) (*EventSubscriber, error) {
+	return &EventSubscriber{
+		conn:       conn,
+		notifyFunc: notifyFunc,
+		sendFunc:   sendFunc,
+	}, nil
+}
+
+// Start subscribes to the event exchange
+func (s *EventSubscriber) Start() error {
+	consumer, err := s.conn.Subscribe(eventExchange, s.handleEvent)
+	if err != nil {
+		return err
+	}
+	s.consumer = consumer
+	return nil
+}
+
+func (s *EventSubscriber) handleEvent(msg amqp.Delivery) {
+	// A broadcast cannot fail, and a malformed event would never succeed
+	defer msg.Ack(false)
+
+	var event domain.OutboxEvent
+	if err := json.Unmarshal(msg.Body, &event); err != nil {
+		log.Printf("Invalid outbox event: %s", string(msg.Body))
+		return
+	}
+
+	if event.UserEmail != "" {
+		s.sendFunc(event.ChannelID, event.UserEmail, event.Type, event.Data)
+		return
+	}
+	s.notifyFunc(event.ChannelID, event.Type, event.Data)
+}
+
+// Shutdown stops receiving events, letting the event being broadcast
+// finish until ctx expires
+func (s *EventSubscriber) Shutdown(ctx context.Context) error {
+	if s.consumer == nil {
+		return nil
+	}
+	return s.consumer.Drain(ctx)
+}
+
+// Close stops receiving events
+func (s *EventSubscriber) Close() error {
+	if s.consumer != nil {
+		s.consumer.Cancel()
+	}
+	return nil
+}
@@ -10,6 +10,10 @@ type MessageService interface {
 	// CreateMessage creates a new message
 	CreateMessage(ctx context.Context, req *domain.CreateMessageRequest, userEmail string) (*domain.Message, error)
 
+	// CreateBotMessage stores a message posted by a bot. Messages carrying a
+	// correlation ID are stored once; repeating it returns the stored message.
+	CreateBotMessage(ctx context.Context, message *domain.Message) (*domain.Message, error)
+
 	// GetMessage gets a message by ID
 	GetMessage(ctx context.Context, id string) (*domain.Message, error)
 
@@ -3,23 +3,30 @@ package service
 import (
 	"context"
 	"errors"
+	"jobsity-backend/internal/markdown"
 	"jobsity-backend/internal/repository"
 	"jobsity-backend/pkg/domain"
+	"log"
 
-	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // MessageServiceImpl implements MessageService
 type MessageServiceImpl struct {
-	messageRepo repository.MessageRepository
-	channelRepo repository.ChannelRepository
+	messageRepo    repository.MessageRepository
+	channelRepo    repository.ChannelRepository
+	attachmentRepo repository.AttachmentRepository
 }
 
+// maxAttachmentsPerMessage limits how many uploads a single message can reference
+const maxAttachmentsPerMessage = 10
+
 // NewMessageService creates a new message service
-func NewMessageService(messageRepo repository.MessageRepository, channelRepo repository.ChannelRepository) MessageService {
+func NewMessageService(messageRepo repository.MessageRepository, channelRepo repository.ChannelRepository, attachmentRepo repository.AttachmentRepository) MessageService {
 	return &MessageServiceImpl{
-		messageRepo: messageRepo,
-		channelRepo: channelRepo,
+		messageRepo:    messageRepo,
+		channelRepo:    channelRepo,
+		attachmentRepo: attachmentRepo,
 	}
 }
 
@@ -27,36 +34,161 @@ func NewMessageService(messageRepo repository.MessageRepository, channelRepo rep
 func (s *MessageServiceImpl) CreateMessage(ctx context.Context, req *domain.CreateMessageRequest, userEmail string) (*domain.Message, error) {
 	// Validate input
 	if req.ChannelID == "" {
-		return nil, errors.New("channel ID is required")
+		return nil, domain.Invalid("channel ID is required")
+	}
+	if req.Content == "" && len(req.AttachmentIDs) == 0 {
+		return nil, domain.Invalid("message content is required")
 	}
-	if req.Content == "" {
-		return nil, errors.New("message content is required")
+	if len(req.AttachmentIDs) > maxAttachmentsPerMessage {
+		return nil, domain.Invalid("too many attachments")
 	}
 
 	// Verify channel exists
 	_, err := s.channelRepo.FindByID(ctx, req.ChannelID)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("channel not found")
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, domain.NotFound("channel not found")
 		}
 		return nil, err
 	}
 
+	// Resolve uploaded attachments
+	attachments, err := s.findAttachments(ctx, req.AttachmentIDs, req.ChannelID, userEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	// Claim the attachments before the message exists, so concurrent posts
+	// and the orphan cleanup cannot take them. The claim is made under an
+	// ID no message has until it is replaced by the message's own.
+	claim := primitive.NewObjectID().Hex()
+	if err := s.swapAttachments(ctx, attachments, "", claim); err != nil {
+		return nil, err
+	}
+
 	// Create new message
 	newMessage := &domain.Message{
-		ChannelID: req.ChannelID,
-		UserEmail: userEmail,
-		Content:   req.Content,
+		ChannelID:   req.ChannelID,
+		UserEmail:   userEmail,
+		AuthorType:  domain.AuthorUser,
+		Content:     req.Content,
+		Attachments: attachments,
 	}
+	renderContent(newMessage)
 
 	err = s.messageRepo.Create(ctx, newMessage)
 	if err != nil {
+		if releaseErr := s.swapAttachments(ctx, attachments, claim, ""); releaseErr != nil {
+			log.Printf("Failed to release attachments claimed for a message: %v", releaseErr)
+		}
 		return nil, err
 	}
 
+	// Link the attachments to the message. They stay claimed if this fails,
+	// so the message keeps them and the request still succeeds.
+	if err := s.swapAttachments(ctx, attachments, claim, newMessage.ID); err != nil {
+		log.Printf("Failed to link attachments to message %s: %v", newMessage.ID, err)
+	}
+
 	return newMessage, nil
 }
 
+// CreateBotMessage stores a message posted by a bot
+func (s *MessageServiceImpl) CreateBotMessage(ctx context.Context, message *domain.Message) (*domain.Message, error) {
+	// Validate input
+	if message.ChannelID == "" {
+		return nil, domain.Invalid("channel ID is required")
+	}
+	if message.Content == "" {
+		return nil, domain.Invalid("message content is required")
+	}
+	if message.AuthorType == "" || message.AuthorType == domain.AuthorUser {
+		return nil, domain.Invalid("bot messages require a bot or system author type")
+	}
+
+	// A redelivered reply resolves to the message stored the first time
+	if message.CorrelationID != "" {
+		existing, err := s.messageRepo.FindByCorrelationID(ctx, message.CorrelationID)
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, domain.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	// Verify channel exists
+	_, err := s.channelRepo.FindByID(ctx, message.ChannelID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, domain.NotFound("channel not found")
+		}
+		return nil, err
+	}
+
+	renderContent(message)
+
+	err = s.messageRepo.Create(ctx, message)
+	if errors.Is(err, domain.ErrConflict) && message.CorrelationID != "" {
+		// Another server stored the same reply since it was looked up
+		if existing, findErr := s.messageRepo.FindByCorrelationID(ctx, message.CorrelationID); findErr == nil {
+			return existing, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// findAttachments loads the referenced uploads and checks they can be attached by the user
+func (s *MessageServiceImpl) findAttachments(ctx context.Context, ids []string, channelID string, userEmail string) ([]*domain.Attachment, error) {
+	var attachments []*domain.Attachment
+	for _, id := range ids {
+		attachment, err := s.attachmentRepo.FindByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil, domain.NotFound("attachment not found")
+			}
+			return nil, err
+		}
+
+		if attachment.UploadedBy != userEmail {
+			return nil, domain.Forbidden("only the uploader can attach a file")
+		}
+		if attachment.ChannelID != channelID {
+			return nil, domain.Invalid("attachment was uploaded to a different channel")
+		}
+		if attachment.MessageID != "" {
+			return nil, domain.Conflict("attachment is already used by another message")
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+// swapAttachments links attachments from oldMessageID to newMessageID. If
+// one of them cannot be linked, those already linked are given back to
+// oldMessageID.
+func (s *MessageServiceImpl) swapAttachments(ctx context.Context, attachments []*domain.Attachment, oldMessageID, newMessageID string) error {
+	for i, attachment := range attachments {
+		err := s.attachmentRepo.SwapMessage(ctx, attachment.ID, oldMessageID, newMessageID)
+		if err != nil {
+			for _, swapped := range attachments[:i] {
+				if undoErr := s.attachmentRepo.SwapMessage(ctx, swapped.ID, newMessageID, oldMessageID); undoErr != nil {
+					log.Printf("Failed to give back attachment %s: %v", swapped.ID, undoErr)
+				}
+			}
+			return err
+		}
+		attachment.MessageID = newMessageID
+	}
+	return nil
+}
+
 // GetMessage gets a message by ID
 func (s *MessageServiceImpl) GetMessage(ctx context.Context, id string) (*domain.Message, error) {
 	return s.messageRepo.FindByID(ctx, id)
@@ -67,8 +199,8 @@ func (s *MessageServiceImpl) GetMessagesByChannel(ctx context.Context, channelID
 	// Verify channel exists
 	_, err := s.channelRepo.FindByID(ctx, channelID)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("channel not found")
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, domain.NotFound("channel not found")
 		}
 		return nil, err
 	}
@@ -85,12 +217,13 @@ func (s *MessageServiceImpl) UpdateMessage(ctx context.Context, id string, conte
 	}
 
 	// Check if user is the message author
-	if message.UserEmail != userEmail {
-		return nil, errors.New("only the message author can update it")
+	if message.AuthorType != domain.AuthorUser || message.UserEmail != userEmail {
+		return nil, domain.Forbidden("only the message author can update it")
 	}
 
 	// Update message content
 	message.Content = content
+	renderContent(message)
 
 	// Save updated message
 	err = s.messageRepo.Update(ctx, message)
@@ -110,9 +243,22 @@ func (s *MessageServiceImpl) DeleteMessage(ctx context.Context, id string, userE
 	}
 
 	// Check if user is the message author
-	if message.UserEmail != userEmail {
-		return errors.New("only the message author can delete it")
+	if message.AuthorType != domain.AuthorUser || message.UserEmail != userEmail {
+		return domain.Forbidden("only the message author can delete it")
 	}
 
-	return s.messageRepo.Delete(ctx, id)
+	err = s.messageRepo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Unlink the attachments so the orphan cleanup reclaims them
+	return s.swapAttachments(ctx, message.Attachments, message.ID, "")
+}
+
+// renderContent fills in the sanitized HTML and plain-text renderings of a message
+func renderContent(message *domain.Message) {
+	rendered := markdown.Render(message.Content)
+	message.ContentHTML = rendered.HTML
+	message.ContentText = rendered.Text
 }
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/pkg/domain"
+	"log"
+	"time"
+)
+
+// outboxAddTimeout bounds the time spent storing one event in the outbox
+const outboxAddTimeout = 5 * time.Second
+
+// OutboxEvents stores WebSocket events that are not part of a message write,
+// such as link previews, processed attachments and ephemeral replies, in the
+// outbox. OutboxRelay then delivers them to the clients of every server, like
+// the events of OutboxMessageService.
+type OutboxEvents struct {
+	outboxRepo repository.OutboxRepository
+	notifier   OutboxNotifier
+}
+
+// NewOutboxEvents creates an outbox event sender. notifier may be nil, in
+// which case events wait for the relay's next poll.
+func NewOutboxEvents(outboxRepo repository.OutboxRepository, notifier OutboxNotifier) *OutboxEvents {
+	return &OutboxEvents{
+		outboxRepo: outboxRepo,
+		notifier:   notifier,
+	}
+}
+
+// Broadcast queues an event for every client in a channel
+func (e *OutboxEvents) Broadcast(channelID string, messageType string, data interface{}) {
+	e.add(&domain.OutboxEvent{Type: messageType, ChannelID: channelID}, data)
+}
+
+// SendToUser queues an event for one user's clients in a channel. It is an
+// EphemeralSender; the connections are reached later, on any server, so it
+// reports none.
+func (e *OutboxEvents) SendToUser(channelID, userEmail, messageType string, data interface{}) int {
+	e.add(&domain.OutboxEvent{Type: messageType, ChannelID: channelID, UserEmail: userEmail}, data)
+	return 0
+}
+
+// add stores event and wakes the relay. The callers have nothing to roll
+// back, so a failure is only logged.
+func (e *OutboxEvents) add(event *domain.OutboxEvent, data interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), outboxAddTimeout)
+	defer cancel()
+
+	if err := addOutboxEvent(ctx, e.outboxRepo, event, data); err != nil {
+		log.Printf("Failed to store %s event for channel %s: %v", event.Type, event.ChannelID, err)
+		return
+	}
+	if e.notifier != nil {
+		e.notifier.Notify()
+	}
+}
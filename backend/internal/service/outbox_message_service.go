@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/pkg/domain"
+)
+
+// OutboxNotifier is told when events have been committed to the outbox
+type OutboxNotifier interface {
+	// Notify wakes the relay so the events are published without waiting
+	// for its next poll
+	Notify()
+}
+
+// OutboxMessageService wraps the message service to store a WebSocket event
+// in the outbox in the same transaction as each message write. The events
+// are broadcast by OutboxRelay and EventSubscriber, so they survive a crash
+// right after the write and reach clients connected to any server.
+type OutboxMessageService struct {
+	messageService MessageService
+	outboxRepo     repository.OutboxRepository
+	transactor     repository.Transactor
+	notifier       OutboxNotifier
+}
+
+// NewOutboxMessageService creates a new outbox message service. notifier may
+// be nil, in which case events wait for the relay's next poll.
+func NewOutboxMessageService(messageService MessageService, outboxRepo repository.OutboxRepository, transactor repository.Transactor, notifier OutboxNotifier) MessageService {
+	return &OutboxMessageService{
+		messageService: messageService,
+		outboxRepo:     outboxRepo,
+		transactor:     transactor,
+		notifier:       notifier,
+	}
+}
+
+// CreateMessage creates a new message together with its new_message event
+func (s *OutboxMessageService) CreateMessage(ctx context.Context, req *domain.CreateMessageRequest, userEmail string) (*domain.Message, error) {
+	var message *domain.Message
+	err := s.write(ctx, func(ctx context.Context) error {
+		var err error
+		message, err = s.messageService.CreateMessage(ctx, req, userEmail)
+		if err != nil {
+			return err
+		}
+		return s.addEvent(ctx, message.ChannelID, domain.EventNewMessage, domain.MessageEventData(message))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// CreateBotMessage stores a bot message together with its new_message event
+func (s *OutboxMessageService) CreateBotMessage(ctx context.Context, message *domain.Message) (*domain.Message, error) {
+	var created *domain.Message
+	write := func(ctx context.Context) error {
+		// Work on a copy so a retried transaction does not reuse the ID
+		// given to the message by the aborted attempt
+		attempt := *message
+		var err error
+		created, err = s.messageService.CreateBotMessage(ctx, &attempt)
+		if err != nil {
+			return err
+		}
+		return s.addEvent(ctx, created.ChannelID, domain.EventNewMessage, domain.MessageEventData(created))
+	}
+
+	err := s.write(ctx, write)
+	if errors.Is(err, domain.ErrConflict) && message.CorrelationID != "" {
+		// Another server stored the same reply during the transaction, which
+		// the conflict aborted; it is found when writing again
+		err = s.write(ctx, write)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetMessage gets a message by ID
+func (s *OutboxMessageService) GetMessage(ctx context.Context, id string) (*domain.Message, error) {
+	return s.messageService.GetMessage(ctx, id)
+}
+
+// GetMessagesByChannel gets messages for a specific channel
+func (s *OutboxMessageService) GetMessagesByChannel(ctx context.Context, channelID string, limit int) ([]*domain.Message, error) {
+	return s.messageService.GetMessagesByChannel(ctx, channelID, limit)
+}
+
+// UpdateMessage updates an existing message together with its
+// message_updated event
+func (s *OutboxMessageService) UpdateMessage(ctx context.Context, id string, content string, userEmail string) (*domain.Message, error) {
+	var message *domain.Message
+	err := s.write(ctx, func(ctx context.Context) error {
+		var err error
+		message, err = s.messageService.UpdateMessage(ctx, id, content, userEmail)
+		if err != nil {
+			return err
+		}
+		return s.addEvent(ctx, message.ChannelID, domain.EventMessageUpdated, domain.MessageEventData(message))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// DeleteMessage deletes a message together with its message_deleted event
+func (s *OutboxMessageService) DeleteMessage(ctx context.Context, id string, userEmail string) error {
+	return s.write(ctx, func(ctx context.Context) error {
+		// Get the message first to know which channel the event is for
+		message, err := s.messageService.GetMessage(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		err = s.messageService.DeleteMessage(ctx, id, userEmail)
+		if err != nil {
+			return err
+		}
+		return s.addEvent(ctx, message.ChannelID, domain.EventMessageDeleted, domain.MessageDeletedEventData(message))
+	})
+}
+
+// write runs fn in a transaction and wakes the relay once it is committed
+func (s *OutboxMessageService) write(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := s.transactor.WithTransaction(ctx, fn); err != nil {
+		return err
+	}
+	if s.notifier != nil {
+		s.notifier.Notify()
+	}
+	return nil
+}
+
+// addEvent stores an event for the clients of a channel in the outbox
+func (s *OutboxMessageService) addEvent(ctx context.Context, channelID, eventType string, data interface{}) error {
+	return addOutboxEvent(ctx, s.outboxRepo, &domain.OutboxEvent{Type: eventType, ChannelID: channelID}, data)
+}
+
+// addOutboxEvent stores event with data as its payload in the outbox
+func addOutboxEvent(ctx context.Context, outboxRepo repository.OutboxRepository, event *domain.OutboxEvent, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	event.Data = encoded
+	return outboxRepo.Add(ctx, event)
+}
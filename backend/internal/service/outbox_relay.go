@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/repository"
+	"log"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	// eventExchange is the fanout exchange carrying WebSocket events to
+	// every server
+	eventExchange = "chat_events"
+
+	// outboxBatchSize is how many events a relay claims at a time
+	outboxBatchSize = 100
+
+	// outboxClaimTTL is how long a relay holds the events it claimed before
+	// another relay may publish them
+	outboxClaimTTL = 30 * time.Second
+
+	// outboxPublishTimeout bounds the wait for the broker to confirm an event
+	outboxPublishTimeout = 5 * time.Second
+)
+
+// OutboxRelay publishes the events stored by OutboxMessageService to the
+// event exchange and marks them delivered. An event whose delivery cannot be
+// recorded is published again, so delivery is at least once.
+type OutboxRelay struct {
+	conn       broker.Broker
+	outboxRepo repository.OutboxRepository
+	interval   time.Duration
+	wake       chan struct{}
+	done       chan struct{}
+}
+
+// NewOutboxRelay creates a relay that polls the outbox every interval, and
+// whenever it is notified of new events
+func NewOutboxRelay(conn broker.Broker, outboxRepo repository.OutboxRepository, interval time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		conn:       conn,
+		outboxRepo: outboxRepo,
+		interval:   interval,
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start begins relaying in the background
+func (r *OutboxRelay) Start() {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.runOnce()
+			case <-r.wake:
+				r.runOnce()
+			case <-r.done:
+				return
+			}
+		}
+	}()
+}
+
+// Notify wakes the relay to publish newly committed events
+func (r *OutboxRelay) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+		// A wake-up is already pending
+	}
+}
+
+// runOnce performs a single relaying pass
+func (r *OutboxRelay) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), outboxClaimTTL)
+	defer cancel()
+
+	if _, err := r.RelayPending(ctx); err != nil {
+		log.Printf("Failed to relay outbox events: %v", err)
+	}
+}
+
+// RelayPending publishes undelivered events, oldest first, until the outbox
+// is empty. It returns how many were published.
+func (r *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	relayed := 0
+	for {
+		events, err := r.outboxRepo.ClaimPending(ctx, outboxBatchSize, time.Now().Add(outboxClaimTTL))
+		if err != nil {
+			return relayed, err
+		}
+
+		for _, event := range events {
+			body, err := json.Marshal(event)
+			if err != nil {
+				return relayed, err
+			}
+
+			publishCtx, cancel := context.WithTimeout(ctx, outboxPublishTimeout)
+			err = r.conn.PublishConfirmed(publishCtx, eventExchange, "", amqp.Publishing{
+				ContentType: "application/json",
+				MessageId:   event.ID,
+				Timestamp:   event.CreatedAt,
+				Body:        body,
+			})
+			cancel()
+			// Without subscribers no server has clients to deliver to
+			if err != nil && !errors.Is(err, broker.ErrUnroutable) {
+				// Stop to keep events in order; unpublished claims expire
+				return relayed, err
+			}
+
+			if err := r.outboxRepo.MarkDelivered(ctx, event.ID, time.Now()); err != nil {
+				return relayed, err
+			}
+			relayed++
+		}
+
+		if len(events) < outboxBatchSize {
+			return relayed, nil
+		}
+	}
+}
+
+// Close stops relaying
+func (r *OutboxRelay) Close() error {
+	close(r.done)
+	return nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"jobsity-backend/internal/commands"
+	"jobsity-backend/pkg/domain"
+)
+
+// NewAlertCommand creates the /alert command, which sets a one-shot price
+// alert (/alert aapl.us > 200) or removes one (/alert remove <id>)
+func NewAlertCommand(alertService StockAlertService) *commands.Command {
+	cmd := &commands.Command{
+		Name: "alert",
+		Args: []commands.Arg{
+			{Name: "stock_code", Required: true},
+			{Name: "condition", Required: true, Variadic: true},
+		},
+		Description: "Get notified once a stock crosses a price, e.g. /alert aapl.us > 200; remove one with /alert remove <id>",
+	}
+	cmd.Handler = func(ctx context.Context, inv *commands.Invocation) (*commands.Result, error) {
+		if strings.EqualFold(inv.Arg(0), "remove") {
+			return removeAlert(ctx, alertService, inv)
+		}
+
+		operator, threshold, err := parseAlertCondition(strings.Join(inv.Args[1:], ""))
+		if err != nil {
+			return nil, &commands.UsageError{Command: cmd, Reason: err.Error()}
+		}
+
+		alert, err := alertService.CreateAlert(ctx, inv.ChannelID, inv.UserEmail, inv.Arg(0), operator, threshold)
+		if err == ErrTooManyAlerts {
+			return &commands.Result{Message: "You already have the maximum number of active alerts, remove one with /alert remove <id>"}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &commands.Result{Message: fmt.Sprintf("Alert %s set: %s", alert.ID, alertCondition(alert))}, nil
+	}
+	return cmd
+}
+
+// NewAlertsCommand creates the /alerts command, which lists the caller's
+// active alerts in the channel
+func NewAlertsCommand(alertService StockAlertService) *commands.Command {
+	return &commands.Command{
+		Name:        "alerts",
+		Description: "List your active price alerts in this channel",
+		Handler: func(ctx context.Context, inv *commands.Invocation) (*commands.Result, error) {
+			alerts, err := alertService.ListAlerts(ctx, inv.ChannelID, inv.UserEmail)
+			if err != nil {
+				return nil, err
+			}
+			if len(alerts) == 0 {
+				return &commands.Result{Message: "You have no active alerts in this channel"}, nil
+			}
+
+			lines := make([]string, len(alerts))
+			for i, alert := range alerts {
+				lines[i] = fmt.Sprintf("%s: %s", alert.ID, alertCondition(alert))
+			}
+			return &commands.Result{Message: strings.Join(lines, "\n")}, nil
+		},
+	}
+}
+
+// removeAlert handles /alert remove <id>
+func removeAlert(ctx context.Context, alertService StockAlertService, inv *commands.Invocation) (*commands.Result, error) {
+	id := inv.Arg(1)
+	err := alertService.RemoveAlert(ctx, id, inv.UserEmail)
+	if errors.Is(err, ErrAlertNotFound) {
+		return &commands.Result{Message: fmt.Sprintf("You have no alert with id %s", id)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &commands.Result{Message: fmt.Sprintf("Alert %s removed", id)}, nil
+}
+
+// parseAlertCondition parses "> 200", ">200" or "<$150.5" into an operator and a price
+func parseAlertCondition(condition string) (string, float64, error) {
+	if condition == "" {
+		return "", 0, errors.New("condition is required")
+	}
+
+	operator := condition[:1]
+	if operator != domain.AlertAbove && operator != domain.AlertBelow {
+		return "", 0, errors.New("condition must start with > or <")
+	}
+
+	price := strings.TrimPrefix(strings.TrimSpace(condition[1:]), "$")
+	threshold, err := strconv.ParseFloat(price, 64)
+	if err != nil || !(threshold > 0) || math.IsInf(threshold, 1) {
+		return "", 0, fmt.Errorf("invalid price %q", price)
+	}
+	return operator, threshold, nil
+}
+
+// alertCondition formats an alert as e.g. "AAPL.US > $200.00"
+func alertCondition(alert *domain.StockAlert) string {
+	return fmt.Sprintf("%s %s $%.2f", strings.ToUpper(alert.StockCode), alert.Operator, alert.Threshold)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/quotes"
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/pkg/domain"
+	"log"
+	"strings"
+	"time"
+)
+
+// StockAlertScheduler periodically checks active price alerts against the
+// quote provider and posts a bot message into the channel of every alert
+// whose threshold was crossed. Alerts fire once and are then deactivated.
+type StockAlertScheduler struct {
+	alertRepo repository.StockAlertRepository
+	provider  quotes.QuoteProvider
+	publisher broker.Publisher
+	interval  time.Duration
+	done      chan struct{}
+}
+
+// NewStockAlertScheduler creates a new stock alert scheduler
+func NewStockAlertScheduler(alertRepo repository.StockAlertRepository, provider quotes.QuoteProvider, publisher broker.Publisher, interval time.Duration) *StockAlertScheduler {
+	return &StockAlertScheduler{
+		alertRepo: alertRepo,
+		provider:  provider,
+		publisher: publisher,
+		interval:  interval,
+		done:      make(chan struct{}),
+	}
+}
+
+// Start begins polling in the background
+func (s *StockAlertScheduler) Start() {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.runOnce()
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// runOnce performs a single polling pass
+func (s *StockAlertScheduler) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.interval)
+	defer cancel()
+
+	fired, err := s.CheckAlerts(ctx)
+	if err != nil {
+		log.Printf("Failed to check stock alerts: %v", err)
+		return
+	}
+	if fired > 0 {
+		log.Printf("Fired %d stock alerts", fired)
+	}
+}
+
+// CheckAlerts looks up a quote for every symbol with active alerts and fires
+// the alerts whose threshold was crossed. It returns how many fired.
+func (s *StockAlertScheduler) CheckAlerts(ctx context.Context) (int, error) {
+	alerts, err := s.alertRepo.FindActive(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Look each symbol up once, however many alerts watch it
+	bySymbol := make(map[string][]*domain.StockAlert)
+	for _, alert := range alerts {
+		symbol := strings.ToLower(alert.StockCode)
+		bySymbol[symbol] = append(bySymbol[symbol], alert)
+	}
+
+	fired := 0
+	for symbol, symbolAlerts := range bySymbol {
+		quote, err := s.provider.Quote(ctx, symbol)
+		if err != nil {
+			if !errors.Is(err, quotes.ErrSymbolNotFound) {
+				log.Printf("Failed to fetch quote for %s alerts: %v", symbol, err)
+			}
+			continue
+		}
+
+		for _, alert := range symbolAlerts {
+			if !alert.Crossed(quote.Close) {
+				continue
+			}
+			ok, err := s.fire(ctx, alert, quote)
+			if err != nil {
+				log.Printf("Failed to fire stock alert %s: %v", alert.ID, err)
+				continue
+			}
+			if ok {
+				fired++
+			}
+		}
+	}
+
+	return fired, nil
+}
+
+// fire deactivates alert and posts its message, reporting false when another
+// scheduler already fired it
+func (s *StockAlertScheduler) fire(ctx context.Context, alert *domain.StockAlert, quote *quotes.Quote) (bool, error) {
+	// Deactivate first so the alert cannot fire twice
+	ok, err := s.alertRepo.MarkTriggered(ctx, alert.ID, time.Now())
+	if err != nil || !ok {
+		return false, err
+	}
+
+	command, err := NewStockCommandMessage(alert.ChannelID, alert.UserEmail, alert.StockCode)
+	if err == nil {
+		message := fmt.Sprintf("Alert for %s: %s is now $%.2f (alert %s)", alert.UserEmail, strings.ToUpper(alert.StockCode), quote.Close, alertCondition(alert))
+		err = publishStockResponse(s.publisher, NewStockResponseMessage(command, message))
+	}
+	if err != nil {
+		// Re-arm so the alert fires on the next pass instead of being lost
+		if rearmErr := s.alertRepo.Rearm(ctx, alert.ID); rearmErr != nil {
+			log.Printf("Failed to re-arm stock alert %s: %v", alert.ID, rearmErr)
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Close stops polling
+func (s *StockAlertScheduler) Close() error {
+	close(s.done)
+	return nil
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"jobsity-backend/pkg/domain"
+)
+
+var (
+	// ErrAlertNotFound is returned when an alert does not exist or belongs to someone else
+	ErrAlertNotFound = errors.New("alert not found")
+
+	// ErrTooManyAlerts is returned when a user already has the maximum number of active alerts
+	ErrTooManyAlerts = errors.New("too many active alerts")
+)
+
+// StockAlertService defines the interface for stock alert business logic
+type StockAlertService interface {
+	// CreateAlert sets a one-shot alert for when stockCode crosses threshold
+	CreateAlert(ctx context.Context, channelID, userEmail, stockCode, operator string, threshold float64) (*domain.StockAlert, error)
+
+	// ListAlerts returns a user's active alerts in a channel
+	ListAlerts(ctx context.Context, channelID, userEmail string) ([]*domain.StockAlert, error)
+
+	// RemoveAlert deletes one of the user's alerts
+	RemoveAlert(ctx context.Context, id, userEmail string) error
+}
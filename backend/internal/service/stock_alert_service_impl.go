@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/pkg/domain"
+	"strings"
+)
+
+// StockAlertServiceImpl implements StockAlertService
+type StockAlertServiceImpl struct {
+	alertRepo  repository.StockAlertRepository
+	maxPerUser int64
+}
+
+// NewStockAlertService creates a new stock alert service
+func NewStockAlertService(alertRepo repository.StockAlertRepository, maxPerUser int64) StockAlertService {
+	return &StockAlertServiceImpl{
+		alertRepo:  alertRepo,
+		maxPerUser: maxPerUser,
+	}
+}
+
+// CreateAlert sets a one-shot alert for when stockCode crosses threshold
+func (s *StockAlertServiceImpl) CreateAlert(ctx context.Context, channelID, userEmail, stockCode, operator string, threshold float64) (*domain.StockAlert, error) {
+	// Validate input
+	stockCode = strings.ToLower(strings.TrimSpace(stockCode))
+	if stockCode == "" {
+		return nil, errors.New("stock code is required")
+	}
+	if operator != domain.AlertAbove && operator != domain.AlertBelow {
+		return nil, errors.New("operator must be > or <")
+	}
+	if threshold <= 0 {
+		return nil, errors.New("threshold must be a positive price")
+	}
+
+	if s.maxPerUser > 0 {
+		count, err := s.alertRepo.CountActiveByUser(ctx, userEmail)
+		if err != nil {
+			return nil, err
+		}
+		if count >= s.maxPerUser {
+			return nil, ErrTooManyAlerts
+		}
+	}
+
+	alert := &domain.StockAlert{
+		ChannelID: channelID,
+		UserEmail: userEmail,
+		StockCode: stockCode,
+		Operator:  operator,
+		Threshold: threshold,
+	}
+
+	err := s.alertRepo.Create(ctx, alert)
+	if err != nil {
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+// ListAlerts returns a user's active alerts in a channel
+func (s *StockAlertServiceImpl) ListAlerts(ctx context.Context, channelID, userEmail string) ([]*domain.StockAlert, error) {
+	return s.alertRepo.FindActiveByUser(ctx, channelID, userEmail)
+}
+
+// RemoveAlert deletes one of the user's alerts
+func (s *StockAlertServiceImpl) RemoveAlert(ctx context.Context, id, userEmail string) error {
+	alert, err := s.alertRepo.FindByID(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrInvalidID) {
+		return ErrAlertNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// Other users' alerts are reported as missing rather than forbidden
+	if alert.UserEmail != userEmail {
+		return ErrAlertNotFound
+	}
+
+	return s.alertRepo.Delete(ctx, id)
+}
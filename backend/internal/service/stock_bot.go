@@ -1,212 +1,272 @@
 package service
 
 import (
-	"encoding/csv"
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/quotes"
+	"math"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/streadway/amqp"
 )
 
-type StockBot struct {
-	conn *amqp.Connection
-	ch   *amqp.Channel
-}
-
-type StockCommand struct {
-	ChannelID string `json:"channel_id"`
-	UserEmail string `json:"user_email"`
-	StockCode string `json:"stock_code"`
-}
+// stockLookupTimeout bounds the time spent looking up one stock command
+const stockLookupTimeout = 30 * time.Second
 
-type StockResponse struct {
-	ChannelID string `json:"channel_id"`
-	Message   string `json:"message"`
-	UserEmail string `json:"user_email"`
+// StockBot answers stock commands from the stock_commands queue. It runs
+// inside the server or as cmd/stockbot; any number of bots, each with
+// options.Consumers consumers, share the queue.
+type StockBot struct {
+	conn      broker.Broker
+	consumers []*broker.Consumer
+	provider  quotes.QuoteProvider
+	options   broker.ConsumerOptions
 }
 
-func NewStockBot(conn *amqp.Connection) (*StockBot, error) {
-	ch, err := conn.Channel()
-	if err != nil {
-		return nil, err
-	}
-
+func NewStockBot(conn broker.Broker, provider quotes.QuoteProvider, options broker.ConsumerOptions) (*StockBot, error) {
 	return &StockBot{
-		conn: conn,
-		ch:   ch,
+		conn:     conn,
+		provider: provider,
+		options:  options,
 	}, nil
 }
 
 func (bot *StockBot) Start() error {
-	// Setup queues
-	err := bot.setupQueues()
+	// Setup queues, again after every reconnect
+	err := bot.conn.DeclareTopology(bot.setupQueues)
 	if err != nil {
 		return err
 	}
 
-	// Start consuming stock commands
-	go bot.consumeStockCommands()
+	// Start consuming stock commands; prefetch shares work between consumers
+	for i := 0; i < max(bot.options.Consumers, 1); i++ {
+		bot.consumers = append(bot.consumers, bot.conn.Consume("stock_commands", bot.options.Prefetch, bot.handleStockCommand))
+	}
 
 	return nil
 }
 
-func (bot *StockBot) setupQueues() error {
-	// Declare stock_commands queue
-	_, err := bot.ch.QueueDeclare(
-		"stock_commands", // name
-		true,             // durable
-		false,            // delete when unused
-		false,            // exclusive
-		false,            // no-wait
-		nil,              // arguments
-	)
-	if err != nil {
-		return err
-	}
+// Consumers returns the number of consumers answering stock commands
+func (bot *StockBot) Consumers() int {
+	return len(bot.consumers)
+}
 
-	// Declare stock_responses queue
-	_, err = bot.ch.QueueDeclare(
-		"stock_responses", // name
-		true,              // durable
-		false,             // delete when unused
-		false,             // exclusive
-		false,             // no-wait
-		nil,               // arguments
-	)
+func (bot *StockBot) setupQueues(ch *amqp.Channel) error {
+	// Declare stock_commands queue with its retry and dead-letter queues
+	err := broker.DeclareQueue(ch, "stock_commands", bot.options.Retry)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// Declare stock_responses queue with its retry and dead-letter queues
+	return broker.DeclareQueue(ch, "stock_responses", bot.options.Retry)
 }
 
-func (bot *StockBot) consumeStockCommands() {
-	msgs, err := bot.ch.Consume(
-		"stock_commands", // queue
-		"",               // consumer
-		true,             // auto-ack
-		false,            // exclusive
-		false,            // no-local
-		false,            // no-wait
-		nil,              // args
-	)
-	if err != nil {
-		fmt.Printf("Failed to register a consumer: %s\n", err)
-		return
+func (bot *StockBot) handleStockCommand(msg amqp.Delivery) {
+	processErr := bot.processStockCommand(msg.Body, broker.IsLastAttempt(msg, bot.options.Retry))
+	if processErr != nil {
+		fmt.Printf("Failed to process stock command (attempt %d): %v\n", broker.RetryCount(msg)+1, processErr)
 	}
-
-	for msg := range msgs {
-		bot.processStockCommand(msg.Body)
+	if err := broker.Settle(bot.conn, "stock_commands", msg, bot.options.Retry, processErr); err != nil {
+		fmt.Printf("Failed to settle stock command: %s\n", err)
 	}
 }
 
-func (bot *StockBot) processStockCommand(body []byte) {
-	// Parse the stock command
-	command := string(body)
-	parts := strings.Split(command, "|")
-	if len(parts) != 3 {
-		fmt.Printf("Invalid stock command format: %s\n", command)
-		return
+// processStockCommand looks up quotes and replies on stock_responses. Lookup
+// failures are returned so the command is retried; on the last attempt the
+// user is told about the failure instead and the command is done.
+func (bot *StockBot) processStockCommand(body []byte, lastAttempt bool) error {
+	// Parse the stock command, accepting both JSON envelopes and the legacy pipe format
+	command, err := DecodeStockCommand(body)
+	if err != nil {
+		return broker.Permanent(err)
 	}
 
-	channelID := parts[0]
-	userEmail := parts[1]
-	stockCode := parts[2]
+	ctx, cancel := context.WithTimeout(context.Background(), stockLookupTimeout)
+	defer cancel()
 
-	// Fetch stock data
-	stockData, err := bot.fetchStockData(stockCode)
+	lines, found, err := bot.lookup(ctx, command.Symbols(), command.Detail)
 	if err != nil {
-		fmt.Printf("Error fetching stock data for %s: %v\n", stockCode, err)
-		// Send error message back
-		errorMsg := fmt.Sprintf("Error fetching stock data for %s", stockCode)
-		bot.sendStockResponse(channelID, userEmail, errorMsg)
-		return
+		fmt.Printf("Error fetching stock data for %s: %v\n", strings.Join(command.Symbols(), ","), err)
+		if !lastAttempt {
+			return err
+		}
 	}
 
-	// Format the response
-	response := fmt.Sprintf("%s quote is $%.2f per share", strings.ToUpper(stockCode), stockData.Price)
-	bot.sendStockResponse(channelID, userEmail, response)
+	// Quotes are posted to the channel, while the lines of the symbols that
+	// were not found, or failed on the last attempt, are shown to the
+	// requester only. A retry after a failed publish posts the quotes again,
+	// which the response handler stores once by correlation ID.
+	var quoted, failed []string
+	for i, line := range lines {
+		if found[i] {
+			quoted = append(quoted, line)
+		} else {
+			failed = append(failed, line)
+		}
+	}
+	if len(quoted) > 0 {
+		err := publishStockResponse(bot.conn, NewStockResponseMessage(command, strings.Join(quoted, "\n")))
+		if err != nil {
+			return err
+		}
+	}
+	if len(failed) > 0 {
+		response := NewStockResponseMessage(command, strings.Join(failed, "\n"))
+		response.Ephemeral = true
+		return publishStockResponse(bot.conn, response)
+	}
+	return nil
 }
 
-func (bot *StockBot) fetchStockData(stockCode string) (*StockData, error) {
-	url := fmt.Sprintf("https://stooq.com/q/l/?s=%s&f=sd2t2ohlcv&h&e=csv", stockCode)
+// Reply looks up every stock code and formats the bot's answer, one line per
+// code. Unknown symbols are answered rather than returned as errors. When a
+// lookup fails the reply is still complete, with an error line for that
+// code, and the first failure is returned so the command can be retried.
+func (bot *StockBot) Reply(ctx context.Context, stockCodes []string, detail bool) (string, error) {
+	lines, _, err := bot.lookup(ctx, stockCodes, detail)
+	return strings.Join(lines, "\n"), err
+}
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+// lookup looks up every stock code concurrently, returning the reply lines,
+// whether each code was found and the first lookup failure
+func (bot *StockBot) lookup(ctx context.Context, stockCodes []string, detail bool) ([]string, []bool, error) {
+	lines := make([]string, len(stockCodes))
+	found := make([]bool, len(stockCodes))
+	errs := make([]error, len(stockCodes))
+
+	var wg sync.WaitGroup
+	for i, stockCode := range stockCodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lines[i], found[i], errs[i] = bot.replyLine(ctx, stockCode, detail)
+		}()
 	}
+	wg.Wait()
 
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, err
+	for _, err := range errs {
+		if err != nil {
+			return lines, found, err
+		}
 	}
-	defer resp.Body.Close()
+	return lines, found, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status code: %d", resp.StatusCode)
-	}
+// replyLine looks up one stock code and formats its line of the reply,
+// reporting whether the stock was found
+func (bot *StockBot) replyLine(ctx context.Context, stockCode string, detail bool) (string, bool, error) {
+	failed := fmt.Sprintf("Error fetching stock data for %s", stockCode)
 
-	// Parse CSV response
-	reader := csv.NewReader(resp.Body)
-	records, err := reader.ReadAll()
+	quote, err := bot.provider.Quote(ctx, stockCode)
+	if errors.Is(err, quotes.ErrSymbolNotFound) {
+		return failed, false, nil
+	}
 	if err != nil {
-		return nil, err
+		return failed, false, err
 	}
 
-	if len(records) < 2 {
-		return nil, fmt.Errorf("invalid CSV response")
+	line := fmt.Sprintf("%s quote is $%.2f per share", strings.ToUpper(stockCode), quote.Close)
+	if detail {
+		line += " (" + quoteDetail(quote) + ")"
 	}
+	return line, true, nil
+}
 
-	// Skip header row, get first data row
-	dataRow := records[1]
-	if len(dataRow) < 5 {
-		return nil, fmt.Errorf("invalid CSV data format")
+// quoteDetail formats the rest of a quote. stooq's quote has no previous
+// close, so the change on the day is measured from the open. Fields the
+// provider had no data for are left out.
+func quoteDetail(quote *quotes.Quote) string {
+	var parts []string
+	if quote.Open > 0 {
+		parts = append(parts, fmt.Sprintf("open $%.2f", quote.Open))
+	}
+	if quote.High > 0 {
+		parts = append(parts, fmt.Sprintf("high $%.2f", quote.High))
+	}
+	if quote.Low > 0 {
+		parts = append(parts, fmt.Sprintf("low $%.2f", quote.Low))
 	}
+	if quote.Volume > 0 {
+		parts = append(parts, "volume "+formatThousands(quote.Volume))
+	}
+	if quote.Open > 0 {
+		parts = append(parts, "change "+formatChange(quote))
+	}
+	if !quote.Time.IsZero() {
+		parts = append(parts, "as of "+quote.Time.Format("2006-01-02 15:04"))
+	}
+	if len(parts) == 0 {
+		return "no further data"
+	}
+	return strings.Join(parts, ", ")
+}
 
-	// Parse the close price (index 4 in the CSV)
-	var price float64
-	_, err = fmt.Sscanf(dataRow[4], "%f", &price)
-	if err != nil {
-		return nil, fmt.Errorf("invalid price format: %s", dataRow[4])
+// formatChange formats the change from the open, e.g. "-$0.81 (-0.45%)"
+func formatChange(quote *quotes.Quote) string {
+	change := quote.Close - quote.Open
+	sign := "+"
+	if change < 0 {
+		sign = "-"
 	}
+	return fmt.Sprintf("%s$%.2f (%s%.2f%%)", sign, math.Abs(change), sign, math.Abs(change/quote.Open*100))
+}
 
-	return &StockData{
-		Symbol: dataRow[0],
-		Price:  price,
-	}, nil
+// formatThousands formats n with comma thousands separators
+func formatThousands(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return b.String()
 }
 
-func (bot *StockBot) sendStockResponse(channelID, userEmail, message string) {
-	response := fmt.Sprintf("%s|%s|%s|%s", channelID, userEmail, "stock_bot", message)
+// publishStockResponse sends a bot reply to the stock_responses queue
+func publishStockResponse(publisher broker.Publisher, response *StockResponse) error {
+	publishing, err := response.Publishing()
+	if err != nil {
+		return err
+	}
 
-	err := bot.ch.Publish(
+	return publisher.Publish(
 		"",                // exchange
 		"stock_responses", // routing key
 		false,             // mandatory
 		false,             // immediate
-		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        []byte(response),
-		},
+		publishing,
 	)
+}
 
-	if err != nil {
-		fmt.Printf("Failed to publish stock response: %s\n", err)
+// Shutdown stops consuming stock commands, letting commands being looked up
+// finish until ctx expires. Commands that were not answered are requeued.
+func (bot *StockBot) Shutdown(ctx context.Context) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(bot.consumers))
+	for i, consumer := range bot.consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = consumer.Drain(ctx)
+		}()
 	}
+	wg.Wait()
+
+	return errors.Join(errs...)
 }
 
+// Close stops consuming stock commands
 func (bot *StockBot) Close() error {
-	if bot.ch != nil {
-		bot.ch.Close()
-	}
-	if bot.conn != nil {
-		bot.conn.Close()
+	for _, consumer := range bot.consumers {
+		consumer.Cancel()
 	}
 	return nil
 }
-
-type StockData struct {
-	Symbol string
-	Price  float64
-}
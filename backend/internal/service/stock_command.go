@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"jobsity-backend/internal/commands"
+)
+
+const (
+	// maxStockBatch bounds how many symbols one command may ask for
+	maxStockBatch = 10
+
+	// stockDetailFlag asks /stock for the full quote, as /quote does
+	stockDetailFlag = "--detail"
+)
+
+// NewStockCommand creates the /stock command, which hands the quote lookup to
+// the stock bot through the stock_commands queue. The quote is posted to the
+// channel once the bot replies on stock_responses.
+func NewStockCommand(publisher CommandPublisher) *commands.Command {
+	cmd := &commands.Command{
+		Name:        "stock",
+		Args:        []commands.Arg{{Name: "stock_code", Required: true, Variadic: true}},
+		Description: "Post the latest quote for one or more stocks, e.g. /stock=aapl.us,msft.us; add --detail for the full quote",
+		Async:       true,
+	}
+	cmd.Handler = stockCommandHandler(cmd, publisher, false)
+	return cmd
+}
+
+// NewQuoteCommand creates the /quote command, a /stock that always replies
+// with open, high, low, volume and the change on the day
+func NewQuoteCommand(publisher CommandPublisher) *commands.Command {
+	cmd := &commands.Command{
+		Name:        "quote",
+		Args:        []commands.Arg{{Name: "stock_code", Required: true, Variadic: true}},
+		Description: "Post the full quote for one or more stocks, e.g. /quote=aapl.us",
+		Async:       true,
+	}
+	cmd.Handler = stockCommandHandler(cmd, publisher, true)
+	return cmd
+}
+
+// stockCommandHandler enqueues a stock command for the symbols of an invocation
+func stockCommandHandler(cmd *commands.Command, publisher CommandPublisher, detail bool) commands.HandlerFunc {
+	return func(ctx context.Context, inv *commands.Invocation) (*commands.Result, error) {
+		stockCodes, detailFlag := parseStockArgs(inv.Args)
+		if len(stockCodes) == 0 {
+			return nil, &commands.UsageError{Command: cmd, Reason: "stock_code is required"}
+		}
+		if len(stockCodes) > maxStockBatch {
+			return nil, &commands.UsageError{Command: cmd, Reason: fmt.Sprintf("at most %d stock codes can be requested at once", maxStockBatch)}
+		}
+
+		command, err := NewStockCommandMessage(inv.ChannelID, inv.UserEmail, stockCodes...)
+		if err != nil {
+			return nil, err
+		}
+		command.Detail = detail || detailFlag
+		publishing, err := command.Publishing()
+		if err != nil {
+			return nil, err
+		}
+
+		// Only acknowledge the command once the broker has stored it
+		err = publisher.PublishCommand(ctx, "stock_commands", publishing)
+		if err != nil {
+			return nil, err
+		}
+
+		return &commands.Result{Message: "Stock command processed"}, nil
+	}
+}
+
+// parseStockArgs splits "aapl.us,msft.us --detail" style arguments into
+// distinct stock codes and the detail flag
+func parseStockArgs(args []string) ([]string, bool) {
+	var stockCodes []string
+	detail := false
+	seen := make(map[string]bool)
+
+	for _, arg := range args {
+		if strings.EqualFold(arg, stockDetailFlag) {
+			detail = true
+			continue
+		}
+		for _, code := range strings.Split(arg, ",") {
+			code = strings.ToLower(strings.TrimSpace(code))
+			if code == "" || seen[code] {
+				continue
+			}
+			seen[code] = true
+			stockCodes = append(stockCodes, code)
+		}
+	}
+	return stockCodes, detail
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// StockSchemaVersion is the version of the JSON envelope written to the
+// stock_commands and stock_responses queues
+const StockSchemaVersion = 1
+
+const (
+	stockCommandType  = "stock_command"
+	stockResponseType = "stock_response"
+	stockBotAuthor    = "stock_bot"
+)
+
+var (
+	// ErrInvalidStockMessage is returned when a queue message cannot be decoded
+	ErrInvalidStockMessage = errors.New("invalid stock message")
+
+	// ErrUnsupportedSchemaVersion is returned for envelopes newer than this build understands
+	ErrUnsupportedSchemaVersion = errors.New("unsupported stock message schema version")
+)
+
+// StockEnvelope holds the metadata shared by every stock queue message
+type StockEnvelope struct {
+	SchemaVersion int       `json:"schema_version"`
+	Type          string    `json:"type"`
+	CorrelationID string    `json:"correlation_id"`
+	Requester     string    `json:"requester"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// StockCommand asks the stock bot to look up one or more quotes
+type StockCommand struct {
+	StockEnvelope
+	ChannelID string `json:"channel_id"`
+
+	// StockCode is the first symbol, so bots that predate batches still
+	// answer for it; StockCodes lists every symbol of a batch
+	StockCode  string   `json:"stock_code"`
+	StockCodes []string `json:"stock_codes,omitempty"`
+
+	// Detail asks for open, high, low, volume and the change on the day
+	Detail bool `json:"detail,omitempty"`
+}
+
+// StockResponse is the stock bot's reply to a StockCommand
+type StockResponse struct {
+	StockEnvelope
+	ChannelID   string    `json:"channel_id"`
+	Author      string    `json:"author"`
+	Message     string    `json:"message"`
+	RequestedAt time.Time `json:"requested_at,omitempty"`
+
+	// Ephemeral replies, such as errors, go only to the requester and are
+	// not stored in the channel history
+	Ephemeral bool `json:"ephemeral,omitempty"`
+}
+
+// NewStockCommandMessage creates a stock command with a fresh correlation id
+func NewStockCommandMessage(channelID, requester string, stockCodes ...string) (*StockCommand, error) {
+	if len(stockCodes) == 0 {
+		return nil, fmt.Errorf("%w: a stock code is required", ErrInvalidStockMessage)
+	}
+	correlationID, err := newCorrelationID()
+	if err != nil {
+		return nil, err
+	}
+
+	command := &StockCommand{
+		StockEnvelope: StockEnvelope{
+			SchemaVersion: StockSchemaVersion,
+			Type:          stockCommandType,
+			CorrelationID: correlationID,
+			Requester:     requester,
+			CreatedAt:     time.Now().UTC(),
+		},
+		ChannelID: channelID,
+		StockCode: stockCodes[0],
+	}
+	if len(stockCodes) > 1 {
+		command.StockCodes = stockCodes
+	}
+	return command, nil
+}
+
+// Symbols returns every stock code the command asks for
+func (cmd *StockCommand) Symbols() []string {
+	if len(cmd.StockCodes) > 0 {
+		return cmd.StockCodes
+	}
+	return []string{cmd.StockCode}
+}
+
+// NewStockResponseMessage creates the bot reply to command, carrying over its
+// correlation id and requester
+func NewStockResponseMessage(command *StockCommand, message string) *StockResponse {
+	return &StockResponse{
+		StockEnvelope: StockEnvelope{
+			SchemaVersion: StockSchemaVersion,
+			Type:          stockResponseType,
+			CorrelationID: command.CorrelationID,
+			Requester:     command.Requester,
+			CreatedAt:     time.Now().UTC(),
+		},
+		ChannelID:   command.ChannelID,
+		Author:      stockBotAuthor,
+		Message:     message,
+		RequestedAt: command.CreatedAt,
+	}
+}
+
+// Publishing encodes the command for the stock_commands queue
+func (cmd *StockCommand) Publishing() (amqp.Publishing, error) {
+	return cmd.StockEnvelope.publishing(cmd)
+}
+
+// Publishing encodes the response for the stock_responses queue
+func (resp *StockResponse) Publishing() (amqp.Publishing, error) {
+	return resp.StockEnvelope.publishing(resp)
+}
+
+func (env StockEnvelope) publishing(message interface{}) (amqp.Publishing, error) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return amqp.Publishing{}, err
+	}
+
+	return amqp.Publishing{
+		ContentType:   "application/json",
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: env.CorrelationID,
+		Type:          env.Type,
+		Timestamp:     env.CreatedAt,
+		Body:          body,
+	}, nil
+}
+
+// DecodeStockCommand decodes a stock_commands message. Besides the JSON
+// envelope it accepts the legacy "channelID|userEmail|stockCode" format.
+func DecodeStockCommand(body []byte) (*StockCommand, error) {
+	if !isJSONEnvelope(body) {
+		parts := strings.Split(string(body), "|")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidStockMessage, body)
+		}
+		return &StockCommand{
+			StockEnvelope: legacyStockEnvelope(stockCommandType, parts[1], body),
+			ChannelID:     parts[0],
+			StockCode:     parts[2],
+		}, nil
+	}
+
+	var cmd StockCommand
+	if err := decodeStockEnvelope(body, stockCommandType, &cmd.StockEnvelope, &cmd); err != nil {
+		return nil, err
+	}
+	if cmd.ChannelID == "" || cmd.StockCode == "" {
+		return nil, fmt.Errorf("%w: channel_id and stock_code are required", ErrInvalidStockMessage)
+	}
+	if len(cmd.StockCodes) > maxStockBatch {
+		return nil, fmt.Errorf("%w: at most %d stock_codes are allowed", ErrInvalidStockMessage, maxStockBatch)
+	}
+	return &cmd, nil
+}
+
+// DecodeStockResponse decodes a stock_responses message. Besides the JSON
+// envelope it accepts the legacy "channelID|userEmail|author|message" format.
+func DecodeStockResponse(body []byte) (*StockResponse, error) {
+	if !isJSONEnvelope(body) {
+		// The message is last so any pipes it contains are kept
+		parts := strings.SplitN(string(body), "|", 4)
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidStockMessage, body)
+		}
+		return &StockResponse{
+			StockEnvelope: legacyStockEnvelope(stockResponseType, parts[1], body),
+			ChannelID:     parts[0],
+			Author:        parts[2],
+			Message:       parts[3],
+		}, nil
+	}
+
+	var resp StockResponse
+	if err := decodeStockEnvelope(body, stockResponseType, &resp.StockEnvelope, &resp); err != nil {
+		return nil, err
+	}
+	if resp.ChannelID == "" {
+		return nil, fmt.Errorf("%w: channel_id is required", ErrInvalidStockMessage)
+	}
+	if resp.Author == "" {
+		resp.Author = stockBotAuthor
+	}
+	return &resp, nil
+}
+
+// decodeStockEnvelope unmarshals body into message and checks its envelope
+func decodeStockEnvelope(body []byte, messageType string, env *StockEnvelope, message interface{}) error {
+	if err := json.Unmarshal(body, message); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidStockMessage, err)
+	}
+	if env.SchemaVersion < 1 || env.SchemaVersion > StockSchemaVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedSchemaVersion, env.SchemaVersion)
+	}
+	if env.Type != "" && env.Type != messageType {
+		return fmt.Errorf("%w: unexpected type %q", ErrInvalidStockMessage, env.Type)
+	}
+	env.Type = messageType
+	return nil
+}
+
+// legacyStockEnvelope fills in the metadata missing from pipe-format
+// messages. The correlation id is derived from body, so a redelivered message
+// keeps its id and its reply is stored once.
+func legacyStockEnvelope(messageType, requester string, body []byte) StockEnvelope {
+	digest := sha256.Sum256(body)
+	return StockEnvelope{
+		Type:          messageType,
+		CorrelationID: hex.EncodeToString(digest[:]),
+		Requester:     requester,
+		CreatedAt:     time.Now().UTC(),
+	}
+}
+
+// isJSONEnvelope reports whether body looks like a JSON object rather than a
+// legacy pipe-separated message
+func isJSONEnvelope(body []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("{"))
+}
+
+// newCorrelationID generates a random id used to trace a command through the queues
+func newCorrelationID() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(random), nil
+}
@@ -1,18 +1,24 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"strings"
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/pkg/domain"
 	"time"
 
 	"github.com/streadway/amqp"
 )
 
+// StockResponseHandler stores bot replies from the stock_responses queue as
+// channel messages, which also broadcasts them to the channel. Ephemeral
+// replies are sent to the requester only and not stored.
 type StockResponseHandler struct {
-	conn          *amqp.Connection
-	ch            *amqp.Channel
-	broadcastFunc func(channelID string, message []byte)
+	conn           broker.Broker
+	consumer       *broker.Consumer
+	messageService MessageService
+	sendEphemeral  EphemeralSender
+	options        broker.ConsumerOptions
 }
 
 type StockResponseMessage struct {
@@ -22,85 +28,80 @@ type StockResponseMessage struct {
 	Timestamp string `json:"timestamp"`
 }
 
-func NewStockResponseHandler(conn *amqp.Connection, broadcastFunc func(channelID string, message []byte)) (*StockResponseHandler, error) {
-	ch, err := conn.Channel()
-	if err != nil {
-		return nil, err
-	}
+// storeTimeout bounds how long storing a single bot reply may take
+const storeTimeout = 10 * time.Second
 
+func NewStockResponseHandler(conn broker.Broker, messageService MessageService, sendEphemeral EphemeralSender, options broker.ConsumerOptions) (*StockResponseHandler, error) {
 	return &StockResponseHandler{
-		conn:          conn,
-		ch:            ch,
-		broadcastFunc: broadcastFunc,
+		conn:           conn,
+		messageService: messageService,
+		sendEphemeral:  sendEphemeral,
+		options:        options,
 	}, nil
 }
 
 func (h *StockResponseHandler) Start() error {
 	// Start consuming stock responses
-	go h.consumeStockResponses()
+	h.consumer = h.conn.Consume("stock_responses", h.options.Prefetch, h.handleStockResponse)
 	return nil
 }
 
-func (h *StockResponseHandler) consumeStockResponses() {
-	msgs, err := h.ch.Consume(
-		"stock_responses", // queue
-		"",                // consumer
-		true,              // auto-ack
-		false,             // exclusive
-		false,             // no-local
-		false,             // no-wait
-		nil,               // args
-	)
-	if err != nil {
-		fmt.Printf("Failed to register a consumer for stock responses: %s\n", err)
-		return
+func (h *StockResponseHandler) handleStockResponse(msg amqp.Delivery) {
+	processErr := h.processStockResponse(msg.Body)
+	if processErr != nil {
+		fmt.Printf("Failed to process stock response: %v\n", processErr)
 	}
-
-	for msg := range msgs {
-		h.processStockResponse(msg.Body)
+	if err := broker.Settle(h.conn, "stock_responses", msg, h.options.Retry, processErr); err != nil {
+		fmt.Printf("Failed to settle stock response: %s\n", err)
 	}
 }
 
-func (h *StockResponseHandler) processStockResponse(body []byte) {
-	// Parse the stock response
-	response := string(body)
-	parts := strings.Split(response, "|")
-	if len(parts) != 4 {
-		fmt.Printf("Invalid stock response format: %s\n", response)
-		return
+func (h *StockResponseHandler) processStockResponse(body []byte) error {
+	// Parse the stock response, accepting both JSON envelopes and the legacy pipe format
+	response, err := DecodeStockResponse(body)
+	if err != nil {
+		return broker.Permanent(err)
 	}
 
-	channelID := parts[0]
-	_ = parts[1] // userEmail - not used in this context
-	botEmail := parts[2]
-	message := parts[3]
-
-	// Create the bot message
-	botMessage := map[string]interface{}{
-		"type": "new_message",
-		"data": map[string]interface{}{
-			"id":          fmt.Sprintf("bot_%d", time.Now().UnixNano()),
-			"channel_id":  channelID,
-			"user_email":  botEmail,
-			"content":     message,
-			"created_at":  time.Now().Format("2006-01-02T15:04:05Z07:00"),
-		},
+	if response.Ephemeral {
+		message := NewEphemeralMessage(response.ChannelID, response.Author, domain.AuthorBot, response.Message)
+		sent := SendEphemeral(h.sendEphemeral, response.Requester, message)
+		fmt.Printf("Sent ephemeral stock response %s to %d connections of %s\n", response.CorrelationID, sent, response.Requester)
+		return nil
 	}
 
-	messageBytes, err := json.Marshal(botMessage)
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	// Store the bot message; a failure is retried like any other processing error
+	message, err := h.messageService.CreateBotMessage(ctx, &domain.Message{
+		ChannelID:     response.ChannelID,
+		UserEmail:     response.Author,
+		AuthorType:    domain.AuthorBot,
+		CorrelationID: response.CorrelationID,
+		Content:       response.Message,
+	})
 	if err != nil {
-		fmt.Printf("Error marshaling stock response: %v\n", err)
-		return
+		return err
 	}
 
-	// Broadcast to WebSocket clients
-	h.broadcastFunc(channelID, messageBytes)
-	fmt.Printf("Broadcasted stock response to channel %s: %s\n", channelID, message)
+	fmt.Printf("Stored stock response %s as message %s in channel %s: %s\n", response.CorrelationID, message.ID, response.ChannelID, response.Message)
+	return nil
+}
+
+// Shutdown stops consuming stock responses, letting the response being
+// posted finish until ctx expires. Responses not posted are requeued.
+func (h *StockResponseHandler) Shutdown(ctx context.Context) error {
+	if h.consumer == nil {
+		return nil
+	}
+	return h.consumer.Drain(ctx)
 }
 
+// Close stops consuming stock responses
 func (h *StockResponseHandler) Close() error {
-	if h.ch != nil {
-		h.ch.Close()
+	if h.consumer != nil {
+		h.consumer.Cancel()
 	}
 	return nil
 }
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/imaging"
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/internal/storage"
+	"jobsity-backend/pkg/domain"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	// attachmentProcessingQueue carries uploads waiting for thumbnail generation
+	attachmentProcessingQueue = "attachment_processing"
+
+	// thumbnailMaxSize is the longest edge of generated thumbnails, in pixels
+	thumbnailMaxSize = 320
+
+	// processingTimeout bounds the time spent on a single attachment
+	processingTimeout = 2 * time.Minute
+
+	// processingPrefetch limits the jobs buffered by one worker
+	processingPrefetch = 1
+)
+
+// AttachmentProcessingJob is the queue payload for attachment processing
+type AttachmentProcessingJob struct {
+	AttachmentID string `json:"attachment_id"`
+}
+
+// ThumbnailWorker generates thumbnails and strips metadata from uploaded
+// images. Failed jobs are retried with retry, since an image that keeps its
+// metadata must not be left as it was uploaded.
+type ThumbnailWorker struct {
+	conn           broker.Broker
+	consumer       *broker.Consumer
+	attachmentRepo repository.AttachmentRepository
+	messageRepo    repository.MessageRepository
+	blobStore      storage.BlobStore
+	notifyFunc     func(channelID string, messageType string, data interface{})
+	retry          broker.RetryPolicy
+}
+
+// NewThumbnailWorker creates a new thumbnail worker
+func NewThumbnailWorker(conn broker.Broker, attachmentRepo repository.AttachmentRepository, messageRepo repository.MessageRepository, blobStore storage.BlobStore, notifyFunc func(channelID string, messageType string, data interface{}), retry broker.RetryPolicy) (*ThumbnailWorker, error) {
+	return &ThumbnailWorker{
+		conn:           conn,
+		attachmentRepo: attachmentRepo,
+		messageRepo:    messageRepo,
+		blobStore:      blobStore,
+		notifyFunc:     notifyFunc,
+		retry:          retry,
+	}, nil
+}
+
+// Start declares the processing queue and begins consuming jobs
+func (w *ThumbnailWorker) Start() error {
+	// Declare attachment_processing with its retry and dead-letter queues,
+	// again after every reconnect
+	err := w.conn.DeclareTopology(func(ch *amqp.Channel) error {
+		return broker.DeclareQueue(ch, attachmentProcessingQueue, w.retry)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Start consuming processing jobs
+	w.consumer = w.conn.Consume(attachmentProcessingQueue, processingPrefetch, w.handleJob)
+
+	return nil
+}
+
+// Enqueue schedules background processing for an uploaded attachment
+func (w *ThumbnailWorker) Enqueue(attachment *domain.Attachment) error {
+	if !imaging.IsSupported(attachment.ContentType) {
+		return nil
+	}
+
+	body, err := json.Marshal(AttachmentProcessingJob{AttachmentID: attachment.ID})
+	if err != nil {
+		return err
+	}
+
+	return w.conn.Publish(
+		"",                        // exchange
+		attachmentProcessingQueue, // routing key
+		false,                     // mandatory
+		false,                     // immediate
+		amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			Body:         body,
+		},
+	)
+}
+
+func (w *ThumbnailWorker) handleJob(msg amqp.Delivery) {
+	processErr := w.processJob(msg.Body)
+	if processErr != nil {
+		fmt.Printf("Failed to process attachment job: %v\n", processErr)
+	}
+	if err := broker.Settle(w.conn, attachmentProcessingQueue, msg, w.retry, processErr); err != nil {
+		fmt.Printf("Failed to settle attachment job: %s\n", err)
+	}
+}
+
+// processJob decodes a job and processes its attachment
+func (w *ThumbnailWorker) processJob(body []byte) error {
+	var job AttachmentProcessingJob
+	if err := json.Unmarshal(body, &job); err != nil {
+		return broker.Permanent(fmt.Errorf("invalid attachment processing job %q: %w", string(body), err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), processingTimeout)
+	defer cancel()
+	if err := w.ProcessAttachment(ctx, job.AttachmentID); err != nil {
+		return fmt.Errorf("attachment %s: %w", job.AttachmentID, err)
+	}
+	return nil
+}
+
+// ProcessAttachment strips metadata from an uploaded image, generates its
+// thumbnail and notifies the channel once the attachment is ready. Images
+// that cannot be decoded fail with a broker.Permanent error.
+func (w *ThumbnailWorker) ProcessAttachment(ctx context.Context, id string) error {
+	attachment, err := w.attachmentRepo.FindByID(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		// Deleted before it was processed
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if attachment.ProcessedAt != nil || !imaging.IsSupported(attachment.ContentType) {
+		return nil
+	}
+
+	original, err := w.readBlob(ctx, attachment.StorageKey)
+	if err != nil {
+		return err
+	}
+
+	// Replace the original with a copy that carries no EXIF/text metadata
+	stripped, err := imaging.StripMetadata(attachment.ContentType, original)
+	if err != nil {
+		// The same bytes would fail the same way again
+		return broker.Permanent(err)
+	}
+	if !bytes.Equal(stripped, original) {
+		err = w.blobStore.Put(ctx, attachment.StorageKey, bytes.NewReader(stripped), int64(len(stripped)), attachment.ContentType)
+		if err != nil {
+			return err
+		}
+	}
+
+	thumbnail, err := imaging.MakeThumbnail(attachment.ContentType, stripped, thumbnailMaxSize)
+	if err != nil {
+		return broker.Permanent(err)
+	}
+
+	thumbnailKey := attachment.StorageKey + ".thumb"
+	err = w.blobStore.Put(ctx, thumbnailKey, bytes.NewReader(thumbnail.Data), int64(len(thumbnail.Data)), thumbnail.ContentType)
+	if err != nil {
+		return err
+	}
+
+	processedAt := time.Now()
+	attachment.Size = int64(len(stripped))
+	attachment.Width = thumbnail.SourceWidth
+	attachment.Height = thumbnail.SourceHeight
+	attachment.ThumbnailKey = thumbnailKey
+	attachment.ThumbnailType = thumbnail.ContentType
+	attachment.ThumbnailWidth = thumbnail.Width
+	attachment.ThumbnailHeight = thumbnail.Height
+	attachment.ProcessedAt = &processedAt
+
+	err = w.attachmentRepo.UpdateProcessed(ctx, attachment)
+	if err != nil {
+		return err
+	}
+
+	// Re-read the attachment: it may have been linked to a message meanwhile
+	current, err := w.attachmentRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current.MessageID != "" {
+		err = w.messageRepo.UpdateAttachment(ctx, current.MessageID, current)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.notifyFunc != nil {
+		w.notifyFunc(current.ChannelID, "attachment_processed", current)
+	}
+
+	return nil
+}
+
+// readBlob reads a whole blob into memory
+func (w *ThumbnailWorker) readBlob(ctx context.Context, key string) ([]byte, error) {
+	reader, err := w.blobStore.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// Shutdown stops consuming processing jobs, letting the job being handled
+// finish until ctx expires
+func (w *ThumbnailWorker) Shutdown(ctx context.Context) error {
+	if w.consumer == nil {
+		return nil
+	}
+	return w.consumer.Drain(ctx)
+}
+
+// Close stops consuming processing jobs
+func (w *ThumbnailWorker) Close() error {
+	if w.consumer != nil {
+		w.consumer.Cancel()
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"jobsity-backend/pkg/domain"
+	"log"
+)
+
+// LinkUnfurlQueue schedules link preview fetching for messages
+type LinkUnfurlQueue interface {
+	// Enqueue schedules unfurling for the links in a message
+	Enqueue(message *domain.Message) error
+}
+
+// UnfurlMessageService wraps the message service to unfurl links in new and edited messages
+type UnfurlMessageService struct {
+	messageService MessageService
+	unfurlQueue    LinkUnfurlQueue
+}
+
+// NewUnfurlMessageService creates a new link-unfurling message service
+func NewUnfurlMessageService(messageService MessageService, unfurlQueue LinkUnfurlQueue) MessageService {
+	return &UnfurlMessageService{
+		messageService: messageService,
+		unfurlQueue:    unfurlQueue,
+	}
+}
+
+// CreateMessage creates a new message and schedules unfurling of its links
+func (s *UnfurlMessageService) CreateMessage(ctx context.Context, req *domain.CreateMessageRequest, userEmail string) (*domain.Message, error) {
+	message, err := s.messageService.CreateMessage(ctx, req, userEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	s.enqueue(message)
+	return message, nil
+}
+
+// CreateBotMessage stores a bot message and schedules unfurling of its links
+func (s *UnfurlMessageService) CreateBotMessage(ctx context.Context, message *domain.Message) (*domain.Message, error) {
+	message, err := s.messageService.CreateBotMessage(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	s.enqueue(message)
+	return message, nil
+}
+
+// GetMessage gets a message by ID
+func (s *UnfurlMessageService) GetMessage(ctx context.Context, id string) (*domain.Message, error) {
+	return s.messageService.GetMessage(ctx, id)
+}
+
+// GetMessagesByChannel gets messages for a specific channel
+func (s *UnfurlMessageService) GetMessagesByChannel(ctx context.Context, channelID string, limit int) ([]*domain.Message, error) {
+	return s.messageService.GetMessagesByChannel(ctx, channelID, limit)
+}
+
+// UpdateMessage updates a message and schedules unfurling of its new links,
+// which also clears the previews of links it no longer has
+func (s *UnfurlMessageService) UpdateMessage(ctx context.Context, id string, content string, userEmail string) (*domain.Message, error) {
+	message, err := s.messageService.UpdateMessage(ctx, id, content, userEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	s.enqueue(message)
+	return message, nil
+}
+
+// DeleteMessage deletes a message
+func (s *UnfurlMessageService) DeleteMessage(ctx context.Context, id string, userEmail string) error {
+	return s.messageService.DeleteMessage(ctx, id, userEmail)
+}
+
+// enqueue schedules unfurling; previews are best effort and never fail the request
+func (s *UnfurlMessageService) enqueue(message *domain.Message) {
+	if err := s.unfurlQueue.Enqueue(message); err != nil {
+		log.Printf("Failed to enqueue link unfurl for message %s: %v", message.ID, err)
+	}
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/internal/unfurl"
+	"jobsity-backend/pkg/domain"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	// linkUnfurlQueue carries messages whose links need previews
+	linkUnfurlQueue = "link_unfurls"
+
+	// unfurlJobTimeout bounds the time spent unfurling the links of one message
+	unfurlJobTimeout = 30 * time.Second
+
+	// unfurlPrefetch limits the jobs buffered by one worker
+	unfurlPrefetch = 4
+)
+
+// LinkUnfurlJob is the queue payload for link unfurling
+type LinkUnfurlJob struct {
+	MessageID string   `json:"message_id"`
+	ChannelID string   `json:"channel_id"`
+	URLs      []string `json:"urls"`
+
+	// ContentHash identifies the content the URLs were taken from, so jobs
+	// for content that was edited since are skipped
+	ContentHash string `json:"content_hash"`
+}
+
+// ContentHash returns the hash of message content stored in unfurl jobs
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// LinkPreviewFetcher fetches preview metadata for a URL
+type LinkPreviewFetcher interface {
+	// Fetch returns the preview for a URL
+	Fetch(ctx context.Context, rawURL string) (*domain.LinkPreview, error)
+}
+
+// UnfurlWorker fetches link previews for new messages in the background
+type UnfurlWorker struct {
+	conn        broker.Broker
+	consumer    *broker.Consumer
+	messageRepo repository.MessageRepository
+	fetcher     LinkPreviewFetcher
+	notifyFunc  func(channelID string, messageType string, data interface{})
+}
+
+// NewUnfurlWorker creates a new link unfurl worker
+func NewUnfurlWorker(conn broker.Broker, messageRepo repository.MessageRepository, fetcher LinkPreviewFetcher, notifyFunc func(channelID string, messageType string, data interface{})) (*UnfurlWorker, error) {
+	return &UnfurlWorker{
+		conn:        conn,
+		messageRepo: messageRepo,
+		fetcher:     fetcher,
+		notifyFunc:  notifyFunc,
+	}, nil
+}
+
+// Start declares the unfurl queue and begins consuming jobs
+func (w *UnfurlWorker) Start() error {
+	// Declare link_unfurls queue, again after every reconnect
+	err := w.conn.DeclareTopology(func(ch *amqp.Channel) error {
+		_, err := ch.QueueDeclare(
+			linkUnfurlQueue, // name
+			true,            // durable
+			false,           // delete when unused
+			false,           // exclusive
+			false,           // no-wait
+			nil,             // arguments
+		)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Start consuming unfurl jobs
+	w.consumer = w.conn.Consume(linkUnfurlQueue, unfurlPrefetch, w.handleJob)
+
+	return nil
+}
+
+// Enqueue schedules unfurling for the links in a message. Messages without
+// links are only queued if they have previews, which the job then clears.
+func (w *UnfurlWorker) Enqueue(message *domain.Message) error {
+	urls := unfurl.ExtractURLs(message.Content)
+	if len(urls) == 0 && len(message.Previews) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(LinkUnfurlJob{
+		MessageID:   message.ID,
+		ChannelID:   message.ChannelID,
+		URLs:        urls,
+		ContentHash: ContentHash(message.Content),
+	})
+	if err != nil {
+		return err
+	}
+
+	return w.conn.Publish(
+		"",              // exchange
+		linkUnfurlQueue, // routing key
+		false,           // mandatory
+		false,           // immediate
+		amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			Body:         body,
+		},
+	)
+}
+
+func (w *UnfurlWorker) handleJob(msg amqp.Delivery) {
+	// Previews are best effort, so failed jobs are not retried
+	defer msg.Ack(false)
+
+	var job LinkUnfurlJob
+	if err := json.Unmarshal(msg.Body, &job); err != nil {
+		fmt.Printf("Invalid link unfurl job: %s\n", string(msg.Body))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), unfurlJobTimeout)
+	defer cancel()
+	if err := w.ProcessJob(ctx, &job); err != nil {
+		fmt.Printf("Failed to unfurl links for message %s: %v\n", job.MessageID, err)
+	}
+}
+
+// ProcessJob fetches previews for the job's URLs, replaces the previews of
+// the message with them and pushes an unfurl event to the channel. Jobs for
+// messages that were deleted or edited since they were queued are skipped.
+func (w *UnfurlWorker) ProcessJob(ctx context.Context, job *LinkUnfurlJob) error {
+	message, err := w.messageRepo.FindByID(ctx, job.MessageID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if ContentHash(message.Content) != job.ContentHash {
+		return nil
+	}
+
+	previews := []*domain.LinkPreview{}
+	for _, rawURL := range job.URLs {
+		preview, err := w.fetcher.Fetch(ctx, rawURL)
+		if err != nil {
+			fmt.Printf("No preview for %s: %v\n", rawURL, err)
+			continue
+		}
+		previews = append(previews, preview)
+	}
+
+	if len(previews) == 0 && len(message.Previews) == 0 {
+		return nil
+	}
+
+	// Fails if the message was edited while the previews were fetched; the
+	// job queued for the edit replaces them instead
+	err = w.messageRepo.UpdatePreviews(ctx, job.MessageID, message.Content, previews)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if w.notifyFunc != nil {
+		w.notifyFunc(job.ChannelID, "unfurl", map[string]interface{}{
+			"id":         job.MessageID,
+			"channel_id": job.ChannelID,
+			"previews":   previews,
+		})
+	}
+
+	return nil
+}
+
+// Shutdown stops consuming unfurl jobs, letting the job being handled
+// finish until ctx expires
+func (w *UnfurlWorker) Shutdown(ctx context.Context) error {
+	if w.consumer == nil {
+		return nil
+	}
+	return w.consumer.Drain(ctx)
+}
+
+// Close stops consuming unfurl jobs
+func (w *UnfurlWorker) Close() error {
+	if w.consumer != nil {
+		w.consumer.Cancel()
+	}
+	return nil
+}
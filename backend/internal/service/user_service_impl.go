@@ -7,8 +7,6 @@ import (
 
 	"jobsity-backend/internal/repository"
 	"jobsity-backend/pkg/domain"
-
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // UserServiceImpl implements UserService
@@ -36,17 +34,14 @@ func (s *UserServiceImpl) Login(ctx context.Context, req *domain.LoginRequest) (
 	// Find user by email
 	userEntity, err := s.userRepo.FindByEmail(ctx, req.Email)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if errors.Is(err, domain.ErrNotFound) {
 			return &domain.LoginResponse{
 				Success: false,
 				Message: "Invalid email or password",
 			}, nil
 		}
 		log.Printf("Database error during login: %v", err)
-		return &domain.LoginResponse{
-			Success: false,
-			Message: "Internal server error",
-		}, nil
+		return nil, err
 	}
 
 	// Check password (in a real app, you'd hash the password)
@@ -70,16 +65,16 @@ func (s *UserServiceImpl) Login(ctx context.Context, req *domain.LoginRequest) (
 func (s *UserServiceImpl) CreateUser(ctx context.Context, req *domain.CreateUserRequest) (*domain.User, error) {
 	// Validate input
 	if req.Email == "" || req.Password == "" {
-		return nil, errors.New("email and password are required")
+		return nil, domain.Invalid("email and password are required")
 	}
 
 	// Check if user already exists
 	existingUser, err := s.userRepo.FindByEmail(ctx, req.Email)
-	if err != nil && err != mongo.ErrNoDocuments {
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
 		return nil, err
 	}
 	if existingUser != nil {
-		return nil, errors.New("user with this email already exists")
+		return nil, domain.Conflict("user with this email already exists")
 	}
 
 	// Create new user
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"jobsity-backend/internal/commands"
+	"jobsity-backend/pkg/domain"
+)
+
+// NewWatchCommand creates the /watch command, which shows the channel's
+// watchlist, adds stocks to it (/watch aapl.us msft.us) or changes when its
+// digest is posted (/watch schedule 0 21 * * 1-5)
+func NewWatchCommand(watchlistService WatchlistService) *commands.Command {
+	cmd := &commands.Command{
+		Name:        "watch",
+		Args:        []commands.Arg{{Name: "stock_code", Variadic: true}},
+		Description: "Follow stocks in this channel with a scheduled digest, e.g. /watch aapl.us msft.us; change the schedule with /watch schedule <cron>",
+	}
+	cmd.Handler = func(ctx context.Context, inv *commands.Invocation) (*commands.Result, error) {
+		if len(inv.Args) == 0 {
+			return showWatchlist(ctx, watchlistService, inv.ChannelID)
+		}
+
+		var (
+			watchlist *domain.Watchlist
+			err       error
+		)
+		if strings.EqualFold(inv.Arg(0), "schedule") {
+			if len(inv.Args) == 1 {
+				return nil, &commands.UsageError{Command: cmd, Reason: "a cron schedule is required"}
+			}
+			watchlist, err = watchlistService.SetSchedule(ctx, inv.ChannelID, inv.UserEmail, strings.Join(inv.Args[1:], " "))
+		} else {
+			stockCodes, _ := parseStockArgs(inv.Args)
+			if len(stockCodes) == 0 {
+				return nil, &commands.UsageError{Command: cmd, Reason: "stock_code is required"}
+			}
+			watchlist, err = watchlistService.Watch(ctx, inv.ChannelID, inv.UserEmail, stockCodes)
+		}
+
+		switch {
+		case errors.Is(err, ErrInvalidSchedule):
+			return nil, &commands.UsageError{Command: cmd, Reason: err.Error()}
+		case err == ErrNoWatchlist:
+			return &commands.Result{Message: "This channel has no watchlist, add stocks with /watch <stock_code>"}, nil
+		case err == ErrWatchlistFull:
+			return &commands.Result{Message: "This channel's watchlist is full, remove stocks with /unwatch <stock_code>"}, nil
+		case err != nil:
+			return nil, err
+		}
+
+		return &commands.Result{Message: describeWatchlist(watchlist)}, nil
+	}
+	return cmd
+}
+
+// NewUnwatchCommand creates the /unwatch command, which removes stocks from
+// the channel's watchlist
+func NewUnwatchCommand(watchlistService WatchlistService) *commands.Command {
+	return &commands.Command{
+		Name:        "unwatch",
+		Args:        []commands.Arg{{Name: "stock_code", Required: true, Variadic: true}},
+		Description: "Stop following stocks in this channel, e.g. /unwatch aapl.us",
+		Handler: func(ctx context.Context, inv *commands.Invocation) (*commands.Result, error) {
+			stockCodes, _ := parseStockArgs(inv.Args)
+			watchlist, err := watchlistService.Unwatch(ctx, inv.ChannelID, inv.UserEmail, stockCodes)
+			if err == ErrNoWatchlist {
+				return &commands.Result{Message: "This channel has no watchlist"}, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if len(watchlist.StockCodes) == 0 {
+				return &commands.Result{Message: "This channel no longer watches any stocks"}, nil
+			}
+			return &commands.Result{Message: describeWatchlist(watchlist)}, nil
+		},
+	}
+}
+
+// showWatchlist handles /watch without arguments
+func showWatchlist(ctx context.Context, watchlistService WatchlistService, channelID string) (*commands.Result, error) {
+	watchlist, err := watchlistService.GetWatchlist(ctx, channelID)
+	if err == ErrNoWatchlist {
+		return &commands.Result{Message: "This channel has no watchlist, add stocks with /watch <stock_code>"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &commands.Result{Message: describeWatchlist(watchlist)}, nil
+}
+
+// describeWatchlist summarises a watchlist and when its next digest is due
+func describeWatchlist(watchlist *domain.Watchlist) string {
+	return fmt.Sprintf("This channel watches %s; digest schedule %q (UTC), next at %s",
+		strings.ToUpper(strings.Join(watchlist.StockCodes, ", ")),
+		watchlist.Schedule,
+		watchlist.NextRunAt.UTC().Format("2006-01-02 15:04 UTC"))
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/cron"
+	"jobsity-backend/internal/quotes"
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/pkg/domain"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WatchlistScheduler posts each channel's watchlist digest on the channel's
+// cron schedule, through the stock_responses queue like any other bot reply.
+//
+// Catch-up: when the server was down at a scheduled time, the missed digest
+// is posted once it is back, provided it is no more than catchUpWindow late;
+// older runs are skipped. Several missed runs produce a single digest.
+type WatchlistScheduler struct {
+	watchlistRepo repository.WatchlistRepository
+	provider      quotes.QuoteProvider
+	publisher     broker.Publisher
+	interval      time.Duration
+	catchUpWindow time.Duration
+	done          chan struct{}
+}
+
+// NewWatchlistScheduler creates a new watchlist digest scheduler
+func NewWatchlistScheduler(watchlistRepo repository.WatchlistRepository, provider quotes.QuoteProvider, publisher broker.Publisher, interval, catchUpWindow time.Duration) *WatchlistScheduler {
+	return &WatchlistScheduler{
+		watchlistRepo: watchlistRepo,
+		provider:      provider,
+		publisher:     publisher,
+		interval:      interval,
+		catchUpWindow: catchUpWindow,
+		done:          make(chan struct{}),
+	}
+}
+
+// Start begins checking for due digests in the background, starting with
+// any digests missed while the server was down
+func (s *WatchlistScheduler) Start() {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.runOnce()
+
+			select {
+			case <-ticker.C:
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// runOnce performs a single check for due digests
+func (s *WatchlistScheduler) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.interval)
+	defer cancel()
+
+	posted, err := s.RunDue(ctx, time.Now().UTC())
+	if err != nil {
+		log.Printf("Failed to run watchlist digests: %v", err)
+		return
+	}
+	if posted > 0 {
+		log.Printf("Posted %d watchlist digests", posted)
+	}
+}
+
+// RunDue posts the digests due at now and schedules their next run. It
+// returns how many digests were posted.
+func (s *WatchlistScheduler) RunDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.watchlistRepo.FindDue(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	posted := 0
+	for _, watchlist := range due {
+		ok, err := s.run(ctx, watchlist, now)
+		if err != nil {
+			log.Printf("Failed to post watchlist digest for channel %s: %v", watchlist.ChannelID, err)
+			continue
+		}
+		if ok {
+			posted++
+		}
+	}
+
+	return posted, nil
+}
+
+// run claims a due watchlist run and posts its digest, reporting whether a
+// digest was posted
+func (s *WatchlistScheduler) run(ctx context.Context, watchlist *domain.Watchlist, now time.Time) (bool, error) {
+	schedule, err := cron.Parse(watchlist.Schedule)
+	if err != nil {
+		return false, err
+	}
+
+	// Claim the run first so only one scheduler posts it
+	claimed, err := s.watchlistRepo.ClaimRun(ctx, watchlist.ID, watchlist.NextRunAt, now, schedule.Next(now))
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	late := now.Sub(watchlist.NextRunAt)
+	if late > s.catchUpWindow {
+		log.Printf("Skipping watchlist digest for channel %s, it was due %s ago", watchlist.ChannelID, late.Round(time.Second))
+		return false, nil
+	}
+	if len(watchlist.StockCodes) == 0 {
+		return false, nil
+	}
+
+	digest := s.digest(ctx, watchlist, late)
+	command, err := NewStockCommandMessage(watchlist.ChannelID, watchlist.UpdatedBy, watchlist.StockCodes...)
+	if err != nil {
+		return false, err
+	}
+	err = publishStockResponse(s.publisher, NewStockResponseMessage(command, digest))
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// digest formats the open, close and change of every watched stock
+func (s *WatchlistScheduler) digest(ctx context.Context, watchlist *domain.Watchlist, late time.Duration) string {
+	lines := make([]string, len(watchlist.StockCodes)+1)
+
+	lines[0] = fmt.Sprintf("Watchlist digest for %s", watchlist.NextRunAt.UTC().Format("2006-01-02 15:04 UTC"))
+	if late > s.interval {
+		lines[0] += fmt.Sprintf(" (delayed by %d min)", int(late.Round(time.Minute).Minutes()))
+	}
+
+	var wg sync.WaitGroup
+	for i, stockCode := range watchlist.StockCodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lines[i+1] = s.digestLine(ctx, stockCode)
+		}()
+	}
+	wg.Wait()
+
+	return strings.Join(lines, "\n")
+}
+
+// digestLine formats one stock of a digest
+func (s *WatchlistScheduler) digestLine(ctx context.Context, stockCode string) string {
+	quote, err := s.provider.Quote(ctx, stockCode)
+	if err != nil {
+		if !errors.Is(err, quotes.ErrSymbolNotFound) {
+			log.Printf("Failed to fetch quote for %s digest: %v", stockCode, err)
+		}
+		return fmt.Sprintf("%s: Error fetching stock data", strings.ToUpper(stockCode))
+	}
+
+	if quote.Open <= 0 {
+		return fmt.Sprintf("%s: close $%.2f", strings.ToUpper(stockCode), quote.Close)
+	}
+	return fmt.Sprintf("%s: open $%.2f, close $%.2f, change %s", strings.ToUpper(stockCode), quote.Open, quote.Close, formatChange(quote))
+}
+
+// Close stops the scheduler
+func (s *WatchlistScheduler) Close() error {
+	close(s.done)
+	return nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"jobsity-backend/pkg/domain"
+)
+
+var (
+	// ErrNoWatchlist is returned when a channel does not watch any stocks
+	ErrNoWatchlist = errors.New("channel has no watchlist")
+
+	// ErrWatchlistFull is returned when a watchlist would exceed the symbol limit
+	ErrWatchlistFull = errors.New("watchlist is full")
+
+	// ErrInvalidSchedule is returned for cron expressions that cannot be used
+	ErrInvalidSchedule = errors.New("invalid schedule")
+)
+
+// WatchlistService defines the interface for channel watchlist business logic
+type WatchlistService interface {
+	// GetWatchlist returns the watchlist of a channel, or ErrNoWatchlist
+	GetWatchlist(ctx context.Context, channelID string) (*domain.Watchlist, error)
+
+	// Watch adds stock codes to a channel's watchlist, creating it with the
+	// default schedule if needed
+	Watch(ctx context.Context, channelID, userEmail string, stockCodes []string) (*domain.Watchlist, error)
+
+	// Unwatch removes stock codes from a channel's watchlist, deleting it
+	// once it is empty
+	Unwatch(ctx context.Context, channelID, userEmail string, stockCodes []string) (*domain.Watchlist, error)
+
+	// SetSchedule changes the cron schedule of a channel's digest
+	SetSchedule(ctx context.Context, channelID, userEmail, schedule string) (*domain.Watchlist, error)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jobsity-backend/internal/cron"
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/pkg/domain"
+	"strings"
+	"time"
+)
+
+// WatchlistServiceImpl implements WatchlistService
+type WatchlistServiceImpl struct {
+	watchlistRepo   repository.WatchlistRepository
+	defaultSchedule string
+	maxSymbols      int
+}
+
+// NewWatchlistService creates a new watchlist service. New watchlists get
+// defaultSchedule, which must be a valid cron expression.
+func NewWatchlistService(watchlistRepo repository.WatchlistRepository, defaultSchedule string, maxSymbols int) (WatchlistService, error) {
+	if _, err := cron.Parse(defaultSchedule); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	return &WatchlistServiceImpl{
+		watchlistRepo:   watchlistRepo,
+		defaultSchedule: defaultSchedule,
+		maxSymbols:      maxSymbols,
+	}, nil
+}
+
+// GetWatchlist returns the watchlist of a channel, or ErrNoWatchlist
+func (s *WatchlistServiceImpl) GetWatchlist(ctx context.Context, channelID string) (*domain.Watchlist, error) {
+	watchlist, err := s.watchlistRepo.FindByChannel(ctx, channelID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrNoWatchlist
+	}
+	return watchlist, err
+}
+
+// Watch adds stock codes to a channel's watchlist
+func (s *WatchlistServiceImpl) Watch(ctx context.Context, channelID, userEmail string, stockCodes []string) (*domain.Watchlist, error) {
+	watchlist, err := s.GetWatchlist(ctx, channelID)
+	if err == ErrNoWatchlist {
+		watchlist = &domain.Watchlist{ChannelID: channelID, Schedule: s.defaultSchedule}
+		err = s.reschedule(watchlist)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, code := range stockCodes {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if code == "" || containsString(watchlist.StockCodes, code) {
+			continue
+		}
+		if s.maxSymbols > 0 && len(watchlist.StockCodes) >= s.maxSymbols {
+			return nil, ErrWatchlistFull
+		}
+		watchlist.StockCodes = append(watchlist.StockCodes, code)
+	}
+
+	watchlist.UpdatedBy = userEmail
+	err = s.watchlistRepo.Save(ctx, watchlist)
+	if err != nil {
+		return nil, err
+	}
+
+	return watchlist, nil
+}
+
+// Unwatch removes stock codes from a channel's watchlist
+func (s *WatchlistServiceImpl) Unwatch(ctx context.Context, channelID, userEmail string, stockCodes []string) (*domain.Watchlist, error) {
+	watchlist, err := s.GetWatchlist(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := watchlist.StockCodes[:0]
+	for _, code := range watchlist.StockCodes {
+		if !containsString(stockCodes, code) {
+			remaining = append(remaining, code)
+		}
+	}
+	watchlist.StockCodes = remaining
+	watchlist.UpdatedBy = userEmail
+
+	if len(watchlist.StockCodes) == 0 {
+		err = s.watchlistRepo.Delete(ctx, channelID)
+	} else {
+		err = s.watchlistRepo.Save(ctx, watchlist)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return watchlist, nil
+}
+
+// SetSchedule changes the cron schedule of a channel's digest
+func (s *WatchlistServiceImpl) SetSchedule(ctx context.Context, channelID, userEmail, schedule string) (*domain.Watchlist, error) {
+	watchlist, err := s.GetWatchlist(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	watchlist.Schedule = schedule
+	if err := s.reschedule(watchlist); err != nil {
+		return nil, err
+	}
+
+	watchlist.UpdatedBy = userEmail
+	err = s.watchlistRepo.Save(ctx, watchlist)
+	if err != nil {
+		return nil, err
+	}
+
+	return watchlist, nil
+}
+
+// reschedule validates the watchlist's schedule and sets its next run
+func (s *WatchlistServiceImpl) reschedule(watchlist *domain.Watchlist) error {
+	schedule, err := cron.Parse(watchlist.Schedule)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	next := schedule.Next(time.Now().UTC())
+	if next.IsZero() {
+		return fmt.Errorf("%w: %q never fires", ErrInvalidSchedule, watchlist.Schedule)
+	}
+
+	watchlist.Schedule = schedule.String()
+	watchlist.NextRunAt = next
+	return nil
+}
+
+// containsString reports whether values contains value, ignoring case
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"jobsity-backend/internal/websocket"
 	"jobsity-backend/pkg/domain"
-	"time"
 )
 
 // WebSocketMessageService wraps the message service with WebSocket broadcasting
@@ -30,13 +29,20 @@ func (s *WebSocketMessageService) CreateMessage(ctx context.Context, req *domain
 	}
 
 	// Broadcast the new message to all clients in the channel
-	s.wsHandler.BroadcastMessage(req.ChannelID, "new_message", map[string]interface{}{
-		"id":         message.ID,
-		"channel_id": message.ChannelID,
-		"user_email": message.UserEmail,
-		"content":    message.Content,
-		"created_at": message.CreatedAt.Format(time.RFC3339),
-	})
+	s.wsHandler.BroadcastMessage(req.ChannelID, domain.EventNewMessage, domain.MessageEventData(message))
+
+	return message, nil
+}
+
+// CreateBotMessage stores a bot message and broadcasts it via WebSocket
+func (s *WebSocketMessageService) CreateBotMessage(ctx context.Context, message *domain.Message) (*domain.Message, error) {
+	message, err := s.messageService.CreateBotMessage(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	// Broadcast the new message to all clients in the channel
+	s.wsHandler.BroadcastMessage(message.ChannelID, domain.EventNewMessage, domain.MessageEventData(message))
 
 	return message, nil
 }
@@ -60,13 +66,7 @@ func (s *WebSocketMessageService) UpdateMessage(ctx context.Context, id string,
 	}
 
 	// Broadcast the message update to all clients in the channel
-	s.wsHandler.BroadcastMessage(message.ChannelID, "message_updated", map[string]interface{}{
-		"id":         message.ID,
-		"channel_id": message.ChannelID,
-		"user_email": message.UserEmail,
-		"content":    message.Content,
-		"created_at": message.CreatedAt.Format(time.RFC3339),
-	})
+	s.wsHandler.BroadcastMessage(message.ChannelID, domain.EventMessageUpdated, domain.MessageEventData(message))
 
 	return message, nil
 }
@@ -86,11 +86,7 @@ func (s *WebSocketMessageService) DeleteMessage(ctx context.Context, id string,
 	}
 
 	// Broadcast the message deletion to all clients in the channel
-	s.wsHandler.BroadcastMessage(message.ChannelID, "message_deleted", map[string]interface{}{
-		"id":         message.ID,
-		"channel_id": message.ChannelID,
-		"user_email": message.UserEmail,
-	})
+	s.wsHandler.BroadcastMessage(message.ChannelID, domain.EventMessageDeleted, domain.MessageDeletedEventData(message))
 
 	return nil
 }
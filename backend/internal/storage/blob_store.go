@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned when a blob does not exist in the store
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore defines the interface for storing uploaded file contents
+type BlobStore interface {
+	// Put stores the contents of r under key
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Get opens the blob stored under key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the blob stored under key
+	Delete(ctx context.Context, key string) error
+}
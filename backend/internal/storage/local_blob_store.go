@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalBlobStore implements BlobStore on the local filesystem
+type LocalBlobStore struct {
+	root string
+}
+
+// NewLocalBlobStore creates a new local filesystem blob store rooted at dir
+func NewLocalBlobStore(dir string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalBlobStore{
+		root: dir,
+	}, nil
+}
+
+// Put stores the contents of r under key
+func (s *LocalBlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see partial blobs
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get opens the blob stored under key
+func (s *LocalBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrBlobNotFound
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// Delete removes the blob stored under key
+func (s *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path resolves a key to a file path inside the store root
+func (s *LocalBlobStore) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if key == "" || cleaned == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid blob key: %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
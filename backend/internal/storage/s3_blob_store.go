@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// unsignedPayload tells S3 not to verify the request body hash
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Config holds configuration for an S3-compatible object store
+type S3Config struct {
+	Endpoint  string // e.g. https://s3.amazonaws.com or http://localhost:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3BlobStore implements BlobStore against any S3-compatible API (AWS S3, MinIO, ...)
+// using path-style addressing and AWS Signature Version 4
+type S3BlobStore struct {
+	config S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3BlobStore creates a new S3-compatible blob store
+func NewS3BlobStore(config S3Config) (*S3BlobStore, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, fmt.Errorf("s3 endpoint and bucket are required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+
+	return &S3BlobStore{
+		config: config,
+		client: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+		now: time.Now,
+	}, nil
+}
+
+// Put stores the contents of r under key
+func (s *S3BlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get opens the blob stored under key
+func (s *S3BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes the blob stored under key
+func (s *S3BlobStore) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		if err == ErrBlobNotFound {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// newRequest builds a request for the object stored under key
+func (s *S3BlobStore) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if key == "" {
+		return nil, fmt.Errorf("invalid blob key: %q", key)
+	}
+
+	objectURL := s.config.Endpoint + "/" + s.config.Bucket + "/" + encodePath(key)
+	return http.NewRequestWithContext(ctx, method, objectURL, body)
+}
+
+// do signs and sends the request, mapping error status codes to errors
+func (s *S3BlobStore) do(req *http.Request) (*http.Response, error) {
+	s.sign(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrBlobNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 %s %s returned status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	return resp, nil
+}
+
+// sign adds AWS Signature Version 4 headers to the request
+func (s *S3BlobStore) sign(req *http.Request) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	// Canonical headers must be lowercase and sorted
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if req.Header.Get("Content-Type") != "" {
+		signed = append(signed, "content-type")
+	}
+	sort.Strings(signed)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range signed {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+// encodePath URI-encodes each segment of an object key
+func encodePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "+", "%2B")
+	}
+	return strings.Join(segments, "/")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,103 @@
+package unfurl
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"jobsity-backend/pkg/domain"
+)
+
+// cacheEntry is a cached preview or a cached failure
+type cacheEntry struct {
+	url       string
+	preview   *domain.LinkPreview
+	err       error
+	expiresAt time.Time
+}
+
+// CachingFetcher wraps a Fetcher with a bounded in-memory TTL cache. Failures
+// are cached for a shorter time so broken links are not refetched on every post.
+type CachingFetcher struct {
+	fetcher     *Fetcher
+	ttl         time.Duration
+	negativeTTL time.Duration
+	maxEntries  int
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used at the front
+}
+
+// NewCachingFetcher creates a new caching preview fetcher
+func NewCachingFetcher(fetcher *Fetcher, ttl time.Duration, maxEntries int) *CachingFetcher {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &CachingFetcher{
+		fetcher:     fetcher,
+		ttl:         ttl,
+		negativeTTL: ttl / 10,
+		maxEntries:  maxEntries,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+	}
+}
+
+// Fetch returns the cached preview for rawURL, fetching it on a miss
+func (c *CachingFetcher) Fetch(ctx context.Context, rawURL string) (*domain.LinkPreview, error) {
+	if entry, ok := c.get(rawURL); ok {
+		return entry.preview, entry.err
+	}
+
+	preview, err := c.fetcher.Fetch(ctx, rawURL)
+
+	// Don't cache cancellations; they say nothing about the URL
+	if ctx.Err() == nil {
+		ttl := c.ttl
+		if err != nil {
+			ttl = c.negativeTTL
+		}
+		c.set(&cacheEntry{url: rawURL, preview: preview, err: err, expiresAt: time.Now().Add(ttl)})
+	}
+
+	return preview, err
+}
+
+func (c *CachingFetcher) get(rawURL string) (*cacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[rawURL]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, rawURL)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry, true
+}
+
+func (c *CachingFetcher) set(entry *cacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[entry.url]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[entry.url] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).url)
+	}
+}
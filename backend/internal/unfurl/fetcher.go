@@ -0,0 +1,332 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"jobsity-backend/pkg/domain"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	// ErrBlockedAddress is returned when a URL resolves to a disallowed IP address
+	ErrBlockedAddress = errors.New("destination address is not allowed")
+
+	// ErrNotHTML is returned when a URL does not serve an HTML document
+	ErrNotHTML = errors.New("response is not an HTML document")
+)
+
+// Field length caps for previews
+const (
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+	maxRedirects         = 3
+)
+
+// blockedNetworks lists address ranges that must never be fetched unless
+// explicitly allow-listed
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",      // "this" network
+	"10.0.0.0/8",     // private
+	"100.64.0.0/10",  // carrier-grade NAT
+	"127.0.0.0/8",    // loopback
+	"169.254.0.0/16", // link-local, cloud metadata endpoints
+	"172.16.0.0/12",  // private
+	"192.0.0.0/24",   // IETF protocol assignments
+	"192.168.0.0/16", // private
+	"198.18.0.0/15",  // benchmarking
+	"224.0.0.0/4",    // multicast
+	"240.0.0.0/4",    // reserved
+	"::/128",         // unspecified
+	"::1/128",        // loopback
+	"64:ff9b::/96",   // NAT64
+	"fc00::/7",       // unique local
+	"fe80::/10",      // link-local
+	"ff00::/8",       // multicast
+)
+
+// FetcherOptions holds limits for outbound preview requests
+type FetcherOptions struct {
+	Timeout  time.Duration
+	MaxBytes int64
+	// AllowedNetworks are CIDRs exempt from the private range block (e.g. for tests)
+	AllowedNetworks []string
+	UserAgent       string
+}
+
+// Fetcher downloads web pages and extracts preview metadata, refusing to
+// connect to private or otherwise internal addresses
+type Fetcher struct {
+	client   *http.Client
+	maxBytes int64
+	agent    string
+}
+
+// NewFetcher creates a new preview fetcher
+func NewFetcher(options FetcherOptions) (*Fetcher, error) {
+	allowed, err := parseCIDRs(options.AllowedNetworks)
+	if err != nil {
+		return nil, err
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 5 * time.Second
+	}
+	if options.MaxBytes <= 0 {
+		options.MaxBytes = 512 << 10
+	}
+	if options.UserAgent == "" {
+		options.UserAgent = "JobsityChatBot/1.0 (+link preview)"
+	}
+
+	dialer := &net.Dialer{
+		Timeout: options.Timeout,
+		// Checking the address at connect time also covers DNS rebinding and redirects
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isAllowedIP(ip, allowed) {
+				return ErrBlockedAddress
+			}
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		// Never go through an environment proxy, which would bypass the address check
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   options.Timeout,
+		ResponseHeaderTimeout: options.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &Fetcher{
+		client: &http.Client{
+			Timeout:   options.Timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return errors.New("too many redirects")
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return fmt.Errorf("unsupported redirect scheme: %s", req.URL.Scheme)
+				}
+				return nil
+			},
+		},
+		maxBytes: options.MaxBytes,
+		agent:    options.UserAgent,
+	}, nil
+}
+
+// Fetch downloads rawURL and extracts its title and OpenGraph metadata
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*domain.LinkPreview, error) {
+	pageURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if pageURL.Scheme != "http" && pageURL.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme: %s", pageURL.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.agent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, ErrNotHTML
+	}
+
+	preview := parsePreview(io.LimitReader(resp.Body, f.maxBytes), resp.Request.URL)
+	preview.URL = rawURL
+	preview.FetchedAt = time.Now()
+
+	if preview.Title == "" && preview.Description == "" {
+		return nil, errors.New("page has no preview metadata")
+	}
+
+	return preview, nil
+}
+
+// parsePreview reads the document head and collects title and OpenGraph tags
+func parsePreview(r io.Reader, base *url.URL) *domain.LinkPreview {
+	preview := &domain.LinkPreview{}
+	var title string
+
+	tokenizer := html.NewTokenizer(r)
+	inTitle := false
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			// EOF or the size cap was reached
+			return finishPreview(preview, title, base)
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = true
+			case "meta":
+				if hasAttr {
+					applyMeta(preview, metaAttributes(tokenizer))
+				}
+			case "body":
+				// Metadata lives in the head
+				return finishPreview(preview, title, base)
+			}
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return finishPreview(preview, title, base)
+			}
+
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = string(tokenizer.Text())
+			}
+		}
+	}
+}
+
+// metaAttributes collects the attributes of a <meta> tag
+func metaAttributes(tokenizer *html.Tokenizer) map[string]string {
+	attrs := make(map[string]string)
+	for {
+		key, value, more := tokenizer.TagAttr()
+		attrs[strings.ToLower(string(key))] = string(value)
+		if !more {
+			return attrs
+		}
+	}
+}
+
+// applyMeta copies supported metadata from a <meta> tag into the preview
+func applyMeta(preview *domain.LinkPreview, attrs map[string]string) {
+	key := strings.ToLower(attrs["property"])
+	if key == "" {
+		key = strings.ToLower(attrs["name"])
+	}
+	content := attrs["content"]
+
+	switch key {
+	case "og:title", "twitter:title":
+		if preview.Title == "" {
+			preview.Title = content
+		}
+	case "og:description", "twitter:description", "description":
+		if preview.Description == "" || key == "og:description" {
+			preview.Description = content
+		}
+	case "og:image", "twitter:image":
+		if preview.ImageURL == "" {
+			preview.ImageURL = content
+		}
+	case "og:site_name":
+		preview.SiteName = content
+	}
+}
+
+// finishPreview normalizes the collected fields
+func finishPreview(preview *domain.LinkPreview, title string, base *url.URL) *domain.LinkPreview {
+	if preview.Title == "" {
+		preview.Title = title
+	}
+	preview.Title = truncate(collapseSpace(preview.Title), maxTitleLength)
+	preview.Description = truncate(collapseSpace(preview.Description), maxDescriptionLength)
+	preview.SiteName = truncate(collapseSpace(preview.SiteName), maxTitleLength)
+
+	// Only keep absolute http(s) image URLs
+	if preview.ImageURL != "" {
+		image, err := base.Parse(strings.TrimSpace(preview.ImageURL))
+		if err != nil || (image.Scheme != "http" && image.Scheme != "https") {
+			preview.ImageURL = ""
+		} else {
+			preview.ImageURL = image.String()
+		}
+	}
+
+	return preview
+}
+
+// isAllowedIP reports whether ip may be connected to
+func isAllowedIP(ip net.IP, allowed []*net.IPNet) bool {
+	for _, network := range allowed {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseCIDRs(values []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, value := range values {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", value, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func mustParseCIDRs(values ...string) []*net.IPNet {
+	networks, err := parseCIDRs(values)
+	if err != nil {
+		panic(err)
+	}
+	return networks
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
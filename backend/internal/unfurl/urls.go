@@ -0,0 +1,40 @@
+package unfurl
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// MaxURLsPerMessage limits how many links are unfurled for a single message
+const MaxURLsPerMessage = 3
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `]+`)
+
+// ExtractURLs returns the distinct http(s) URLs found in message content, in
+// order of appearance and capped at MaxURLsPerMessage
+func ExtractURLs(content string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+
+	for _, match := range urlPattern.FindAllString(content, -1) {
+		// Trailing punctuation usually belongs to the sentence, not the URL
+		match = strings.TrimRight(match, ".,;:!?)]}")
+
+		parsed, err := url.Parse(match)
+		if err != nil || parsed.Host == "" {
+			continue
+		}
+		if seen[match] {
+			continue
+		}
+		seen[match] = true
+
+		urls = append(urls, match)
+		if len(urls) == MaxURLsPerMessage {
+			break
+		}
+	}
+
+	return urls
+}
@@ -1,7 +1,10 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
+	"jobsity-backend/internal/commands"
+	"jobsity-backend/internal/ratelimit"
 	"log"
 	"time"
 
@@ -20,6 +23,9 @@ const (
 
 	// Maximum message size allowed from peer
 	maxMessageSize = 512
+
+	// Time allowed for a slash command to complete
+	commandTimeout = 10 * time.Second
 )
 
 // WebSocket upgrader configuration is handled by Fiber's websocket package
@@ -32,14 +38,27 @@ type Client struct {
 	// Buffered channel of outbound messages
 	send chan []byte
 
+	// Closed by the hub to close the connection after the queued messages
+	quit chan struct{}
+
 	// Hub for managing clients
 	hub *Hub
 
+	// Executes slash commands sent by this client
+	commands CommandExecutor
+
+	// Limits how often the user may run commands
+	limiter *ratelimit.Limiter
+
 	// User email
 	UserEmail string
 
-	// Channel ID this client is connected to
+	// Channel ID this client is connected to. Only the read pump changes
+	// it, holding the hub's write lock.
 	ChannelID string
+
+	// Set by the hub, holding its write lock, once send is closed
+	removed bool
 }
 
 // Message represents a websocket message
@@ -56,7 +75,10 @@ type Message struct {
 func (c *Client) readPump() {
 	defer func() {
 		log.Printf("Client readPump exiting for user: %s", c.UserEmail)
-		c.hub.unregister <- c
+		select {
+		case c.hub.unregister <- c:
+		case <-c.hub.done:
+		}
 		if c.conn != nil {
 			c.conn.Close()
 		}
@@ -85,7 +107,7 @@ func (c *Client) readPump() {
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 	if welcomeBytes, err := json.Marshal(welcomeMessage); err == nil {
-		c.send <- welcomeBytes
+		c.reply(welcomeBytes)
 		log.Printf("Sent welcome message to user %s", c.UserEmail)
 	}
 
@@ -123,6 +145,8 @@ func (c *Client) readPump() {
 			c.handleLeaveChannel(message)
 		case "ping":
 			c.handlePing()
+		case "command":
+			c.handleCommand(message)
 		default:
 			log.Printf("Unknown message type: %s", message.Type)
 		}
@@ -168,6 +192,25 @@ func (c *Client) writePump() {
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
+
+		case <-c.hub.done:
+			// The hub no longer closes send when the client unregisters
+			return
+
+		case <-c.quit:
+			// Flush what is queued, such as the shutdown notice, then close
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			for n := len(c.send); n > 0; n-- {
+				message, ok := <-c.send
+				if !ok {
+					break
+				}
+				if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+					return
+				}
+			}
+			c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"))
+			return
 		}
 	}
 }
@@ -178,25 +221,16 @@ func (c *Client) handleJoinChannel(message Message) {
 		return
 	}
 
-	// Remove from previous channel if any
-	if c.ChannelID != "" {
-		c.hub.mutex.Lock()
-		if channelClients, exists := c.hub.channelClients[c.ChannelID]; exists {
-			delete(channelClients, c)
-			if len(channelClients) == 0 {
-				delete(c.hub.channelClients, c.ChannelID)
-			}
-		}
-		c.hub.mutex.Unlock()
-	}
-
-	// Add to new channel
-	c.ChannelID = message.ChannelID
+	// Move from the previous channel, if any, to the new one
 	c.hub.mutex.Lock()
-	if c.hub.channelClients[c.ChannelID] == nil {
-		c.hub.channelClients[c.ChannelID] = make(map[*Client]bool)
+	c.leaveChannel()
+	c.ChannelID = message.ChannelID
+	if !c.removed {
+		if c.hub.channelClients[c.ChannelID] == nil {
+			c.hub.channelClients[c.ChannelID] = make(map[*Client]bool)
+		}
+		c.hub.channelClients[c.ChannelID][c] = true
 	}
-	c.hub.channelClients[c.ChannelID][c] = true
 	c.hub.mutex.Unlock()
 
 	// Send confirmation
@@ -208,40 +242,63 @@ func (c *Client) handleJoinChannel(message Message) {
 	}
 
 	responseBytes, _ := json.Marshal(response)
-	c.send <- responseBytes
+	c.reply(responseBytes)
 
 	log.Printf("User %s joined channel %s", c.UserEmail, c.ChannelID)
 }
 
 // handleLeaveChannel handles when a client leaves a channel
 func (c *Client) handleLeaveChannel(_ Message) {
-	if c.ChannelID == "" {
+	channelID := c.ChannelID
+	if channelID == "" {
 		return
 	}
 
 	// Remove from current channel
 	c.hub.mutex.Lock()
-	if channelClients, exists := c.hub.channelClients[c.ChannelID]; exists {
-		delete(channelClients, c)
-		if len(channelClients) == 0 {
-			delete(c.hub.channelClients, c.ChannelID)
-		}
-	}
+	c.leaveChannel()
+	c.ChannelID = ""
 	c.hub.mutex.Unlock()
 
 	// Send confirmation
 	response := Message{
 		Type:      "channel_left",
-		ChannelID: c.ChannelID,
+		ChannelID: channelID,
 		UserEmail: c.UserEmail,
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 
 	responseBytes, _ := json.Marshal(response)
-	c.send <- responseBytes
+	c.reply(responseBytes)
 
-	log.Printf("User %s left channel %s", c.UserEmail, c.ChannelID)
-	c.ChannelID = ""
+	log.Printf("User %s left channel %s", c.UserEmail, channelID)
+}
+
+// leaveChannel removes the client from its channel's clients. It must be
+// called with the hub's write lock held.
+func (c *Client) leaveChannel() {
+	if channelClients, exists := c.hub.channelClients[c.ChannelID]; exists {
+		delete(channelClients, c)
+		if len(channelClients) == 0 {
+			delete(c.hub.channelClients, c.ChannelID)
+		}
+	}
+}
+
+// reply queues a message for this client unless the hub has removed it. The
+// message is dropped if the send buffer is full.
+func (c *Client) reply(message []byte) {
+	c.hub.mutex.RLock()
+	defer c.hub.mutex.RUnlock()
+
+	if c.removed {
+		return
+	}
+	select {
+	case c.send <- message:
+	default:
+		log.Printf("Client send buffer is full, dropping reply for user %s", c.UserEmail)
+	}
 }
 
 // handlePing handles ping messages
@@ -252,5 +309,46 @@ func (c *Client) handlePing() {
 	}
 
 	responseBytes, _ := json.Marshal(response)
-	c.send <- responseBytes
+	c.reply(responseBytes)
+}
+
+// handleCommand executes a slash command and replies to this client only
+func (c *Client) handleCommand(message Message) {
+	channelID := message.ChannelID
+	if channelID == "" {
+		channelID = c.ChannelID
+	}
+
+	response := Message{
+		Type:      "command_result",
+		ChannelID: channelID,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	if c.commands == nil {
+		response.Type = "command_error"
+		response.Content = "commands are not available"
+	} else if decision := c.limiter.Allow(context.Background(), ratelimit.ActionCommand, c.UserEmail); !decision.Allowed {
+		response.Type = "rate_limited"
+		response.Content = "You are sending commands too quickly"
+		response.Data = map[string]interface{}{
+			"action":      ratelimit.ActionCommand,
+			"retry_after": decision.RetryAfterSeconds(),
+		}
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		result, err := c.commands.Execute(ctx, channelID, c.UserEmail, message.Content)
+		cancel()
+		if err != nil {
+			log.Printf("Command from user %s failed: %v", c.UserEmail, err)
+			response.Type = "command_error"
+			response.Content = commands.ErrorMessage(err)
+		} else {
+			response.Content = result.Message
+			response.Data = result
+		}
+	}
+
+	responseBytes, _ := json.Marshal(response)
+	c.reply(responseBytes)
 }
@@ -1,7 +1,10 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
+	"jobsity-backend/internal/commands"
+	"jobsity-backend/internal/ratelimit"
 	"log"
 	"time"
 
@@ -9,15 +12,30 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// shutdownPollInterval is how often Shutdown checks whether every client has
+// disconnected
+const shutdownPollInterval = 50 * time.Millisecond
+
+// CommandExecutor runs slash commands sent over a WebSocket connection
+type CommandExecutor interface {
+	Execute(ctx context.Context, channelID, userEmail, content string) (*commands.Result, error)
+}
+
 // Handler handles WebSocket connections
 type Handler struct {
-	hub *Hub
+	hub      *Hub
+	commands CommandExecutor
+	limiter  *ratelimit.Limiter
 }
 
-// NewHandler creates a new WebSocket handler
-func NewHandler(hub *Hub) *Handler {
+// NewHandler creates a new WebSocket handler. commandExecutor may be nil, in
+// which case command frames are rejected. Command frames are rate limited per
+// user by limiter, which may also be nil.
+func NewHandler(hub *Hub, commandExecutor CommandExecutor, limiter *ratelimit.Limiter) *Handler {
 	return &Handler{
-		hub: hub,
+		hub:      hub,
+		commands: commandExecutor,
+		limiter:  limiter,
 	}
 }
 
@@ -40,19 +58,34 @@ func (h *Handler) HandleWebSocket(c *websocket.Conn) {
 	client := &Client{
 		conn:      c,
 		send:      make(chan []byte, 256),
+		quit:      make(chan struct{}),
 		hub:       h.hub,
+		commands:  h.commands,
+		limiter:   h.limiter,
 		UserEmail: userEmail,
 		ChannelID: channelID,
 	}
 
 	// Register client with hub
-	client.hub.register <- client
+	select {
+	case client.hub.register <- client:
+	case <-client.hub.done:
+		c.Close()
+		return
+	}
 
 	// Start writePump in a goroutine
-	go client.writePump()
+	written := make(chan struct{})
+	go func() {
+		client.writePump()
+		close(written)
+	}()
 
 	// Run readPump in the main thread to keep the connection alive
 	client.readPump()
+
+	// The connection is reused once this returns, so writePump must be done
+	<-written
 }
 
 // BroadcastMessage broadcasts a message to all clients in a channel
@@ -73,6 +106,27 @@ func (h *Handler) BroadcastMessage(channelID string, messageType string, data in
 	h.hub.BroadcastToChannel(channelID, messageBytes)
 }
 
+// SendToUser sends a message only to one user's clients in a channel, for
+// replies other members should not see. It returns how many clients it was
+// sent to.
+func (h *Handler) SendToUser(channelID, userEmail, messageType string, data interface{}) int {
+	message := Message{
+		Type:      messageType,
+		ChannelID: channelID,
+		UserEmail: userEmail,
+		Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
+		Data:      data,
+	}
+
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Error marshaling user message: %v", err)
+		return 0
+	}
+
+	return h.hub.SendToUser(channelID, userEmail, messageBytes)
+}
+
 // BroadcastToAll broadcasts a message to all connected clients
 func (h *Handler) BroadcastToAll(messageType string, data interface{}) {
 	message := Message{
@@ -94,6 +148,37 @@ func (h *Handler) BroadcastToAll(messageType string, data interface{}) {
 	}
 }
 
+// Shutdown sends a server_shutdown message to every client, telling it to
+// reconnect after reconnectAfter, and closes the connections. It waits until
+// every client has disconnected or ctx expires.
+func (h *Handler) Shutdown(ctx context.Context, reconnectAfter time.Duration) error {
+	message := Message{
+		Type:      "server_shutdown",
+		Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
+		Data: map[string]interface{}{
+			"reconnect_after_ms": reconnectAfter.Milliseconds(),
+		},
+	}
+
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Closing %d WebSocket clients", h.hub.Shutdown(messageBytes))
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for h.hub.GetClientCount() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
 // GetStats returns WebSocket connection statistics
 func (h *Handler) GetStats() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -104,8 +189,8 @@ func (h *Handler) GetStats() fiber.Handler {
 
 		// Get client count per channel
 		h.hub.mutex.RLock()
-		for channelID := range h.hub.channelClients {
-			stats["channels"].(map[string]int)[channelID] = h.hub.GetChannelClientCount(channelID)
+		for channelID, channelClients := range h.hub.channelClients {
+			stats["channels"].(map[string]int)[channelID] = len(channelClients)
 		}
 		h.hub.mutex.RUnlock()
 
@@ -13,6 +13,9 @@ type Hub struct {
 	// Channel-specific clients
 	channelClients map[string]map[*Client]bool
 
+	// Clients of each user, for messages addressed to a single user
+	userClients map[string]map[*Client]bool
+
 	// Inbound messages from the clients
 	broadcast chan []byte
 
@@ -22,6 +25,13 @@ type Hub struct {
 	// Unregister requests from clients
 	unregister chan *Client
 
+	// Closed by Stop to end Run
+	done chan struct{}
+
+	// Notice sent to every client by Shutdown, and to clients registering
+	// afterwards; nil until then
+	shutdownMessage []byte
+
 	// Mutex for thread-safe operations
 	mutex sync.RWMutex
 }
@@ -31,16 +41,21 @@ func NewHub() *Hub {
 	return &Hub{
 		clients:        make(map[*Client]bool),
 		channelClients: make(map[string]map[*Client]bool),
+		userClients:    make(map[string]map[*Client]bool),
 		broadcast:      make(chan []byte),
 		register:       make(chan *Client),
 		unregister:     make(chan *Client),
+		done:           make(chan struct{}),
 	}
 }
 
-// Run starts the hub
+// Run starts the hub and returns once Stop is called
 func (h *Hub) Run() {
 	for {
 		select {
+		case <-h.done:
+			return
+
 		case client := <-h.register:
 			h.mutex.Lock()
 			h.clients[client] = true
@@ -52,61 +67,137 @@ func (h *Hub) Run() {
 				}
 				h.channelClients[client.ChannelID][client] = true
 			}
+
+			if h.userClients[client.UserEmail] == nil {
+				h.userClients[client.UserEmail] = make(map[*Client]bool)
+			}
+			h.userClients[client.UserEmail][client] = true
+
+			// Clients that connected while the hub was shutting down are
+			// sent away like the rest
+			if h.shutdownMessage != nil {
+				select {
+				case client.send <- h.shutdownMessage:
+				default:
+				}
+				close(client.quit)
+			}
 			h.mutex.Unlock()
 
 			log.Printf("Client connected. Total clients: %d", len(h.clients))
 
 		case client := <-h.unregister:
 			h.mutex.Lock()
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
-
-				// Remove from channel-specific clients
-				if client.ChannelID != "" {
-					if channelClients, exists := h.channelClients[client.ChannelID]; exists {
-						delete(channelClients, client)
-						if len(channelClients) == 0 {
-							delete(h.channelClients, client.ChannelID)
-						}
-					}
-				}
-			}
+			h.remove(client)
 			h.mutex.Unlock()
 
 			log.Printf("Client disconnected. Total clients: %d", len(h.clients))
 
 		case message := <-h.broadcast:
-			h.mutex.RLock()
+			h.mutex.Lock()
 			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(h.clients, client)
-				}
+				h.sendOrRemove(client, message)
 			}
-			h.mutex.RUnlock()
+			h.mutex.Unlock()
+		}
+	}
+}
+
+// remove forgets client and closes its send channel, which ends its write
+// pump. It must be called with the write lock held, like every change to
+// the client sets; a client already removed is left alone.
+func (h *Hub) remove(client *Client) {
+	if !h.clients[client] {
+		return
+	}
+	client.removed = true
+	delete(h.clients, client)
+	close(client.send)
+
+	if channelClients, exists := h.channelClients[client.ChannelID]; exists {
+		delete(channelClients, client)
+		if len(channelClients) == 0 {
+			delete(h.channelClients, client.ChannelID)
+		}
+	}
+
+	if userClients, exists := h.userClients[client.UserEmail]; exists {
+		delete(userClients, client)
+		if len(userClients) == 0 {
+			delete(h.userClients, client.UserEmail)
+		}
+	}
+}
+
+// sendOrRemove queues message for client, removing the client if its send
+// buffer is full. It must be called with the write lock held.
+func (h *Hub) sendOrRemove(client *Client, message []byte) {
+	select {
+	case client.send <- message:
+	default:
+		log.Printf("Client send buffer is full, disconnecting user %s", client.UserEmail)
+		h.remove(client)
+	}
+}
+
+// Stop ends Run. Clients should be disconnected with Shutdown first.
+func (h *Hub) Stop() {
+	close(h.done)
+}
+
+// Shutdown sends message to every client and then closes its connection once
+// the messages queued before it have been written. It returns how many
+// clients were connected; each is unregistered when its connection closes.
+func (h *Hub) Shutdown(message []byte) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.shutdownMessage != nil {
+		return 0
+	}
+	h.shutdownMessage = message
+
+	for client := range h.clients {
+		select {
+		case client.send <- message:
+		default:
+			log.Printf("Client send buffer is full, closing without shutdown notice for user %s", client.UserEmail)
 		}
+		close(client.quit)
 	}
+	return len(h.clients)
 }
 
 // BroadcastToChannel broadcasts a message to all clients in a specific channel
 func (h *Hub) BroadcastToChannel(channelID string, message []byte) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for client := range h.channelClients[channelID] {
+		h.sendOrRemove(client, message)
+	}
+}
+
+// SendToUser sends a message only to the given user's clients in a channel,
+// returning how many clients it was sent to. Clients too slow to accept it
+// miss the message but stay connected.
+func (h *Hub) SendToUser(channelID, userEmail string, message []byte) int {
 	h.mutex.RLock()
 	defer h.mutex.RUnlock()
 
-	if channelClients, exists := h.channelClients[channelID]; exists {
-		for client := range channelClients {
-			select {
-			case client.send <- message:
-			default:
-				close(client.send)
-				delete(h.clients, client)
-				delete(channelClients, client)
-			}
+	sent := 0
+	for client := range h.userClients[userEmail] {
+		if client.ChannelID != channelID {
+			continue
+		}
+		select {
+		case client.send <- message:
+			sent++
+		default:
+			log.Printf("Client send buffer is full, dropping message for user %s", userEmail)
 		}
 	}
+	return sent
 }
 
 // GetClientCount returns the number of connected clients
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"io"
+	"time"
+)
+
+// Attachment represents a file uploaded to a channel
+type Attachment struct {
+	ID          string    `bson:"_id,omitempty" json:"id"`
+	ChannelID   string    `bson:"channel_id" json:"channel_id"`
+	MessageID   string    `bson:"message_id,omitempty" json:"message_id,omitempty"`
+	UploadedBy  string    `bson:"uploaded_by" json:"uploaded_by"` // User email
+	FileName    string    `bson:"file_name" json:"file_name"`
+	ContentType string    `bson:"content_type" json:"content_type"`
+	Size        int64     `bson:"size" json:"size"`
+	StorageKey  string    `bson:"storage_key" json:"-"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+
+	// Image details, filled in by background processing
+	Width           int        `bson:"width,omitempty" json:"width,omitempty"`
+	Height          int        `bson:"height,omitempty" json:"height,omitempty"`
+	ThumbnailKey    string     `bson:"thumbnail_key,omitempty" json:"-"`
+	ThumbnailType   string     `bson:"thumbnail_type,omitempty" json:"thumbnail_type,omitempty"`
+	ThumbnailWidth  int        `bson:"thumbnail_width,omitempty" json:"thumbnail_width,omitempty"`
+	ThumbnailHeight int        `bson:"thumbnail_height,omitempty" json:"thumbnail_height,omitempty"`
+	ProcessedAt     *time.Time `bson:"processed_at,omitempty" json:"processed_at,omitempty"`
+}
+
+// HasThumbnail reports whether a thumbnail has been generated for the attachment
+func (a *Attachment) HasThumbnail() bool {
+	return a.ThumbnailKey != ""
+}
+
+// UploadAttachmentRequest represents a file upload to a channel
+type UploadAttachmentRequest struct {
+	ChannelID string    `json:"channel_id"`
+	FileName  string    `json:"file_name"`
+	Size      int64     `json:"size"`
+	Content   io.Reader `json:"-"`
+}
+
+// AttachmentResponse represents the attachment response structure
+type AttachmentResponse struct {
+	Success    bool        `json:"success"`
+	Message    string      `json:"message"`
+	Attachment *Attachment `json:"attachment,omitempty"`
+}
+
+// DownloadURL represents a signed, time-limited download URL for an attachment
+type DownloadURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DownloadURLResponse represents the download URL response structure
+type DownloadURLResponse struct {
+	Success  bool         `json:"success"`
+	Message  string       `json:"message"`
+	Download *DownloadURL `json:"download,omitempty"`
+}
@@ -2,6 +2,12 @@ package domain
 
 import "time"
 
+// WebSocket events sent to the clients of a channel when it changes
+const (
+	EventChannelUpdated = "channel_updated"
+	EventChannelDeleted = "channel_deleted"
+)
+
 // Channel represents a chat channel
 type Channel struct {
 	ID          string    `bson:"_id,omitempty" json:"id"`
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// DeadLetter represents a queue message that could not be processed
+type DeadLetter struct {
+	Queue          string    `json:"queue"`
+	MessageID      string    `json:"message_id,omitempty"`
+	CorrelationID  string    `json:"correlation_id,omitempty"`
+	Type           string    `json:"type,omitempty"`
+	ContentType    string    `json:"content_type,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	Retries        int       `json:"retries"`
+	DeadLetteredAt time.Time `json:"dead_lettered_at,omitempty"`
+	Body           string    `json:"body"`
+}
+
+// RequeueDeadLettersRequest selects dead letters to move back to their work queue.
+// When IDs is empty every dead letter is requeued, up to Limit.
+type RequeueDeadLettersRequest struct {
+	IDs   []string `json:"ids,omitempty"`
+	Limit int      `json:"limit,omitempty"`
+}
+
+// DeadLettersResponse represents the dead letter listing response structure
+type DeadLettersResponse struct {
+	Success     bool          `json:"success"`
+	Message     string        `json:"message"`
+	DeadLetters []*DeadLetter `json:"dead_letters,omitempty"`
+}
+
+// RequeueDeadLettersResponse represents the dead letter requeue response structure
+type RequeueDeadLettersResponse struct {
+	Success  bool   `json:"success"`
+	Message  string `json:"message"`
+	Requeued int    `json:"requeued"`
+}
@@ -0,0 +1,78 @@
+package domain
+
+import "errors"
+
+// Errors returned by repositories and services regardless of the database
+// behind them. Check for them with errors.Is.
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict is returned when a record would duplicate an existing one
+	ErrConflict = errors.New("already exists")
+
+	// ErrInvalidID is returned for IDs that are not in the database's format
+	ErrInvalidID = errors.New("invalid id")
+
+	// ErrInvalidInput is returned for requests that fail validation
+	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrForbidden is returned when a user may not change a record
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrTooLarge is returned for content over a size limit
+	ErrTooLarge = errors.New("too large")
+
+	// ErrUnsupported is returned for content of a type that is not accepted
+	ErrUnsupported = errors.New("unsupported")
+)
+
+// kindError is an error with its own message that matches one of the
+// sentinel errors above
+type kindError struct {
+	kind    error
+	message string
+}
+
+func (e *kindError) Error() string {
+	return e.message
+}
+
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+// NotFound returns an ErrNotFound error with message, e.g. "channel not found"
+func NotFound(message string) error {
+	return &kindError{kind: ErrNotFound, message: message}
+}
+
+// Conflict returns an ErrConflict error with message
+func Conflict(message string) error {
+	return &kindError{kind: ErrConflict, message: message}
+}
+
+// InvalidID returns an ErrInvalidID error with message
+func InvalidID(message string) error {
+	return &kindError{kind: ErrInvalidID, message: message}
+}
+
+// Invalid returns an ErrInvalidInput error with message
+func Invalid(message string) error {
+	return &kindError{kind: ErrInvalidInput, message: message}
+}
+
+// Forbidden returns an ErrForbidden error with message
+func Forbidden(message string) error {
+	return &kindError{kind: ErrForbidden, message: message}
+}
+
+// TooLarge returns an ErrTooLarge error with message
+func TooLarge(message string) error {
+	return &kindError{kind: ErrTooLarge, message: message}
+}
+
+// Unsupported returns an ErrUnsupported error with message
+func Unsupported(message string) error {
+	return &kindError{kind: ErrUnsupported, message: message}
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// LinkPreview represents metadata fetched for a URL posted in a message
+type LinkPreview struct {
+	URL         string    `bson:"url" json:"url"`
+	Title       string    `bson:"title,omitempty" json:"title,omitempty"`
+	Description string    `bson:"description,omitempty" json:"description,omitempty"`
+	ImageURL    string    `bson:"image_url,omitempty" json:"image_url,omitempty"`
+	SiteName    string    `bson:"site_name,omitempty" json:"site_name,omitempty"`
+	FetchedAt   time.Time `bson:"fetched_at" json:"fetched_at"`
+}
@@ -2,19 +2,36 @@ package domain
 
 import "time"
 
+// Message author types
+const (
+	AuthorUser   = "user"
+	AuthorBot    = "bot"
+	AuthorSystem = "system"
+)
+
 // Message represents a chat message
 type Message struct {
-	ID        string    `bson:"_id,omitempty" json:"id"`
-	ChannelID string    `bson:"channel_id" json:"channel_id"`
-	UserEmail string    `bson:"user_email" json:"user_email"`
-	Content   string    `bson:"content" json:"content"`
-	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	ID            string         `bson:"_id,omitempty" json:"id"`
+	ChannelID     string         `bson:"channel_id" json:"channel_id"`
+	UserEmail     string         `bson:"user_email" json:"user_email"`   // User email, or the bot name for bot messages
+	AuthorType    string         `bson:"author_type" json:"author_type"` // AuthorUser, AuthorBot or AuthorSystem
+	CorrelationID string         `bson:"correlation_id,omitempty" json:"correlation_id,omitempty"`
+	Content       string         `bson:"content" json:"content"`
+	ContentHTML   string         `bson:"content_html,omitempty" json:"content_html,omitempty"` // Sanitized rendering of Content
+	ContentText   string         `bson:"content_text,omitempty" json:"content_text,omitempty"` // Plain-text rendering of Content
+	Attachments   []*Attachment  `bson:"attachments,omitempty" json:"attachments,omitempty"`
+	Previews      []*LinkPreview `bson:"previews,omitempty" json:"previews,omitempty"`
+	CreatedAt     time.Time      `bson:"created_at" json:"created_at"`
+
+	// Ephemeral messages are shown only to one user and never stored
+	Ephemeral bool `bson:"-" json:"ephemeral,omitempty"`
 }
 
 // CreateMessageRequest represents the create message request structure
 type CreateMessageRequest struct {
-	ChannelID string `json:"channel_id"`
-	Content   string `json:"content"`
+	ChannelID     string   `json:"channel_id"`
+	Content       string   `json:"content"`
+	AttachmentIDs []string `json:"attachment_ids,omitempty"`
 }
 
 // MessageResponse represents the message response structure
@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// WebSocket events sent to the clients of a channel when its messages change
+const (
+	EventNewMessage     = "new_message"
+	EventMessageUpdated = "message_updated"
+	EventMessageDeleted = "message_deleted"
+)
+
+// MessageEventData returns the payload of a new_message or message_updated
+// event. Bot messages carry their correlation ID instead of attachments.
+func MessageEventData(message *Message) map[string]interface{} {
+	data := map[string]interface{}{
+		"id":           message.ID,
+		"channel_id":   message.ChannelID,
+		"user_email":   message.UserEmail,
+		"author_type":  message.AuthorType,
+		"content":      message.Content,
+		"content_html": message.ContentHTML,
+		"content_text": message.ContentText,
+		"created_at":   message.CreatedAt.Format(time.RFC3339),
+	}
+	if message.AuthorType == AuthorUser {
+		data["attachments"] = message.Attachments
+	} else {
+		data["correlation_id"] = message.CorrelationID
+	}
+	return data
+}
+
+// MessageDeletedEventData returns the payload of a message_deleted event
+func MessageDeletedEventData(message *Message) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         message.ID,
+		"channel_id": message.ChannelID,
+		"user_email": message.UserEmail,
+	}
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxEvent is a WebSocket event stored in the same transaction as the write
+// that caused it, so it is published even if the server stops right after
+// the write
+type OutboxEvent struct {
+	ID        string          `bson:"_id,omitempty" json:"id"`
+	Type      string          `bson:"type" json:"type"` // e.g. "new_message"
+	ChannelID string          `bson:"channel_id" json:"channel_id"`
+	Data      json.RawMessage `bson:"data" json:"data"` // JSON payload sent to clients
+	CreatedAt time.Time       `bson:"created_at" json:"created_at"`
+
+	// UserEmail, if set, limits delivery to that user's clients in the
+	// channel, as for ephemeral replies
+	UserEmail string `bson:"user_email,omitempty" json:"user_email,omitempty"`
+
+	// ClaimedUntil keeps other relays away while one publishes the event
+	ClaimedUntil time.Time  `bson:"claimed_until" json:"-"`
+	DeliveredAt  *time.Time `bson:"delivered_at,omitempty" json:"-"`
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// Stock alert operators
+const (
+	AlertAbove = ">"
+	AlertBelow = "<"
+)
+
+// StockAlert is a one-shot price alert set by a user in a channel
+type StockAlert struct {
+	ID          string     `bson:"_id,omitempty" json:"id"`
+	ChannelID   string     `bson:"channel_id" json:"channel_id"`
+	UserEmail   string     `bson:"user_email" json:"user_email"`
+	StockCode   string     `bson:"stock_code" json:"stock_code"`
+	Operator    string     `bson:"operator" json:"operator"` // AlertAbove or AlertBelow
+	Threshold   float64    `bson:"threshold" json:"threshold"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	TriggeredAt *time.Time `bson:"triggered_at,omitempty" json:"triggered_at,omitempty"`
+}
+
+// Crossed reports whether price is on the alerting side of the threshold
+func (a *StockAlert) Crossed(price float64) bool {
+	switch a.Operator {
+	case AlertAbove:
+		return price > a.Threshold
+	case AlertBelow:
+		return price < a.Threshold
+	default:
+		return false
+	}
+}
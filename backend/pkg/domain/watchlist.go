@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// Watchlist is the set of stocks a channel follows, with the cron schedule on
+// which the stock bot posts a digest of them
+type Watchlist struct {
+	ID         string     `bson:"_id,omitempty" json:"id"`
+	ChannelID  string     `bson:"channel_id" json:"channel_id"`
+	StockCodes []string   `bson:"stock_codes" json:"stock_codes"`
+	Schedule   string     `bson:"schedule" json:"schedule"` // five-field cron expression, in UTC
+	NextRunAt  time.Time  `bson:"next_run_at" json:"next_run_at"`
+	LastRunAt  *time.Time `bson:"last_run_at,omitempty" json:"last_run_at,omitempty"`
+	UpdatedBy  string     `bson:"updated_by" json:"updated_by"` // User email
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,248 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/service"
+	"jobsity-backend/internal/storage"
+	"jobsity-backend/pkg/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// MockAttachmentRepository is a mock implementation of AttachmentRepository
+type MockAttachmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockAttachmentRepository) Create(ctx context.Context, attachment *domain.Attachment) error {
+	args := m.Called(ctx, attachment)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentRepository) FindByID(ctx context.Context, id string) (*domain.Attachment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) FindUnattachedBefore(ctx context.Context, before time.Time) ([]*domain.Attachment, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) SwapMessage(ctx context.Context, id string, oldMessageID string, newMessageID string) error {
+	args := m.Called(ctx, id, oldMessageID, newMessageID)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentRepository) UpdateProcessed(ctx context.Context, attachment *domain.Attachment) error {
+	args := m.Called(ctx, attachment)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentRepository) DeleteUnattached(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// staticMembership grants membership to a fixed set of users
+type staticMembership map[string]bool
+
+func (m staticMembership) IsMember(ctx context.Context, channelID string, userEmail string) (bool, error) {
+	return m[userEmail], nil
+}
+
+// pngHeader is enough of a PNG file for content type detection
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// AttachmentServiceTestSuite contains the test suite for the attachment service
+type AttachmentServiceTestSuite struct {
+	suite.Suite
+	mockRepo          *MockAttachmentRepository
+	blobStore         *storage.LocalBlobStore
+	attachmentService service.AttachmentService
+}
+
+func (suite *AttachmentServiceTestSuite) SetupTest() {
+	var err error
+	suite.blobStore, err = storage.NewLocalBlobStore(suite.T().TempDir())
+	suite.Require().NoError(err)
+
+	suite.mockRepo = new(MockAttachmentRepository)
+	suite.attachmentService = service.NewAttachmentService(suite.mockRepo, suite.blobStore, staticMembership{"member@example.com": true}, nil, service.AttachmentOptions{
+		MaxSize:      64,
+		AllowedTypes: []string{"image/png"},
+		URLSecret:    []byte("secret"),
+		URLTTL:       time.Minute,
+	})
+}
+
+// TestUploadSuccess tests a valid upload is stored with its detected content type
+func (suite *AttachmentServiceTestSuite) TestUploadSuccess() {
+	suite.mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Attachment")).Return(nil)
+
+	attachment, err := suite.attachmentService.UploadAttachment(context.Background(), &domain.UploadAttachmentRequest{
+		ChannelID: "channel-1",
+		FileName:  "../../photo.png",
+		Size:      int64(len(pngHeader)),
+		Content:   bytes.NewReader(pngHeader),
+	}, "member@example.com")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "image/png", attachment.ContentType)
+	assert.Equal(suite.T(), "photo.png", attachment.FileName)
+	assert.Equal(suite.T(), int64(len(pngHeader)), attachment.Size)
+	assert.True(suite.T(), strings.HasPrefix(attachment.StorageKey, "channel-1/"))
+
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+// TestUploadRejectsDisallowedType tests that content types off the allow list are rejected
+func (suite *AttachmentServiceTestSuite) TestUploadRejectsDisallowedType() {
+	_, err := suite.attachmentService.UploadAttachment(context.Background(), &domain.UploadAttachmentRequest{
+		ChannelID: "channel-1",
+		FileName:  "script.png",
+		Size:      20,
+		Content:   strings.NewReader("<html><script></script>"),
+	}, "member@example.com")
+
+	assert.Equal(suite.T(), service.ErrUnsupportedMediaType, err)
+}
+
+// TestUploadRejectsOversizedStream tests that the size limit applies to the actual content
+func (suite *AttachmentServiceTestSuite) TestUploadRejectsOversizedStream() {
+	content := append(append([]byte{}, pngHeader...), make([]byte, 100)...)
+
+	_, err := suite.attachmentService.UploadAttachment(context.Background(), &domain.UploadAttachmentRequest{
+		ChannelID: "channel-1",
+		FileName:  "big.png",
+		Size:      10, // understated by the client
+		Content:   bytes.NewReader(content),
+	}, "member@example.com")
+
+	assert.Equal(suite.T(), service.ErrUploadTooLarge, err)
+	suite.mockRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+}
+
+// TestUploadRequiresMembership tests that non-members cannot upload
+func (suite *AttachmentServiceTestSuite) TestUploadRequiresMembership() {
+	_, err := suite.attachmentService.UploadAttachment(context.Background(), &domain.UploadAttachmentRequest{
+		ChannelID: "channel-1",
+		FileName:  "photo.png",
+		Size:      int64(len(pngHeader)),
+		Content:   bytes.NewReader(pngHeader),
+	}, "stranger@example.com")
+
+	assert.Equal(suite.T(), service.ErrNotChannelMember, err)
+}
+
+// TestSignedDownload tests that a signed URL opens the attachment only for the user it was issued to
+func (suite *AttachmentServiceTestSuite) TestSignedDownload() {
+	ctx := context.Background()
+	attachment := &domain.Attachment{
+		ID:          "att-1",
+		ChannelID:   "channel-1",
+		ContentType: "image/png",
+		StorageKey:  "channel-1/att-1.png",
+	}
+	suite.Require().NoError(suite.blobStore.Put(ctx, attachment.StorageKey, bytes.NewReader(pngHeader), int64(len(pngHeader)), "image/png"))
+	suite.mockRepo.On("FindByID", mock.Anything, "att-1").Return(attachment, nil)
+
+	download, err := suite.attachmentService.GetDownloadURL(ctx, "att-1", service.AttachmentVariantOriginal, "member@example.com")
+	suite.Require().NoError(err)
+
+	parsed, err := url.Parse(download.URL)
+	suite.Require().NoError(err)
+	query := parsed.Query()
+	expires, _ := strconv.ParseInt(query.Get("expires"), 10, 64)
+
+	_, opened, err := suite.attachmentService.OpenAttachment(ctx, "att-1", service.AttachmentVariantOriginal, query.Get("user"), expires, query.Get("signature"))
+	suite.Require().NoError(err)
+	body, _ := io.ReadAll(opened.Content)
+	opened.Content.Close()
+	assert.Equal(suite.T(), pngHeader, body)
+
+	// The same signature is not valid for another user
+	_, _, err = suite.attachmentService.OpenAttachment(ctx, "att-1", service.AttachmentVariantOriginal, "stranger@example.com", expires, query.Get("signature"))
+	assert.Equal(suite.T(), service.ErrInvalidDownloadURL, err)
+
+	// Nor for another variant of the same attachment
+	_, _, err = suite.attachmentService.OpenAttachment(ctx, "att-1", service.AttachmentVariantThumbnail, query.Get("user"), expires, query.Get("signature"))
+	assert.Equal(suite.T(), service.ErrInvalidDownloadURL, err)
+
+	// Expired URLs are rejected
+	_, _, err = suite.attachmentService.OpenAttachment(ctx, "att-1", service.AttachmentVariantOriginal, query.Get("user"), time.Now().Add(-time.Minute).Unix(), query.Get("signature"))
+	assert.Equal(suite.T(), service.ErrInvalidDownloadURL, err)
+}
+
+// TestThumbnailURLRequiresThumbnail tests that thumbnail URLs are only issued once processing is done
+func (suite *AttachmentServiceTestSuite) TestThumbnailURLRequiresThumbnail() {
+	attachment := &domain.Attachment{ID: "att-3", ChannelID: "channel-1", StorageKey: "channel-1/att-3.png"}
+	suite.mockRepo.On("FindByID", mock.Anything, "att-3").Return(attachment, nil)
+
+	_, err := suite.attachmentService.GetDownloadURL(context.Background(), "att-3", service.AttachmentVariantThumbnail, "member@example.com")
+
+	assert.Equal(suite.T(), service.ErrThumbnailNotReady, err)
+}
+
+// TestCleanupOrphans tests that unattached uploads are removed from storage and the repository
+func (suite *AttachmentServiceTestSuite) TestCleanupOrphans() {
+	ctx := context.Background()
+	orphan := &domain.Attachment{ID: "att-2", StorageKey: "channel-1/att-2.png"}
+	suite.Require().NoError(suite.blobStore.Put(ctx, orphan.StorageKey, bytes.NewReader(pngHeader), int64(len(pngHeader)), "image/png"))
+
+	suite.mockRepo.On("FindUnattachedBefore", mock.Anything, mock.AnythingOfType("time.Time")).Return([]*domain.Attachment{orphan}, nil)
+	suite.mockRepo.On("DeleteUnattached", mock.Anything, "att-2").Return(nil)
+
+	removed, err := suite.attachmentService.CleanupOrphans(ctx, time.Hour)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, removed)
+	_, err = suite.blobStore.Get(ctx, orphan.StorageKey)
+	assert.Equal(suite.T(), storage.ErrBlobNotFound, err)
+
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+// TestCleanupOrphansKeepsAttachedUploads tests that an upload attached to a
+// message after it was found as an orphan keeps its blob
+func (suite *AttachmentServiceTestSuite) TestCleanupOrphansKeepsAttachedUploads() {
+	ctx := context.Background()
+	orphan := &domain.Attachment{ID: "att-2", StorageKey: "channel-1/att-2.png"}
+	suite.Require().NoError(suite.blobStore.Put(ctx, orphan.StorageKey, bytes.NewReader(pngHeader), int64(len(pngHeader)), "image/png"))
+
+	suite.mockRepo.On("FindUnattachedBefore", mock.Anything, mock.AnythingOfType("time.Time")).Return([]*domain.Attachment{orphan}, nil)
+	suite.mockRepo.On("DeleteUnattached", mock.Anything, "att-2").Return(domain.Conflict("attachment is already used by another message"))
+
+	removed, err := suite.attachmentService.CleanupOrphans(ctx, time.Hour)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, removed)
+	_, err = suite.blobStore.Get(ctx, orphan.StorageKey)
+	assert.NoError(suite.T(), err)
+}
+
+// TestAttachmentServiceSuite runs the test suite
+func TestAttachmentServiceSuite(t *testing.T) {
+	suite.Run(t, new(AttachmentServiceTestSuite))
+}
@@ -0,0 +1,146 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"jobsity-backend/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeS3Server is a minimal MinIO-style stand-in that stores objects in memory
+type fakeS3Server struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newFakeS3Server() *fakeS3Server {
+	return &fakeS3Server{
+		objects: make(map[string][]byte),
+		types:   make(map[string]string),
+	}
+}
+
+func (f *fakeS3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=test-access/") || !strings.Contains(auth, "Signature=") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// BlobStoreTestSuite contains the test suite for blob store implementations
+type BlobStoreTestSuite struct {
+	suite.Suite
+}
+
+// assertRoundTrip stores, reads and deletes a blob through the store
+func (suite *BlobStoreTestSuite) assertRoundTrip(store storage.BlobStore) {
+	ctx := context.Background()
+	content := "hello attachments"
+
+	err := store.Put(ctx, "channel-1/file.txt", strings.NewReader(content), int64(len(content)), "text/plain")
+	assert.NoError(suite.T(), err)
+
+	reader, err := store.Get(ctx, "channel-1/file.txt")
+	assert.NoError(suite.T(), err)
+	body, _ := io.ReadAll(reader)
+	reader.Close()
+	assert.Equal(suite.T(), content, string(body))
+
+	err = store.Delete(ctx, "channel-1/file.txt")
+	assert.NoError(suite.T(), err)
+
+	_, err = store.Get(ctx, "channel-1/file.txt")
+	assert.Equal(suite.T(), storage.ErrBlobNotFound, err)
+
+	// Deleting a missing blob is not an error
+	assert.NoError(suite.T(), store.Delete(ctx, "channel-1/file.txt"))
+}
+
+// TestLocalBlobStore tests the local filesystem blob store
+func (suite *BlobStoreTestSuite) TestLocalBlobStore() {
+	store, err := storage.NewLocalBlobStore(suite.T().TempDir())
+	assert.NoError(suite.T(), err)
+
+	suite.assertRoundTrip(store)
+}
+
+// TestLocalBlobStoreRejectsTraversal tests that keys cannot escape the storage root
+func (suite *BlobStoreTestSuite) TestLocalBlobStoreRejectsTraversal() {
+	store, err := storage.NewLocalBlobStore(suite.T().TempDir())
+	assert.NoError(suite.T(), err)
+
+	err = store.Put(context.Background(), "../outside.txt", strings.NewReader("x"), 1, "text/plain")
+	assert.Error(suite.T(), err)
+}
+
+// TestS3BlobStore tests the S3-compatible blob store against a local stand-in
+func (suite *BlobStoreTestSuite) TestS3BlobStore() {
+	fake := newFakeS3Server()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := storage.NewS3BlobStore(storage.S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "uploads",
+		AccessKey: "test-access",
+		SecretKey: "test-secret",
+	})
+	assert.NoError(suite.T(), err)
+
+	suite.assertRoundTrip(store)
+}
+
+// TestS3BlobStoreForbidden tests that authentication failures are reported
+func (suite *BlobStoreTestSuite) TestS3BlobStoreForbidden() {
+	server := httptest.NewServer(newFakeS3Server())
+	defer server.Close()
+
+	store, err := storage.NewS3BlobStore(storage.S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "uploads",
+		AccessKey: "wrong-access",
+		SecretKey: "test-secret",
+	})
+	assert.NoError(suite.T(), err)
+
+	err = store.Put(context.Background(), "key", strings.NewReader("x"), 1, "text/plain")
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "403")
+}
+
+// TestBlobStoreSuite runs the test suite
+func TestBlobStoreSuite(t *testing.T) {
+	suite.Run(t, new(BlobStoreTestSuite))
+}
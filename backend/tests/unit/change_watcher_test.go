@@ -0,0 +1,144 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/database"
+	"jobsity-backend/pkg/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// ChangeWatcherTestSuite contains the test suite for the change stream watcher
+type ChangeWatcherTestSuite struct {
+	suite.Suite
+	mt *mtest.T
+}
+
+func (suite *ChangeWatcherTestSuite) SetupTest() {
+	suite.mt = mtest.New(suite.T(), mtest.NewOptions().ClientType(mtest.Mock))
+}
+
+// changeDocument returns a change stream event with a resume token
+func changeDocument(token, operation string, fields ...bson.E) bson.D {
+	return append(bson.D{
+		{Key: "_id", Value: bson.D{{Key: "_data", Value: token}}},
+		{Key: "operationType", Value: operation},
+	}, fields...)
+}
+
+// watchEvents starts a watcher and returns the first count events it broadcasts
+func (suite *ChangeWatcherTestSuite) watchEvents(mt *mtest.T, count int) []broadcastEvent {
+	events := make(chan broadcastEvent, count)
+	watcher := database.NewChangeWatcher(mt.DB, "server-1", func(channelID string, messageType string, data interface{}) {
+		events <- broadcastEvent{channelID: channelID, eventType: messageType, data: toMap(data)}
+	})
+	suite.Require().NoError(watcher.Start())
+	defer watcher.Close()
+
+	var received []broadcastEvent
+	for len(received) < count {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		case <-time.After(time.Second):
+			suite.FailNow("no event broadcast")
+		}
+	}
+	return received
+}
+
+// TestChangesBecomeEvents tests that message and channel changes are
+// broadcast as the events the services send
+func (suite *ChangeWatcherTestSuite) TestChangesBecomeEvents() {
+	suite.mt.Run("changes", func(mt *mtest.T) {
+		messageID := primitive.NewObjectID()
+		channelID := primitive.NewObjectID()
+		message := bson.D{
+			{Key: "_id", Value: messageID},
+			{Key: "channel_id", Value: channelID.Hex()},
+			{Key: "user_email", Value: "user1@jobsity.com"},
+			{Key: "content", Value: "hello"},
+			{Key: "created_at", Value: time.Now()},
+		}
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "jobsity.change_stream_tokens", mtest.FirstBatch))
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "jobsity.messages", mtest.FirstBatch,
+			changeDocument("1", "insert", bson.E{Key: "fullDocument", Value: message}),
+			changeDocument("2", "delete", bson.E{Key: "fullDocumentBeforeChange", Value: message}),
+		))
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "jobsity.change_stream_tokens", mtest.FirstBatch))
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "jobsity.channels", mtest.FirstBatch,
+			changeDocument("3", "delete", bson.E{Key: "documentKey", Value: bson.D{{Key: "_id", Value: channelID}}}),
+		))
+		for i := 0; i < 3; i++ {
+			mt.AddMockResponses(mtest.CreateSuccessResponse())
+		}
+
+		events := suite.watchEvents(mt, 3)
+
+		byType := make(map[string]broadcastEvent)
+		for _, event := range events {
+			byType[event.eventType] = event
+		}
+		created := byType[domain.EventNewMessage]
+		assert.Equal(suite.T(), channelID.Hex(), created.channelID)
+		assert.Equal(suite.T(), messageID.Hex(), created.data["id"])
+		assert.Equal(suite.T(), domain.AuthorUser, created.data["author_type"])
+		assert.Equal(suite.T(), "hello", created.data["content"])
+
+		deleted := byType[domain.EventMessageDeleted]
+		assert.Equal(suite.T(), channelID.Hex(), deleted.channelID)
+		assert.Equal(suite.T(), messageID.Hex(), deleted.data["id"])
+
+		channelDeleted := byType[domain.EventChannelDeleted]
+		assert.Equal(suite.T(), channelID.Hex(), channelDeleted.channelID)
+	})
+}
+
+// TestResumesFromSavedToken tests that streams start after the saved token
+func (suite *ChangeWatcherTestSuite) TestResumesFromSavedToken() {
+	suite.mt.Run("saved token", func(mt *mtest.T) {
+		token := bson.D{{Key: "_data", Value: "41"}}
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "jobsity.change_stream_tokens", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "server-1/messages"}, {Key: "token", Value: token}}))
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "jobsity.messages", mtest.FirstBatch))
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "jobsity.change_stream_tokens", mtest.FirstBatch))
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "jobsity.channels", mtest.FirstBatch))
+
+		watcher := database.NewChangeWatcher(mt.DB, "server-1", func(string, string, interface{}) {})
+		suite.Require().NoError(watcher.Start())
+		watcher.Close()
+
+		var aggregates []bson.Raw
+		for _, event := range mt.GetAllStartedEvents() {
+			if event.CommandName == "aggregate" {
+				aggregates = append(aggregates, event.Command)
+			}
+		}
+		suite.Require().Len(aggregates, 2)
+
+		stage := func(command bson.Raw) bson.Raw {
+			pipeline, _ := command.Lookup("pipeline").ArrayOK()
+			first, _ := pipeline.Index(0).Value().DocumentOK()
+			return first.Lookup("$changeStream").Document()
+		}
+		startAfter, ok := stage(aggregates[0]).Lookup("startAfter").DocumentOK()
+		suite.Require().True(ok)
+		assert.Equal(suite.T(), "41", startAfter.Lookup("_data").StringValue())
+		_, ok = stage(aggregates[1]).Lookup("startAfter").DocumentOK()
+		assert.False(suite.T(), ok)
+	})
+}
+
+func TestChangeWatcherSuite(t *testing.T) {
+	suite.Run(t, new(ChangeWatcherTestSuite))
+}
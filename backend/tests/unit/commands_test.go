@@ -0,0 +1,159 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"jobsity-backend/internal/commands"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// CommandsTestSuite contains the test suite for the slash-command registry
+type CommandsTestSuite struct {
+	suite.Suite
+	registry    *commands.Registry
+	invocations []*commands.Invocation
+}
+
+// SetupTest sets up a registry with an echo command before each test
+func (suite *CommandsTestSuite) SetupTest() {
+	suite.registry = commands.NewRegistry()
+	suite.invocations = nil
+	suite.registry.MustRegister(&commands.Command{
+		Name:        "echo",
+		Args:        []commands.Arg{{Name: "first", Required: true}, {Name: "rest", Variadic: true}},
+		Description: "Echo the arguments",
+		Handler: func(ctx context.Context, inv *commands.Invocation) (*commands.Result, error) {
+			suite.invocations = append(suite.invocations, inv)
+			return &commands.Result{Message: inv.Arg(0)}, nil
+		},
+	})
+}
+
+// TestParse tests splitting content into a command name and arguments
+func (suite *CommandsTestSuite) TestParse() {
+	tests := []struct {
+		name    string
+		content string
+		command string
+		args    []string
+		ok      bool
+	}{
+		{name: "legacy equals form", content: "/stock=aapl.us", command: "stock", args: []string{"aapl.us"}, ok: true},
+		{name: "space form", content: "/stock aapl.us", command: "stock", args: []string{"aapl.us"}, ok: true},
+		{name: "no arguments", content: "/help", command: "help", args: []string{}, ok: true},
+		{name: "upper case name", content: " /HELP stock ", command: "help", args: []string{"stock"}, ok: true},
+		{name: "plain message", content: "hello /stock=aapl.us", ok: false},
+		{name: "path", content: "/usr/bin is missing", ok: false},
+		{name: "lone slash", content: "/", ok: false},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			command, args, ok := commands.Parse(tt.content)
+			assert.Equal(suite.T(), tt.ok, ok)
+			if tt.ok {
+				assert.Equal(suite.T(), tt.command, command)
+				assert.Equal(suite.T(), tt.args, args)
+			}
+		})
+	}
+}
+
+// TestExecute tests running a registered command
+func (suite *CommandsTestSuite) TestExecute() {
+	result, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/echo hello big world")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "hello", result.Message)
+	assert.False(suite.T(), result.Async)
+	assert.Len(suite.T(), suite.invocations, 1)
+	assert.Equal(suite.T(), "channel-1", suite.invocations[0].ChannelID)
+	assert.Equal(suite.T(), "user@example.com", suite.invocations[0].UserEmail)
+	assert.Equal(suite.T(), []string{"hello", "big", "world"}, suite.invocations[0].Args)
+}
+
+// TestExecuteUnknownCommand tests that unknown commands are rejected
+func (suite *CommandsTestSuite) TestExecuteUnknownCommand() {
+	result, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/nope")
+
+	assert.Nil(suite.T(), result)
+	assert.ErrorIs(suite.T(), err, commands.ErrUnknownCommand)
+	assert.Contains(suite.T(), err.Error(), "/help")
+}
+
+// TestExecuteMissingArgument tests that the argument grammar is enforced
+func (suite *CommandsTestSuite) TestExecuteMissingArgument() {
+	_, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/echo=")
+
+	var usageErr *commands.UsageError
+	assert.ErrorAs(suite.T(), err, &usageErr)
+	assert.Equal(suite.T(), "first is required (usage: /echo <first> [rest...])", err.Error())
+	assert.Empty(suite.T(), suite.invocations)
+}
+
+// TestExecuteTooManyArguments tests that extra arguments are rejected
+func (suite *CommandsTestSuite) TestExecuteTooManyArguments() {
+	_, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/help echo stock")
+
+	var usageErr *commands.UsageError
+	assert.ErrorAs(suite.T(), err, &usageErr)
+}
+
+// TestExecuteHandlerFailure tests that handler errors are not shown verbatim
+func (suite *CommandsTestSuite) TestExecuteHandlerFailure() {
+	suite.registry.MustRegister(&commands.Command{
+		Name:  "broken",
+		Async: true,
+		Handler: func(ctx context.Context, inv *commands.Invocation) (*commands.Result, error) {
+			return nil, errors.New("connection refused")
+		},
+	})
+
+	_, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/broken")
+
+	var execErr *commands.ExecutionError
+	assert.ErrorAs(suite.T(), err, &execErr)
+	assert.Equal(suite.T(), "Failed to process broken command", commands.ErrorMessage(err))
+}
+
+// TestHelp tests that /help lists every registered command
+func (suite *CommandsTestSuite) TestHelp() {
+	result, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/help")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Available commands:\n"+
+		"/echo <first> [rest...] - Echo the arguments\n"+
+		"/help [command] - List available commands or show help for one command", result.Message)
+
+	result, err = suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/help /echo")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "/echo <first> [rest...] - Echo the arguments", result.Message)
+
+	_, err = suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/help nope")
+	assert.ErrorIs(suite.T(), err, commands.ErrUnknownCommand)
+}
+
+// TestRegisterInvalid tests that malformed or duplicate commands are rejected
+func (suite *CommandsTestSuite) TestRegisterInvalid() {
+	noop := func(ctx context.Context, inv *commands.Invocation) (*commands.Result, error) {
+		return nil, nil
+	}
+
+	assert.Error(suite.T(), suite.registry.Register(&commands.Command{Name: "echo", Handler: noop}))
+	assert.Error(suite.T(), suite.registry.Register(&commands.Command{Name: "Bad Name", Handler: noop}))
+	assert.Error(suite.T(), suite.registry.Register(&commands.Command{Name: "nohandler"}))
+	assert.Error(suite.T(), suite.registry.Register(&commands.Command{
+		Name:    "order",
+		Args:    []commands.Arg{{Name: "a"}, {Name: "b", Required: true}},
+		Handler: noop,
+	}))
+}
+
+// TestCommandsSuite runs the commands test suite
+func TestCommandsSuite(t *testing.T) {
+	suite.Run(t, new(CommandsTestSuite))
+}
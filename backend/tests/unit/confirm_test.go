@@ -0,0 +1,83 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/broker"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// ConfirmTestSuite contains the test suite for publisher confirmations
+type ConfirmTestSuite struct {
+	suite.Suite
+	confirms chan amqp.Confirmation
+	returns  chan amqp.Return
+}
+
+// SetupTest creates the notification channels of a confirm mode channel
+// before each test
+func (suite *ConfirmTestSuite) SetupTest() {
+	suite.confirms = make(chan amqp.Confirmation, 1)
+	suite.returns = make(chan amqp.Return, 1)
+}
+
+// await waits for the confirmation of the current publish
+func (suite *ConfirmTestSuite) await() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return broker.AwaitConfirmation(ctx, suite.confirms, suite.returns)
+}
+
+// TestAck tests that an acked publish succeeds
+func (suite *ConfirmTestSuite) TestAck() {
+	suite.confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+	assert.NoError(suite.T(), suite.await())
+}
+
+// TestNack tests that a nacked publish is not confirmed
+func (suite *ConfirmTestSuite) TestNack() {
+	suite.confirms <- amqp.Confirmation{DeliveryTag: 1}
+	assert.ErrorIs(suite.T(), suite.await(), broker.ErrNotConfirmed)
+}
+
+// TestReturned tests that a returned publish is unroutable
+func (suite *ConfirmTestSuite) TestReturned() {
+	suite.returns <- amqp.Return{ReplyText: "NO_ROUTE"}
+	suite.confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+
+	err := suite.await()
+	assert.ErrorIs(suite.T(), err, broker.ErrUnroutable)
+	assert.Contains(suite.T(), err.Error(), "NO_ROUTE")
+}
+
+// TestChannelClosed tests that a channel closing mid-publish is reported as
+// a disconnect, never as an unroutable message, whichever notification
+// channel is seen closed first
+func (suite *ConfirmTestSuite) TestChannelClosed() {
+	for i := 0; i < 100; i++ {
+		confirms := make(chan amqp.Confirmation)
+		returns := make(chan amqp.Return)
+		close(confirms)
+		close(returns)
+
+		err := broker.AwaitConfirmation(context.Background(), confirms, returns)
+		suite.Require().ErrorIs(err, broker.ErrDisconnected)
+		suite.Require().NotErrorIs(err, broker.ErrUnroutable)
+	}
+}
+
+// TestTimeout tests that waiting gives up when ctx expires
+func (suite *ConfirmTestSuite) TestTimeout() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(suite.T(), broker.AwaitConfirmation(ctx, suite.confirms, suite.returns), context.DeadlineExceeded)
+}
+
+func TestConfirmSuite(t *testing.T) {
+	suite.Run(t, new(ConfirmTestSuite))
+}
@@ -0,0 +1,66 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/cron"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// CronTestSuite contains the test suite for cron schedules
+type CronTestSuite struct {
+	suite.Suite
+}
+
+// next parses expr and returns its next run after the given UTC time
+func (suite *CronTestSuite) next(expr string, after string) string {
+	schedule, err := cron.Parse(expr)
+	if !assert.NoError(suite.T(), err) {
+		return ""
+	}
+	t, _ := time.Parse("2006-01-02 15:04", after)
+	return schedule.Next(t).Format("2006-01-02 15:04 Mon")
+}
+
+// TestNext tests computing the next run of common schedules
+func (suite *CronTestSuite) TestNext() {
+	// 2024-01-05 is a Friday
+	assert.Equal(suite.T(), "2024-01-05 21:00 Fri", suite.next("0 21 * * 1-5", "2024-01-05 20:59"))
+	assert.Equal(suite.T(), "2024-01-08 21:00 Mon", suite.next("0 21 * * 1-5", "2024-01-05 21:00"))
+	assert.Equal(suite.T(), "2024-01-05 10:45 Fri", suite.next("*/15 * * * *", "2024-01-05 10:31"))
+	assert.Equal(suite.T(), "2024-01-05 11:05 Fri", suite.next("5/30 9-17/2 * * *", "2024-01-05 09:35"))
+	assert.Equal(suite.T(), "2024-02-29 00:00 Thu", suite.next("0 0 29 2 *", "2023-03-01 00:00"))
+	assert.Equal(suite.T(), "2024-01-07 00:00 Sun", suite.next("@weekly", "2024-01-05 12:00"))
+	assert.Equal(suite.T(), "2024-01-07 09:00 Sun", suite.next("0 9 * * 7", "2024-01-05 12:00"))
+	assert.Equal(suite.T(), "2024-01-06 00:00 Sat", suite.next("@daily", "2024-01-05 12:00"))
+}
+
+// TestNextDayFieldsCombine tests that restricted day-of-month and day-of-week fields are ORed
+func (suite *CronTestSuite) TestNextDayFieldsCombine() {
+	// The 15th or any Monday, whichever comes first
+	assert.Equal(suite.T(), "2024-01-08 00:00 Mon", suite.next("0 0 15 * 1", "2024-01-05 12:00"))
+	assert.Equal(suite.T(), "2024-01-15 00:00 Mon", suite.next("0 0 15 * 1", "2024-01-08 00:00"))
+}
+
+// TestNextNever tests that impossible schedules report the zero time
+func (suite *CronTestSuite) TestNextNever() {
+	schedule, err := cron.Parse("0 0 30 2 *")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), schedule.Next(time.Now()).IsZero())
+}
+
+// TestParseInvalid tests that malformed expressions are rejected
+func (suite *CronTestSuite) TestParseInvalid() {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@yearly"} {
+		_, err := cron.Parse(expr)
+		assert.Error(suite.T(), err, expr)
+	}
+}
+
+// TestCronSuite runs the cron test suite
+func TestCronSuite(t *testing.T) {
+	suite.Run(t, new(CronTestSuite))
+}
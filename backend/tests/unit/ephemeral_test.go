@@ -0,0 +1,116 @@
+package unit
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"jobsity-backend/internal/commands"
+	"jobsity-backend/internal/handlers"
+	"jobsity-backend/internal/service"
+	"jobsity-backend/pkg/domain"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// sentFrame records a frame passed to an EphemeralSender
+type sentFrame struct {
+	channelID   string
+	userEmail   string
+	messageType string
+	message     *domain.Message
+}
+
+// EphemeralTestSuite contains the test suite for ephemeral command replies
+type EphemeralTestSuite struct {
+	suite.Suite
+	sent      []sentFrame
+	publisher *service.InMemoryCommandPublisher
+	app       *fiber.App
+}
+
+// SetupTest sets up a message handler whose ephemeral replies are recorded
+func (suite *EphemeralTestSuite) SetupTest() {
+	suite.sent = nil
+	suite.publisher = service.NewInMemoryCommandPublisher("stock_commands")
+
+	registry := commands.NewRegistry()
+	registry.MustRegister(service.NewStockCommand(suite.publisher))
+
+	send := func(channelID, userEmail, messageType string, data interface{}) int {
+		suite.sent = append(suite.sent, sentFrame{channelID, userEmail, messageType, data.(*domain.Message)})
+		return 1
+	}
+	messageHandler := handlers.NewMessageHandler(nil, registry, send, nil)
+
+	suite.app = fiber.New()
+	suite.app.Post("/messages", func(c *fiber.Ctx) error {
+		c.Locals("userEmail", "user@example.com")
+		return c.Next()
+	}, messageHandler.CreateMessage)
+}
+
+// post sends a message to the handler and returns the response status
+func (suite *EphemeralTestSuite) post(content string) int {
+	body := `{"channel_id":"channel-1","content":"` + content + `"}`
+	req := httptest.NewRequest("POST", "/messages", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := suite.app.Test(req)
+	suite.Require().NoError(err)
+	return resp.StatusCode
+}
+
+// TestHelpIsEphemeral tests that /help output is sent only to the requester
+func (suite *EphemeralTestSuite) TestHelpIsEphemeral() {
+	assert.Equal(suite.T(), fiber.StatusOK, suite.post("/help"))
+
+	suite.Require().Len(suite.sent, 1)
+	frame := suite.sent[0]
+	assert.Equal(suite.T(), "channel-1", frame.channelID)
+	assert.Equal(suite.T(), "user@example.com", frame.userEmail)
+	assert.Equal(suite.T(), "new_message", frame.messageType)
+	assert.True(suite.T(), frame.message.Ephemeral)
+	assert.Equal(suite.T(), domain.AuthorSystem, frame.message.AuthorType)
+	assert.True(suite.T(), strings.HasPrefix(frame.message.ID, "ephemeral-"))
+	assert.Contains(suite.T(), frame.message.Content, "Available commands:")
+}
+
+// TestCommandErrorIsEphemeral tests that command errors are sent only to the requester
+func (suite *EphemeralTestSuite) TestCommandErrorIsEphemeral() {
+	assert.Equal(suite.T(), fiber.StatusBadRequest, suite.post("/nope"))
+
+	suite.Require().Len(suite.sent, 1)
+	assert.Equal(suite.T(), "user@example.com", suite.sent[0].userEmail)
+	assert.Contains(suite.T(), suite.sent[0].message.Content, "unknown command")
+}
+
+// TestAsyncAcknowledgementNotSent tests that async commands are not echoed before their answer
+func (suite *EphemeralTestSuite) TestAsyncAcknowledgementNotSent() {
+	assert.Equal(suite.T(), fiber.StatusOK, suite.post("/stock aapl.us"))
+
+	assert.Len(suite.T(), suite.publisher.Published("stock_commands"), 1)
+	assert.Empty(suite.T(), suite.sent)
+}
+
+// TestEphemeralResponseRoundTrip tests that the ephemeral flag survives the queue
+func (suite *EphemeralTestSuite) TestEphemeralResponseRoundTrip() {
+	command, err := service.NewStockCommandMessage("channel-1", "user@example.com", "nope.us")
+	suite.Require().NoError(err)
+
+	response := service.NewStockResponseMessage(command, "Error fetching stock data for nope.us")
+	response.Ephemeral = true
+	publishing, err := response.Publishing()
+	suite.Require().NoError(err)
+
+	decoded, err := service.DecodeStockResponse(publishing.Body)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), decoded.Ephemeral)
+	assert.Equal(suite.T(), "user@example.com", decoded.Requester)
+}
+
+// TestEphemeralSuite runs the ephemeral reply test suite
+func TestEphemeralSuite(t *testing.T) {
+	suite.Run(t, new(EphemeralTestSuite))
+}
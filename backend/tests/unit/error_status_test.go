@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"jobsity-backend/internal/commands"
+	"jobsity-backend/internal/handlers"
+	"jobsity-backend/internal/middleware"
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/internal/service"
+	"jobsity-backend/pkg/domain"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// ErrorStatusTestSuite contains the test suite for mapping service errors to HTTP status codes
+type ErrorStatusTestSuite struct {
+	suite.Suite
+	ctx      context.Context
+	channels *repository.MemoryChannelRepository
+	messages *repository.MemoryMessageRepository
+	app      *fiber.App
+}
+
+// SetupTest serves the message and channel handlers over in-memory repositories
+func (suite *ErrorStatusTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.channels = repository.NewMemoryChannelRepository()
+	suite.messages = repository.NewMemoryMessageRepository()
+
+	messageService := service.NewMessageService(suite.messages, suite.channels, repository.NewMemoryAttachmentRepository())
+	messageHandler := handlers.NewMessageHandler(messageService, commands.NewRegistry(), nil, nil)
+	channelHandler := handlers.NewChannelHandler(service.NewChannelService(suite.channels))
+
+	suite.app = fiber.New()
+	suite.app.Use(middleware.AuthMiddleware())
+	suite.app.Get("/messages/:id", messageHandler.GetMessage)
+	suite.app.Put("/messages/:id", messageHandler.UpdateMessage)
+	suite.app.Post("/channels", channelHandler.CreateChannel)
+}
+
+// request sends a request as user@example.com and returns the status and message of the response
+func (suite *ErrorStatusTestSuite) request(app *fiber.App, method, path, body string) (int, string) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Email", "user@example.com")
+	resp, err := app.Test(req)
+	suite.Require().NoError(err)
+
+	var decoded struct {
+		Message string `json:"message"`
+	}
+	suite.Require().NoError(json.NewDecoder(resp.Body).Decode(&decoded))
+	return resp.StatusCode, decoded.Message
+}
+
+// TestGetMessage tests that malformed IDs are bad requests while unknown IDs are not found
+func (suite *ErrorStatusTestSuite) TestGetMessage() {
+	status, _ := suite.request(suite.app, "GET", "/messages/not-an-id", "")
+	assert.Equal(suite.T(), fiber.StatusBadRequest, status)
+
+	status, message := suite.request(suite.app, "GET", "/messages/507f1f77bcf86cd799439011", "")
+	assert.Equal(suite.T(), fiber.StatusNotFound, status)
+	assert.Equal(suite.T(), "Message not found", message)
+}
+
+// TestUpdateMessage tests that editing someone else's message is forbidden
+func (suite *ErrorStatusTestSuite) TestUpdateMessage() {
+	message := &domain.Message{ChannelID: "channel-1", UserEmail: "other@example.com", AuthorType: domain.AuthorUser, Content: "hi"}
+	suite.Require().NoError(suite.messages.Create(suite.ctx, message))
+
+	status, _ := suite.request(suite.app, "PUT", "/messages/"+message.ID, `{"content":"changed"}`)
+	assert.Equal(suite.T(), fiber.StatusForbidden, status)
+
+	status, _ = suite.request(suite.app, "PUT", "/messages/507f1f77bcf86cd799439011", `{"content":"changed"}`)
+	assert.Equal(suite.T(), fiber.StatusNotFound, status)
+}
+
+// TestCreateChannel tests validation and duplicate names
+func (suite *ErrorStatusTestSuite) TestCreateChannel() {
+	status, _ := suite.request(suite.app, "POST", "/channels", `{"description":"no name"}`)
+	assert.Equal(suite.T(), fiber.StatusBadRequest, status)
+
+	status, _ = suite.request(suite.app, "POST", "/channels", `{"name":"general"}`)
+	assert.Equal(suite.T(), fiber.StatusCreated, status)
+
+	status, message := suite.request(suite.app, "POST", "/channels", `{"name":"general"}`)
+	assert.Equal(suite.T(), fiber.StatusConflict, status)
+	assert.Equal(suite.T(), "channel with this name already exists", message)
+}
+
+// TestDatabaseFailure tests that unexpected repository errors are internal errors without details
+func (suite *ErrorStatusTestSuite) TestDatabaseFailure() {
+	channelRepo := new(MockChannelRepository)
+	channelRepo.On("FindByID", mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+	channelHandler := handlers.NewChannelHandler(service.NewChannelService(channelRepo))
+
+	app := fiber.New()
+	app.Use(middleware.AuthMiddleware())
+	app.Get("/channels/:id", channelHandler.GetChannel)
+
+	status, message := suite.request(app, "GET", "/channels/507f1f77bcf86cd799439011", "")
+	assert.Equal(suite.T(), fiber.StatusInternalServerError, status)
+	assert.Equal(suite.T(), "Internal server error", message)
+}
+
+// TestAttachmentErrorKinds tests that attachment errors carry the domain kinds the handlers map to status codes
+func (suite *ErrorStatusTestSuite) TestAttachmentErrorKinds() {
+	assert.ErrorIs(suite.T(), service.ErrUploadTooLarge, domain.ErrTooLarge)
+	assert.ErrorIs(suite.T(), service.ErrUnsupportedMediaType, domain.ErrUnsupported)
+	assert.ErrorIs(suite.T(), service.ErrNotChannelMember, domain.ErrForbidden)
+	assert.ErrorIs(suite.T(), service.ErrInvalidDownloadURL, domain.ErrForbidden)
+	assert.ErrorIs(suite.T(), service.ErrThumbnailNotReady, domain.ErrNotFound)
+}
+
+func TestErrorStatusSuite(t *testing.T) {
+	suite.Run(t, new(ErrorStatusTestSuite))
+}
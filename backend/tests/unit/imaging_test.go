@@ -0,0 +1,148 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"jobsity-backend/internal/imaging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// ImagingTestSuite contains the test suite for image processing
+type ImagingTestSuite struct {
+	suite.Suite
+}
+
+// testImage returns a wide image with a red left half and a blue right half
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	return img
+}
+
+// exifSegment builds an APP1 EXIF segment with an orientation tag and a fake GPS marker
+func exifSegment(orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("II")
+	binary.Write(&tiff, binary.LittleEndian, uint16(42))
+	binary.Write(&tiff, binary.LittleEndian, uint32(8))
+	binary.Write(&tiff, binary.LittleEndian, uint16(1))
+	binary.Write(&tiff, binary.LittleEndian, uint16(0x0112))
+	binary.Write(&tiff, binary.LittleEndian, uint16(3))
+	binary.Write(&tiff, binary.LittleEndian, uint32(1))
+	binary.Write(&tiff, binary.LittleEndian, uint32(orientation))
+	binary.Write(&tiff, binary.LittleEndian, uint32(0))
+	tiff.WriteString("GPS-SECRET-LOCATION")
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// jpegWithEXIF encodes img as JPEG and inserts an EXIF segment after SOI
+func jpegWithEXIF(img image.Image, orientation uint16) []byte {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, img, nil)
+	data := buf.Bytes()
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, exifSegment(orientation)...)
+	return append(out, data[2:]...)
+}
+
+// TestStripJPEGMetadata tests that EXIF data is removed but orientation is kept
+func (suite *ImagingTestSuite) TestStripJPEGMetadata() {
+	data := jpegWithEXIF(testImage(40, 20), 6)
+	assert.True(suite.T(), bytes.Contains(data, []byte("GPS-SECRET-LOCATION")))
+
+	stripped, err := imaging.StripMetadata("image/jpeg", data)
+
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), bytes.Contains(stripped, []byte("GPS-SECRET-LOCATION")))
+	assert.Equal(suite.T(), 6, imaging.Orientation(stripped))
+
+	decoded, err := jpeg.Decode(bytes.NewReader(stripped))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 40, decoded.Bounds().Dx())
+}
+
+// TestStripPNGMetadata tests that PNG text chunks are removed
+func (suite *ImagingTestSuite) TestStripPNGMetadata() {
+	var buf bytes.Buffer
+	png.Encode(&buf, testImage(8, 8))
+	data := buf.Bytes()
+
+	// Insert a tEXt chunk right after IHDR (8 byte signature + 25 byte IHDR chunk)
+	text := []byte("Comment\x00taken at home")
+	chunk := make([]byte, 8, 12+len(text))
+	binary.BigEndian.PutUint32(chunk, uint32(len(text)))
+	copy(chunk[4:], "tEXt")
+	chunk = append(chunk, text...)
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32.ChecksumIEEE(chunk[4:]))
+	chunk = append(chunk, crc...)
+	withText := append(append(append([]byte{}, data[:33]...), chunk...), data[33:]...)
+
+	stripped, err := imaging.StripMetadata("image/png", withText)
+
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), bytes.Contains(stripped, []byte("taken at home")))
+	assert.Equal(suite.T(), data, stripped)
+}
+
+// TestMakeThumbnail tests that thumbnails fit the requested size and keep the aspect ratio
+func (suite *ImagingTestSuite) TestMakeThumbnail() {
+	var buf bytes.Buffer
+	png.Encode(&buf, testImage(800, 400))
+
+	thumbnail, err := imaging.MakeThumbnail("image/png", buf.Bytes(), 100)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "image/png", thumbnail.ContentType)
+	assert.Equal(suite.T(), 100, thumbnail.Width)
+	assert.Equal(suite.T(), 50, thumbnail.Height)
+	assert.Equal(suite.T(), 800, thumbnail.SourceWidth)
+	assert.Equal(suite.T(), 400, thumbnail.SourceHeight)
+}
+
+// TestMakeThumbnailAppliesOrientation tests that rotated JPEGs produce upright thumbnails
+func (suite *ImagingTestSuite) TestMakeThumbnailAppliesOrientation() {
+	data := jpegWithEXIF(testImage(200, 100), 6)
+
+	thumbnail, err := imaging.MakeThumbnail("image/jpeg", data, 50)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 25, thumbnail.Width)
+	assert.Equal(suite.T(), 50, thumbnail.Height)
+	assert.Equal(suite.T(), 100, thumbnail.SourceWidth)
+	assert.Equal(suite.T(), 200, thumbnail.SourceHeight)
+	assert.False(suite.T(), bytes.Contains(thumbnail.Data, []byte("Exif")))
+
+	// Rotating clockwise puts the red (left) half on top
+	decoded, err := jpeg.Decode(bytes.NewReader(thumbnail.Data))
+	assert.NoError(suite.T(), err)
+	r, _, b, _ := decoded.At(12, 5).RGBA()
+	assert.Greater(suite.T(), r, b)
+}
+
+// TestImagingSuite runs the test suite
+func TestImagingSuite(t *testing.T) {
+	suite.Run(t, new(ImagingTestSuite))
+}
@@ -0,0 +1,134 @@
+package unit
+
+import (
+	"testing"
+
+	"jobsity-backend/internal/markdown"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// MarkdownTestSuite contains the test suite for message formatting
+type MarkdownTestSuite struct {
+	suite.Suite
+}
+
+// TestRender tests the supported Markdown subset
+func (suite *MarkdownTestSuite) TestRender() {
+	tests := []struct {
+		name  string
+		input string
+		html  string
+		text  string
+	}{
+		{
+			name:  "plain text",
+			input: "hello world",
+			html:  "<p>hello world</p>",
+			text:  "hello world",
+		},
+		{
+			name:  "bold and italics",
+			input: "**bold** and *italic* and _also_",
+			html:  "<p><strong>bold</strong> and <em>italic</em> and <em>also</em></p>",
+			text:  "bold and italic and also",
+		},
+		{
+			name:  "snake_case is not emphasis",
+			input: "use snake_case_names here",
+			html:  "<p>use snake_case_names here</p>",
+			text:  "use snake_case_names here",
+		},
+		{
+			name:  "inline code is not formatted",
+			input: "run `a **b** <c>`",
+			html:  "<p>run <code>a **b** &lt;c&gt;</code></p>",
+			text:  "run a **b** <c>",
+		},
+		{
+			name:  "code block",
+			input: "```go\nfmt.Println(\"<hi>\")\n```",
+			html:  "<pre><code class=\"language-go\">fmt.Println(&#34;&lt;hi&gt;&#34;)</code></pre>",
+			text:  "fmt.Println(\"<hi>\")",
+		},
+		{
+			name:  "link",
+			input: "see [the docs](https://example.com/docs)",
+			html:  `<p>see <a href="https://example.com/docs" rel="nofollow noopener noreferrer" target="_blank">the docs</a></p>`,
+			text:  "see the docs (https://example.com/docs)",
+		},
+		{
+			name:  "bare URL",
+			input: "go to https://example.com.",
+			html:  `<p>go to <a href="https://example.com" rel="nofollow noopener noreferrer" target="_blank">https://example.com</a>.</p>`,
+			text:  "go to https://example.com.",
+		},
+		{
+			name:  "quote",
+			input: "> quoted **text**\n> second line\n\nreply",
+			html:  "<blockquote><p>quoted <strong>text</strong><br>second line</p></blockquote><p>reply</p>",
+			text:  "> quoted text\n> second line\n\nreply",
+		},
+		{
+			name:  "backslash escapes",
+			input: `\*not italic\*`,
+			html:  "<p>*not italic*</p>",
+			text:  "*not italic*",
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			rendered := markdown.Render(tt.input)
+			assert.Equal(suite.T(), tt.html, rendered.HTML)
+			assert.Equal(suite.T(), tt.text, rendered.Text)
+		})
+	}
+}
+
+// TestRenderEscapesDangerousInput tests that untrusted markup never reaches the HTML output
+func (suite *MarkdownTestSuite) TestRenderEscapesDangerousInput() {
+	tests := []struct {
+		name  string
+		input string
+		html  string
+	}{
+		{
+			name:  "raw HTML",
+			input: `<script>alert(1)</script><img src=x onerror=alert(1)>`,
+			html:  "<p>&lt;script&gt;alert(1)&lt;/script&gt;&lt;img src=x onerror=alert(1)&gt;</p>",
+		},
+		{
+			name:  "javascript link",
+			input: "[click](javascript:alert(1))",
+			html:  "<p>[click](javascript:alert(1))</p>",
+		},
+		{
+			name:  "data link",
+			input: "[x](data:text/html;base64,PHNjcmlwdD4=)",
+			html:  "<p>[x](data:text/html;base64,PHNjcmlwdD4=)</p>",
+		},
+		{
+			name:  "attribute breakout in link",
+			input: `[x](https://example.com/"onmouseover="alert(1))`,
+			html:  `<p><a href="https://example.com/%22onmouseover=%22alert%281" rel="nofollow noopener noreferrer" target="_blank">x</a>)</p>`,
+		},
+		{
+			name:  "code block language injection",
+			input: "```\"><script>\nx\n```",
+			html:  "<pre><code>x</code></pre>",
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			assert.Equal(suite.T(), tt.html, markdown.Render(tt.input).HTML)
+		})
+	}
+}
+
+// TestMarkdownSuite runs the test suite
+func TestMarkdownSuite(t *testing.T) {
+	suite.Run(t, new(MarkdownTestSuite))
+}
@@ -0,0 +1,202 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/service"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// MemoryBrokerTestSuite contains the test suite for the in-process broker
+type MemoryBrokerTestSuite struct {
+	suite.Suite
+	broker *broker.MemoryBroker
+	policy broker.RetryPolicy
+}
+
+// SetupTest creates an empty broker before each test
+func (suite *MemoryBrokerTestSuite) SetupTest() {
+	suite.broker = broker.NewMemoryBroker()
+	suite.policy = broker.RetryPolicy{MaxRetries: 2, BaseDelay: 10 * time.Millisecond, MaxDelay: 20 * time.Millisecond}
+}
+
+// TearDownTest closes the broker after each test
+func (suite *MemoryBrokerTestSuite) TearDownTest() {
+	suite.broker.Close()
+}
+
+// receive waits for the next delivery handed to a consumer
+func (suite *MemoryBrokerTestSuite) receive(deliveries chan amqp.Delivery) amqp.Delivery {
+	select {
+	case msg := <-deliveries:
+		return msg
+	case <-time.After(time.Second):
+		suite.FailNow("no delivery received")
+		return amqp.Delivery{}
+	}
+}
+
+// TestConsume tests that published messages reach a consumer
+func (suite *MemoryBrokerTestSuite) TestConsume() {
+	var _ broker.Broker = suite.broker
+
+	deliveries := make(chan amqp.Delivery, 1)
+	consumer := suite.broker.Consume("stock_commands", 1, func(msg amqp.Delivery) {
+		msg.Ack(false)
+		deliveries <- msg
+	})
+	defer consumer.Cancel()
+
+	err := suite.broker.PublishConfirmed(context.Background(), "", "stock_commands", amqp.Publishing{CorrelationId: "corr-1", Body: []byte("hello")})
+	suite.Require().NoError(err)
+
+	msg := suite.receive(deliveries)
+	assert.Equal(suite.T(), "corr-1", msg.CorrelationId)
+	assert.Equal(suite.T(), "hello", string(msg.Body))
+	assert.False(suite.T(), msg.Redelivered)
+
+	err = suite.broker.Publish("unknown", "stock_commands", false, false, amqp.Publishing{})
+	assert.ErrorIs(suite.T(), err, broker.ErrUnroutable)
+}
+
+// TestSubscribe tests that every subscriber of a fanout exchange receives
+// each message
+func (suite *MemoryBrokerTestSuite) TestSubscribe() {
+	err := suite.broker.Publish("chat_events", "", false, false, amqp.Publishing{})
+	assert.ErrorIs(suite.T(), err, broker.ErrUnroutable)
+
+	first := make(chan amqp.Delivery, 1)
+	second := make(chan amqp.Delivery, 1)
+	for _, deliveries := range []chan amqp.Delivery{first, second} {
+		consumer, err := suite.broker.Subscribe("chat_events", func(msg amqp.Delivery) {
+			msg.Ack(false)
+			deliveries <- msg
+		})
+		suite.Require().NoError(err)
+		defer consumer.Cancel()
+	}
+
+	err = suite.broker.PublishConfirmed(context.Background(), "chat_events", "", amqp.Publishing{MessageId: "event-1"})
+	suite.Require().NoError(err)
+
+	assert.Equal(suite.T(), "event-1", suite.receive(first).MessageId)
+	assert.Equal(suite.T(), "event-1", suite.receive(second).MessageId)
+}
+
+// TestSettleRetriesThenDeadLetters tests that Settle's retry queues deliver
+// back to the work queue after their delay and exhausted deliveries are
+// dead-lettered
+func (suite *MemoryBrokerTestSuite) TestSettleRetriesThenDeadLetters() {
+	deliveries := make(chan amqp.Delivery, 3)
+	consumer := suite.broker.Consume("stock_commands", 1, func(msg amqp.Delivery) {
+		deliveries <- msg
+		broker.Settle(suite.broker, "stock_commands", msg, suite.policy, errors.New("timeout"))
+	})
+	defer consumer.Cancel()
+
+	suite.Require().NoError(suite.broker.Publish("", "stock_commands", false, false, amqp.Publishing{CorrelationId: "corr-1"}))
+
+	started := time.Now()
+	for attempt := 0; attempt <= suite.policy.MaxRetries; attempt++ {
+		msg := suite.receive(deliveries)
+		assert.Equal(suite.T(), attempt, broker.RetryCount(msg))
+	}
+	assert.GreaterOrEqual(suite.T(), time.Since(started), 30*time.Millisecond)
+
+	assert.Eventually(suite.T(), func() bool {
+		return suite.broker.Len(broker.DeadLetterQueueName("stock_commands")) == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+// TestDeadLetterService tests listing and requeueing dead letters held in memory
+func (suite *MemoryBrokerTestSuite) TestDeadLetterService() {
+	ch, err := suite.broker.Channel()
+	suite.Require().NoError(err)
+	defer ch.Close()
+
+	// A nack without requeue dead-letters the delivery
+	suite.Require().NoError(ch.Publish("", "stock_commands", false, false, amqp.Publishing{CorrelationId: "corr-1"}))
+	msg, ok, err := ch.Get("stock_commands", false)
+	suite.Require().NoError(err)
+	suite.Require().True(ok)
+	suite.Require().NoError(msg.Nack(false, false))
+
+	deadLetters, err := service.NewDeadLetterService(suite.broker, []string{"stock_commands"})
+	suite.Require().NoError(err)
+
+	listed, err := deadLetters.ListDeadLetters("stock_commands", 10)
+	suite.Require().NoError(err)
+	suite.Require().Len(listed, 1)
+	assert.Equal(suite.T(), "corr-1", listed[0].CorrelationID)
+	assert.Equal(suite.T(), 1, suite.broker.Len("stock_commands.dead"))
+
+	requeued, err := deadLetters.RequeueDeadLetters("stock_commands", nil, 10)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, requeued)
+	assert.Equal(suite.T(), 0, suite.broker.Len("stock_commands.dead"))
+	assert.Equal(suite.T(), 1, suite.broker.Len("stock_commands"))
+}
+
+// unconfirmedBroker is a MemoryBroker whose confirmed publishes are refused
+type unconfirmedBroker struct {
+	*broker.MemoryBroker
+}
+
+// PublishConfirmed fails as if the broker had nacked the message
+func (b unconfirmedBroker) PublishConfirmed(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+	return broker.ErrNotConfirmed
+}
+
+// TestRequeueKeepsUnconfirmedDeadLetters tests that a dead letter stays in
+// its queue when the broker does not confirm its copy in the work queue
+func (suite *MemoryBrokerTestSuite) TestRequeueKeepsUnconfirmedDeadLetters() {
+	suite.Require().NoError(suite.broker.Publish(broker.DeadLetterExchange, "stock_commands", false, false, amqp.Publishing{CorrelationId: "corr-1"}))
+
+	deadLetters, err := service.NewDeadLetterService(unconfirmedBroker{suite.broker}, []string{"stock_commands"})
+	suite.Require().NoError(err)
+
+	requeued, err := deadLetters.RequeueDeadLetters("stock_commands", nil, 10)
+	assert.ErrorIs(suite.T(), err, broker.ErrNotConfirmed)
+	assert.Equal(suite.T(), 0, requeued)
+	assert.Equal(suite.T(), 1, suite.broker.Len("stock_commands.dead"))
+	assert.Equal(suite.T(), 0, suite.broker.Len("stock_commands"))
+}
+
+// TestDrainRequeues tests that draining waits for the delivery being handled
+// and gives back the ones that were not handled
+func (suite *MemoryBrokerTestSuite) TestDrainRequeues() {
+	release := make(chan struct{})
+	handled := make(chan amqp.Delivery, 2)
+	consumer := suite.broker.Consume("stock_commands", 1, func(msg amqp.Delivery) {
+		handled <- msg
+		<-release
+		msg.Ack(false)
+	})
+
+	suite.Require().NoError(suite.broker.Publish("", "stock_commands", false, false, amqp.Publishing{MessageId: "first"}))
+	assert.Equal(suite.T(), "first", suite.receive(handled).MessageId)
+	suite.Require().NoError(suite.broker.Publish("", "stock_commands", false, false, amqp.Publishing{MessageId: "second"}))
+
+	// The first delivery is still being handled when the deadline passes
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(suite.T(), consumer.Drain(ctx), context.DeadlineExceeded)
+	close(release)
+
+	assert.Eventually(suite.T(), func() bool {
+		return suite.broker.Len("stock_commands") == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Len(suite.T(), handled, 0)
+}
+
+// TestMemoryBrokerSuite runs the in-process broker test suite
+func TestMemoryBrokerSuite(t *testing.T) {
+	suite.Run(t, new(MemoryBrokerTestSuite))
+}
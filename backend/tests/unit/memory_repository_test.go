@@ -0,0 +1,205 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/internal/service"
+	"jobsity-backend/pkg/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// MemoryRepositoryTestSuite contains the test suite for the in-memory repositories
+type MemoryRepositoryTestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+// SetupTest sets up the context before each test
+func (suite *MemoryRepositoryTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+}
+
+// TestUserRepository tests creating, finding, updating and deleting users
+func (suite *MemoryRepositoryTestSuite) TestUserRepository() {
+	repo := repository.NewMemoryUserRepository()
+
+	user := &domain.User{Email: "user@example.com", Password: "secret"}
+	suite.Require().NoError(repo.Create(suite.ctx, user))
+	assert.Len(suite.T(), user.ID, 24)
+	assert.False(suite.T(), user.CreatedAt.IsZero())
+
+	found, err := repo.FindByEmail(suite.ctx, "user@example.com")
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), user.ID, found.ID)
+
+	// Callers get copies, so changing one does not change the stored user
+	found.Password = "changed"
+	found, err = repo.FindByID(suite.ctx, user.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "secret", found.Password)
+
+	found.Password = "changed"
+	suite.Require().NoError(repo.Update(suite.ctx, found))
+	found, _ = repo.FindByEmail(suite.ctx, "user@example.com")
+	assert.Equal(suite.T(), "changed", found.Password)
+
+	suite.Require().NoError(repo.Delete(suite.ctx, user.ID))
+	_, err = repo.FindByID(suite.ctx, user.ID)
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+	_, err = repo.FindByEmail(suite.ctx, "user@example.com")
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+}
+
+// TestInvalidIDs tests that malformed IDs fail like they do against MongoDB
+// while missing documents are only reported by finds
+func (suite *MemoryRepositoryTestSuite) TestInvalidIDs() {
+	users := repository.NewMemoryUserRepository()
+	channels := repository.NewMemoryChannelRepository()
+	messages := repository.NewMemoryMessageRepository()
+
+	_, err := users.FindByID(suite.ctx, "not-an-id")
+	assert.Error(suite.T(), err)
+	assert.ErrorIs(suite.T(), err, domain.ErrInvalidID)
+	assert.Error(suite.T(), channels.Delete(suite.ctx, "not-an-id"))
+	assert.Error(suite.T(), messages.Update(suite.ctx, &domain.Message{ID: "not-an-id"}))
+
+	missing := "507f1f77bcf86cd799439011"
+	_, err = channels.FindByID(suite.ctx, missing)
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+	assert.NoError(suite.T(), channels.Update(suite.ctx, &domain.Channel{ID: missing, Name: "ghost"}))
+	assert.NoError(suite.T(), messages.Delete(suite.ctx, missing))
+}
+
+// TestChannelRepository tests channel lookups and updates
+func (suite *MemoryRepositoryTestSuite) TestChannelRepository() {
+	repo := repository.NewMemoryChannelRepository()
+
+	for _, name := range []string{"general", "random"} {
+		suite.Require().NoError(repo.Create(suite.ctx, &domain.Channel{Name: name, CreatedBy: "user@example.com"}))
+	}
+
+	channels, err := repo.FindAll(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Require().Len(channels, 2)
+	assert.Equal(suite.T(), "general", channels[0].Name)
+
+	channel, err := repo.FindByName(suite.ctx, "random")
+	suite.Require().NoError(err)
+	channel.Description = "Off topic"
+	suite.Require().NoError(repo.Update(suite.ctx, channel))
+
+	updated, err := repo.FindByID(suite.ctx, channel.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Off topic", updated.Description)
+	assert.Equal(suite.T(), "user@example.com", updated.CreatedBy)
+
+	_, err = repo.FindByName(suite.ctx, "missing")
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+}
+
+// TestMessagesByChannel tests that the newest messages are returned oldest first
+func (suite *MemoryRepositoryTestSuite) TestMessagesByChannel() {
+	repo := repository.NewMemoryMessageRepository()
+
+	for _, content := range []string{"one", "two", "three"} {
+		suite.Require().NoError(repo.Create(suite.ctx, &domain.Message{ChannelID: "channel-1", Content: content}))
+		time.Sleep(time.Millisecond)
+	}
+	suite.Require().NoError(repo.Create(suite.ctx, &domain.Message{ChannelID: "channel-2", Content: "elsewhere"}))
+
+	messages, err := repo.FindByChannelID(suite.ctx, "channel-1", 2)
+	suite.Require().NoError(err)
+	suite.Require().Len(messages, 2)
+	assert.Equal(suite.T(), "two", messages[0].Content)
+	assert.Equal(suite.T(), "three", messages[1].Content)
+	assert.Equal(suite.T(), domain.AuthorUser, messages[0].AuthorType)
+
+	messages, _ = repo.FindByChannelID(suite.ctx, "channel-1", 0)
+	assert.Len(suite.T(), messages, 3)
+}
+
+// TestMessageEmbeddedUpdates tests replacing embedded attachments and previews
+func (suite *MemoryRepositoryTestSuite) TestMessageEmbeddedUpdates() {
+	repo := repository.NewMemoryMessageRepository()
+
+	message := &domain.Message{
+		ChannelID:     "channel-1",
+		Content:       "photo",
+		AuthorType:    domain.AuthorBot,
+		CorrelationID: "corr-1",
+		Attachments:   []*domain.Attachment{{ID: "attachment-1", FileName: "cat.png"}},
+	}
+	suite.Require().NoError(repo.Create(suite.ctx, message))
+
+	// The caller's attachment is not the stored one
+	message.Attachments[0].FileName = "dog.png"
+
+	suite.Require().NoError(repo.UpdateAttachment(suite.ctx, message.ID, &domain.Attachment{ID: "attachment-1", FileName: "cat.png", ThumbnailKey: "thumb"}))
+	suite.Require().NoError(repo.UpdatePreviews(suite.ctx, message.ID, message.Content, []*domain.LinkPreview{{URL: "https://example.com"}}))
+
+	found, err := repo.FindByCorrelationID(suite.ctx, "corr-1")
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), domain.AuthorBot, found.AuthorType)
+	assert.Equal(suite.T(), "cat.png", found.Attachments[0].FileName)
+	assert.True(suite.T(), found.Attachments[0].HasThumbnail())
+	assert.Equal(suite.T(), "https://example.com", found.Previews[0].URL)
+
+	_, err = repo.FindByCorrelationID(suite.ctx, "corr-2")
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+}
+
+// TestAttachmentClaims tests that an attachment is only linked to a message
+// while it is free, and only deleted while unattached
+func (suite *MemoryRepositoryTestSuite) TestAttachmentClaims() {
+	repo := repository.NewMemoryAttachmentRepository()
+	attachment := &domain.Attachment{ChannelID: "channel-1", FileName: "cat.png"}
+	suite.Require().NoError(repo.Create(suite.ctx, attachment))
+
+	suite.Require().NoError(repo.SwapMessage(suite.ctx, attachment.ID, "", "message-1"))
+	assert.ErrorIs(suite.T(), repo.SwapMessage(suite.ctx, attachment.ID, "", "message-2"), domain.ErrConflict)
+	assert.ErrorIs(suite.T(), repo.DeleteUnattached(suite.ctx, attachment.ID), domain.ErrConflict)
+	assert.ErrorIs(suite.T(), repo.SwapMessage(suite.ctx, "507f1f77bcf86cd799439011", "", "message-1"), domain.ErrNotFound)
+
+	found, err := repo.FindByID(suite.ctx, attachment.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "message-1", found.MessageID)
+
+	suite.Require().NoError(repo.SwapMessage(suite.ctx, attachment.ID, "message-1", ""))
+	suite.Require().NoError(repo.DeleteUnattached(suite.ctx, attachment.ID))
+	_, err = repo.FindByID(suite.ctx, attachment.ID)
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+}
+
+// TestServicesOnMemoryRepositories tests the message service end to end on
+// in-memory repositories instead of mocks
+func (suite *MemoryRepositoryTestSuite) TestServicesOnMemoryRepositories() {
+	channels := repository.NewMemoryChannelRepository()
+	messages := repository.NewMemoryMessageRepository()
+	messageService := service.NewMessageService(messages, channels, repository.NewMemoryAttachmentRepository())
+
+	channel := &domain.Channel{Name: "general"}
+	suite.Require().NoError(channels.Create(suite.ctx, channel))
+
+	created, err := messageService.CreateMessage(suite.ctx, &domain.CreateMessageRequest{ChannelID: channel.ID, Content: "**hello**"}, "user@example.com")
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), domain.AuthorUser, created.AuthorType)
+
+	listed, err := messageService.GetMessagesByChannel(suite.ctx, channel.ID, 50)
+	suite.Require().NoError(err)
+	suite.Require().Len(listed, 1)
+	assert.Equal(suite.T(), created.ID, listed[0].ID)
+	assert.Contains(suite.T(), listed[0].ContentHTML, "<strong>hello</strong>")
+
+	_, err = messageService.CreateMessage(suite.ctx, &domain.CreateMessageRequest{ChannelID: "507f1f77bcf86cd799439011", Content: "hi"}, "user@example.com")
+	assert.EqualError(suite.T(), err, "channel not found")
+}
+
+// TestMemoryRepositorySuite runs the in-memory repository test suite
+func TestMemoryRepositorySuite(t *testing.T) {
+	suite.Run(t, new(MemoryRepositoryTestSuite))
+}
@@ -0,0 +1,347 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/service"
+	"jobsity-backend/pkg/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// MockMessageRepository is a mock implementation of MessageRepository
+type MockMessageRepository struct {
+	mock.Mock
+}
+
+func (m *MockMessageRepository) Create(ctx context.Context, message *domain.Message) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) FindByID(ctx context.Context, id string) (*domain.Message, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) FindByCorrelationID(ctx context.Context, correlationID string) (*domain.Message, error) {
+	args := m.Called(ctx, correlationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) FindByChannelID(ctx context.Context, channelID string, limit int) ([]*domain.Message, error) {
+	args := m.Called(ctx, channelID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) Update(ctx context.Context, message *domain.Message) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) UpdateAttachment(ctx context.Context, messageID string, attachment *domain.Attachment) error {
+	args := m.Called(ctx, messageID, attachment)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) UpdatePreviews(ctx context.Context, messageID string, content string, previews []*domain.LinkPreview) error {
+	args := m.Called(ctx, messageID, content, previews)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockChannelRepository is a mock implementation of ChannelRepository
+type MockChannelRepository struct {
+	mock.Mock
+}
+
+func (m *MockChannelRepository) Create(ctx context.Context, channel *domain.Channel) error {
+	args := m.Called(ctx, channel)
+	return args.Error(0)
+}
+
+func (m *MockChannelRepository) FindByID(ctx context.Context, id string) (*domain.Channel, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Channel), args.Error(1)
+}
+
+func (m *MockChannelRepository) FindByName(ctx context.Context, name string) (*domain.Channel, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Channel), args.Error(1)
+}
+
+func (m *MockChannelRepository) FindAll(ctx context.Context) ([]*domain.Channel, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Channel), args.Error(1)
+}
+
+func (m *MockChannelRepository) Update(ctx context.Context, channel *domain.Channel) error {
+	args := m.Called(ctx, channel)
+	return args.Error(0)
+}
+
+func (m *MockChannelRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MessageServiceTestSuite contains the test suite for the message service
+type MessageServiceTestSuite struct {
+	suite.Suite
+	mockMessageRepo *MockMessageRepository
+	mockChannelRepo *MockChannelRepository
+	mockAttachments *MockAttachmentRepository
+	messageService  service.MessageService
+}
+
+// SetupTest sets up the message service before each test
+func (suite *MessageServiceTestSuite) SetupTest() {
+	suite.mockMessageRepo = new(MockMessageRepository)
+	suite.mockChannelRepo = new(MockChannelRepository)
+	suite.mockAttachments = new(MockAttachmentRepository)
+	suite.messageService = service.NewMessageService(suite.mockMessageRepo, suite.mockChannelRepo, suite.mockAttachments)
+}
+
+// TestCreateMessageIsUserAuthored tests that messages sent by users are marked as user messages
+func (suite *MessageServiceTestSuite) TestCreateMessageIsUserAuthored() {
+	suite.mockChannelRepo.On("FindByID", mock.Anything, "channel-1").Return(&domain.Channel{ID: "channel-1"}, nil)
+	suite.mockMessageRepo.On("Create", mock.Anything, mock.MatchedBy(func(message *domain.Message) bool {
+		return message.AuthorType == domain.AuthorUser && message.UserEmail == "user@example.com"
+	})).Return(nil)
+
+	message, err := suite.messageService.CreateMessage(context.Background(), &domain.CreateMessageRequest{
+		ChannelID: "channel-1",
+		Content:   "hello",
+	}, "user@example.com")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), domain.AuthorUser, message.AuthorType)
+	suite.mockMessageRepo.AssertExpectations(suite.T())
+}
+
+// TestCreateBotMessage tests that bot replies are stored and rendered like other messages
+func (suite *MessageServiceTestSuite) TestCreateBotMessage() {
+	suite.mockMessageRepo.On("FindByCorrelationID", mock.Anything, "corr-1").Return(nil, domain.ErrNotFound)
+	suite.mockChannelRepo.On("FindByID", mock.Anything, "channel-1").Return(&domain.Channel{ID: "channel-1"}, nil)
+	suite.mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Run(func(args mock.Arguments) {
+		message := args.Get(1).(*domain.Message)
+		message.ID = "m1"
+		message.CreatedAt = time.Now()
+	}).Return(nil)
+
+	message, err := suite.messageService.CreateBotMessage(context.Background(), &domain.Message{
+		ChannelID:     "channel-1",
+		UserEmail:     "stock_bot",
+		AuthorType:    domain.AuthorBot,
+		CorrelationID: "corr-1",
+		Content:       "AAPL.US quote is **$210.00** per share",
+	})
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "m1", message.ID)
+	assert.Equal(suite.T(), domain.AuthorBot, message.AuthorType)
+	assert.Contains(suite.T(), message.ContentHTML, "<strong>$210.00</strong>")
+	assert.Equal(suite.T(), "AAPL.US quote is $210.00 per share", message.ContentText)
+	suite.mockMessageRepo.AssertExpectations(suite.T())
+}
+
+// TestCreateBotMessageRedelivered tests that a redelivered reply is not stored twice
+func (suite *MessageServiceTestSuite) TestCreateBotMessageRedelivered() {
+	stored := &domain.Message{ID: "m1", ChannelID: "channel-1", AuthorType: domain.AuthorBot, CorrelationID: "corr-1"}
+	suite.mockMessageRepo.On("FindByCorrelationID", mock.Anything, "corr-1").Return(stored, nil)
+
+	message, err := suite.messageService.CreateBotMessage(context.Background(), &domain.Message{
+		ChannelID:     "channel-1",
+		UserEmail:     "stock_bot",
+		AuthorType:    domain.AuthorBot,
+		CorrelationID: "corr-1",
+		Content:       "AAPL.US quote is $210.00 per share",
+	})
+
+	assert.NoError(suite.T(), err)
+	assert.Same(suite.T(), stored, message)
+	suite.mockMessageRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+}
+
+// TestCreateBotMessageStoredConcurrently tests that a reply stored by another
+// server between the lookup and the insert resolves to the stored message
+func (suite *MessageServiceTestSuite) TestCreateBotMessageStoredConcurrently() {
+	stored := &domain.Message{ID: "m1", ChannelID: "channel-1", AuthorType: domain.AuthorBot, CorrelationID: "corr-1"}
+	suite.mockMessageRepo.On("FindByCorrelationID", mock.Anything, "corr-1").Return(nil, domain.ErrNotFound).Once()
+	suite.mockChannelRepo.On("FindByID", mock.Anything, "channel-1").Return(&domain.Channel{ID: "channel-1"}, nil)
+	suite.mockMessageRepo.On("Create", mock.Anything, mock.Anything).Return(domain.Conflict("already exists"))
+	suite.mockMessageRepo.On("FindByCorrelationID", mock.Anything, "corr-1").Return(stored, nil).Once()
+
+	message, err := suite.messageService.CreateBotMessage(context.Background(), &domain.Message{
+		ChannelID:     "channel-1",
+		UserEmail:     "stock_bot",
+		AuthorType:    domain.AuthorBot,
+		CorrelationID: "corr-1",
+		Content:       "AAPL.US quote is $210.00 per share",
+	})
+
+	assert.NoError(suite.T(), err)
+	assert.Same(suite.T(), stored, message)
+}
+
+// TestCreateBotMessageRequiresBotAuthor tests that bot messages cannot pose as users
+func (suite *MessageServiceTestSuite) TestCreateBotMessageRequiresBotAuthor() {
+	_, err := suite.messageService.CreateBotMessage(context.Background(), &domain.Message{
+		ChannelID:  "channel-1",
+		UserEmail:  "user@example.com",
+		AuthorType: domain.AuthorUser,
+		Content:    "hello",
+	})
+
+	assert.Error(suite.T(), err)
+	suite.mockMessageRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+}
+
+// TestCreateBotMessageRepositoryError tests that storage failures are returned so the reply is retried
+func (suite *MessageServiceTestSuite) TestCreateBotMessageRepositoryError() {
+	suite.mockMessageRepo.On("FindByCorrelationID", mock.Anything, "corr-1").Return(nil, errors.New("connection reset"))
+
+	_, err := suite.messageService.CreateBotMessage(context.Background(), &domain.Message{
+		ChannelID:     "channel-1",
+		UserEmail:     "stock_bot",
+		AuthorType:    domain.AuthorBot,
+		CorrelationID: "corr-1",
+		Content:       "AAPL.US quote is $210.00 per share",
+	})
+
+	assert.EqualError(suite.T(), err, "connection reset")
+}
+
+// TestBotMessagesCannotBeEdited tests that users cannot edit or delete bot messages
+func (suite *MessageServiceTestSuite) TestBotMessagesCannotBeEdited() {
+	suite.mockMessageRepo.On("FindByID", mock.Anything, "m1").Return(&domain.Message{
+		ID:         "m1",
+		ChannelID:  "channel-1",
+		UserEmail:  "stock_bot",
+		AuthorType: domain.AuthorBot,
+	}, nil)
+
+	_, err := suite.messageService.UpdateMessage(context.Background(), "m1", "edited", "stock_bot")
+	assert.Error(suite.T(), err)
+
+	err = suite.messageService.DeleteMessage(context.Background(), "m1", "stock_bot")
+	assert.Error(suite.T(), err)
+
+	suite.mockMessageRepo.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything)
+	suite.mockMessageRepo.AssertNotCalled(suite.T(), "Delete", mock.Anything, mock.Anything)
+}
+
+// TestMessageServiceSuite runs the message service test suite
+// expectAttachment sets up an upload of user@example.com to channel-1
+func (suite *MessageServiceTestSuite) expectAttachment(id string) {
+	suite.mockChannelRepo.On("FindByID", mock.Anything, "channel-1").Return(&domain.Channel{ID: "channel-1"}, nil)
+	suite.mockAttachments.On("FindByID", mock.Anything, id).Return(&domain.Attachment{ID: id, ChannelID: "channel-1", UploadedBy: "user@example.com"}, nil)
+}
+
+// postWithAttachments sends a message with the given attachments
+func (suite *MessageServiceTestSuite) postWithAttachments(ids ...string) (*domain.Message, error) {
+	return suite.messageService.CreateMessage(context.Background(), &domain.CreateMessageRequest{
+		ChannelID:     "channel-1",
+		Content:       "look",
+		AttachmentIDs: ids,
+	}, "user@example.com")
+}
+
+// TestCreateMessageClaimsAttachments tests that attachments are claimed
+// before the message is stored and then linked to it
+func (suite *MessageServiceTestSuite) TestCreateMessageClaimsAttachments() {
+	suite.expectAttachment("att-1")
+	var claim string
+	suite.mockAttachments.On("SwapMessage", mock.Anything, "att-1", "", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		claim = args.String(3)
+	}).Return(nil).Once()
+	suite.mockMessageRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		suite.Require().NotEmpty(claim, "attachment not claimed before the message was stored")
+		args.Get(1).(*domain.Message).ID = "message-1"
+	}).Return(nil)
+	suite.mockAttachments.On("SwapMessage", mock.Anything, "att-1", mock.AnythingOfType("string"), "message-1").Return(nil).Once()
+
+	message, err := suite.postWithAttachments("att-1")
+
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "message-1", message.Attachments[0].MessageID)
+	suite.mockAttachments.AssertCalled(suite.T(), "SwapMessage", mock.Anything, "att-1", claim, "message-1")
+}
+
+// TestCreateMessageAttachmentTakenConcurrently tests that an attachment
+// claimed by a concurrent message is rejected without storing the message,
+// and that attachments claimed before it are given back
+func (suite *MessageServiceTestSuite) TestCreateMessageAttachmentTakenConcurrently() {
+	suite.expectAttachment("att-1")
+	suite.expectAttachment("att-2")
+	suite.mockAttachments.On("SwapMessage", mock.Anything, "att-1", "", mock.AnythingOfType("string")).Return(nil).Once()
+	suite.mockAttachments.On("SwapMessage", mock.Anything, "att-2", "", mock.AnythingOfType("string")).Return(domain.Conflict("attachment is already used by another message"))
+	suite.mockAttachments.On("SwapMessage", mock.Anything, "att-1", mock.AnythingOfType("string"), "").Return(nil).Once()
+
+	_, err := suite.postWithAttachments("att-1", "att-2")
+
+	assert.ErrorIs(suite.T(), err, domain.ErrConflict)
+	suite.mockMessageRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+	suite.mockAttachments.AssertNumberOfCalls(suite.T(), "SwapMessage", 3)
+}
+
+// TestCreateMessageReleasesClaimsOnFailure tests that attachments are given
+// back when the message cannot be stored
+func (suite *MessageServiceTestSuite) TestCreateMessageReleasesClaimsOnFailure() {
+	suite.expectAttachment("att-1")
+	suite.mockAttachments.On("SwapMessage", mock.Anything, "att-1", "", mock.AnythingOfType("string")).Return(nil).Once()
+	suite.mockMessageRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+	suite.mockAttachments.On("SwapMessage", mock.Anything, "att-1", mock.AnythingOfType("string"), "").Return(nil).Once()
+
+	_, err := suite.postWithAttachments("att-1")
+
+	assert.EqualError(suite.T(), err, "connection refused")
+	suite.mockAttachments.AssertNumberOfCalls(suite.T(), "SwapMessage", 2)
+}
+
+// TestCreateMessageSucceedsWhenLinkingFails tests that a stored message is
+// returned even if its claimed attachments cannot be linked to it
+func (suite *MessageServiceTestSuite) TestCreateMessageSucceedsWhenLinkingFails() {
+	suite.expectAttachment("att-1")
+	suite.mockAttachments.On("SwapMessage", mock.Anything, "att-1", "", mock.AnythingOfType("string")).Return(nil).Once()
+	suite.mockMessageRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.Message).ID = "message-1"
+	}).Return(nil)
+	suite.mockAttachments.On("SwapMessage", mock.Anything, "att-1", mock.AnythingOfType("string"), "message-1").Return(errors.New("connection refused"))
+
+	message, err := suite.postWithAttachments("att-1")
+
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "message-1", message.ID)
+}
+
+func TestMessageServiceSuite(t *testing.T) {
+	suite.Run(t, new(MessageServiceTestSuite))
+}
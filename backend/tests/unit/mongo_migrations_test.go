@@ -0,0 +1,108 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"jobsity-backend/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// MongoMigrationsTestSuite contains the test suite for the MongoDB migration runner
+type MongoMigrationsTestSuite struct {
+	suite.Suite
+	mt *mtest.T
+}
+
+func (suite *MongoMigrationsTestSuite) SetupTest() {
+	suite.mt = mtest.New(suite.T(), mtest.NewOptions().ClientType(mtest.Mock))
+}
+
+// sentCommands returns the names and collections of the commands sent to the server
+func sentCommands(mt *mtest.T) []string {
+	var sent []string
+	for _, event := range mt.GetAllStartedEvents() {
+		collection, _ := event.Command.Lookup(event.CommandName).StringValueOK()
+		sent = append(sent, event.CommandName+" "+collection)
+	}
+	return sent
+}
+
+// TestAppliesMissingMigrations tests that indexes are created and recorded in order
+func (suite *MongoMigrationsTestSuite) TestAppliesMissingMigrations() {
+	suite.mt.Run("fresh database", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "jobsity.schema_migrations", mtest.FirstBatch))
+		for i := 0; i < 6; i++ {
+			mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+		}
+
+		err := database.MigrateMongo(context.Background(), mt.DB)
+
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), []string{
+			"find schema_migrations",
+			"createIndexes users", "insert schema_migrations",
+			"createIndexes channels", "insert schema_migrations",
+			"createIndexes messages", "insert schema_migrations",
+			"createIndexes outbox", "insert schema_migrations",
+			"createIndexes outbox", "insert schema_migrations",
+			"createIndexes messages", "insert schema_migrations",
+		}, sentCommands(mt))
+
+		indexes, _ := mt.GetAllStartedEvents()[1].Command.Lookup("indexes").ArrayOK()
+		index, _ := indexes.Index(0).Value().DocumentOK()
+		assert.True(suite.T(), index.Lookup("unique").Boolean())
+
+		indexes, _ = mt.GetAllStartedEvents()[11].Command.Lookup("indexes").ArrayOK()
+		index, _ = indexes.Index(0).Value().DocumentOK()
+		assert.True(suite.T(), index.Lookup("unique").Boolean())
+		assert.True(suite.T(), index.Lookup("sparse").Boolean())
+	})
+
+	suite.mt.Run("partly migrated", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "jobsity.schema_migrations", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "0001"}}, bson.D{{Key: "_id", Value: "0002"}}))
+		for i := 0; i < 4; i++ {
+			mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+		}
+
+		err := database.MigrateMongo(context.Background(), mt.DB)
+
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), []string{
+			"find schema_migrations",
+			"createIndexes messages", "insert schema_migrations",
+			"createIndexes outbox", "insert schema_migrations",
+			"createIndexes outbox", "insert schema_migrations",
+			"createIndexes messages", "insert schema_migrations",
+		}, sentCommands(mt))
+
+		indexes, _ := mt.GetAllStartedEvents()[5].Command.Lookup("indexes").ArrayOK()
+		index, _ := indexes.Index(0).Value().DocumentOK()
+		assert.Equal(suite.T(), int32(86400), index.Lookup("expireAfterSeconds").Int32())
+	})
+}
+
+// TestStopsOnFailure tests that a failed migration is not recorded and stops later ones
+func (suite *MongoMigrationsTestSuite) TestStopsOnFailure() {
+	suite.mt.Run("duplicate emails", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "jobsity.schema_migrations", mtest.FirstBatch))
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code:    11000,
+			Message: "E11000 duplicate key error collection: jobsity.users index: email_1",
+		}))
+
+		err := database.MigrateMongo(context.Background(), mt.DB)
+
+		assert.ErrorContains(suite.T(), err, "0001")
+		assert.Equal(suite.T(), []string{"find schema_migrations", "createIndexes users"}, sentCommands(mt))
+	})
+}
+
+func TestMongoMigrationsSuite(t *testing.T) {
+	suite.Run(t, new(MongoMigrationsTestSuite))
+}
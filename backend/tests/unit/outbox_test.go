@@ -0,0 +1,231 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/internal/service"
+	"jobsity-backend/pkg/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// broadcastEvent is an event handed to a subscriber's notify function
+type broadcastEvent struct {
+	channelID string
+	userEmail string
+	eventType string
+	data      map[string]interface{}
+}
+
+// toMap returns data as its JSON object form
+func toMap(data interface{}) map[string]interface{} {
+	var decoded map[string]interface{}
+	raw, _ := json.Marshal(data)
+	json.Unmarshal(raw, &decoded)
+	return decoded
+}
+
+// OutboxTestSuite contains the test suite for outbox event delivery
+type OutboxTestSuite struct {
+	suite.Suite
+	broker    *broker.MemoryBroker
+	outbox    *repository.MemoryOutboxRepository
+	service   service.MessageService
+	relay     *service.OutboxRelay
+	events    chan broadcastEvent
+	channelID string
+}
+
+// SetupTest wires the outbox message service to in-memory repositories and
+// a subscribed in-memory broker before each test
+func (suite *OutboxTestSuite) SetupTest() {
+	ctx := context.Background()
+	channels := repository.NewMemoryChannelRepository()
+	channel := &domain.Channel{Name: "general", CreatedBy: "user1@jobsity.com"}
+	suite.Require().NoError(channels.Create(ctx, channel))
+	suite.channelID = channel.ID
+
+	suite.broker = broker.NewMemoryBroker()
+	suite.outbox = repository.NewMemoryOutboxRepository()
+	suite.relay = service.NewOutboxRelay(suite.broker, suite.outbox, time.Hour)
+	base := service.NewMessageService(repository.NewMemoryMessageRepository(), channels, repository.NewMemoryAttachmentRepository())
+	suite.service = service.NewOutboxMessageService(base, suite.outbox, repository.NewMemoryTransactor(), nil)
+
+	suite.events = make(chan broadcastEvent, 10)
+}
+
+// TearDownTest closes the broker after each test
+func (suite *OutboxTestSuite) TearDownTest() {
+	suite.broker.Close()
+}
+
+// subscribe starts a subscriber that records the events it broadcasts
+func (suite *OutboxTestSuite) subscribe() {
+	suite.subscribeTo(suite.events)
+}
+
+// subscribeTo starts a subscriber, standing for one server, that records the
+// events it broadcasts in events
+func (suite *OutboxTestSuite) subscribeTo(events chan broadcastEvent) {
+	subscriber, err := service.NewEventSubscriber(suite.broker, func(channelID string, messageType string, data interface{}) {
+		events <- broadcastEvent{channelID: channelID, eventType: messageType, data: toMap(data)}
+	}, func(channelID, userEmail, messageType string, data interface{}) int {
+		events <- broadcastEvent{channelID: channelID, userEmail: userEmail, eventType: messageType, data: toMap(data)}
+		return 1
+	})
+	suite.Require().NoError(err)
+	suite.Require().NoError(subscriber.Start())
+	suite.T().Cleanup(func() { subscriber.Close() })
+}
+
+// receive waits for the next broadcast event
+func (suite *OutboxTestSuite) receive() broadcastEvent {
+	select {
+	case event := <-suite.events:
+		return event
+	case <-time.After(time.Second):
+		suite.FailNow("no event broadcast")
+		return broadcastEvent{}
+	}
+}
+
+// TestMessageEventsAreRelayed tests that message writes store events that
+// reach subscribers in order once relayed
+func (suite *OutboxTestSuite) TestMessageEventsAreRelayed() {
+	suite.subscribe()
+	ctx := context.Background()
+
+	message, err := suite.service.CreateMessage(ctx, &domain.CreateMessageRequest{ChannelID: suite.channelID, Content: "hello"}, "user1@jobsity.com")
+	suite.Require().NoError(err)
+	_, err = suite.service.UpdateMessage(ctx, message.ID, "hello again", "user1@jobsity.com")
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.service.DeleteMessage(ctx, message.ID, "user1@jobsity.com"))
+	assert.Equal(suite.T(), 3, suite.outbox.Len())
+
+	relayed, err := suite.relay.RelayPending(ctx)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 3, relayed)
+	assert.Equal(suite.T(), 0, suite.outbox.Len())
+
+	created := suite.receive()
+	assert.Equal(suite.T(), suite.channelID, created.channelID)
+	assert.Equal(suite.T(), "new_message", created.eventType)
+	assert.Equal(suite.T(), message.ID, created.data["id"])
+	assert.Equal(suite.T(), "hello", created.data["content"])
+
+	updated := suite.receive()
+	assert.Equal(suite.T(), "message_updated", updated.eventType)
+	assert.Equal(suite.T(), "hello again", updated.data["content"])
+
+	deleted := suite.receive()
+	assert.Equal(suite.T(), "message_deleted", deleted.eventType)
+	assert.Equal(suite.T(), message.ID, deleted.data["id"])
+}
+
+// TestFailedWriteStoresNoEvent tests that rejected writes leave the outbox empty
+func (suite *OutboxTestSuite) TestFailedWriteStoresNoEvent() {
+	ctx := context.Background()
+
+	_, err := suite.service.CreateMessage(ctx, &domain.CreateMessageRequest{ChannelID: "507f1f77bcf86cd799439011", Content: "hello"}, "user1@jobsity.com")
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+
+	_, err = suite.service.CreateBotMessage(ctx, &domain.Message{ChannelID: suite.channelID, AuthorType: domain.AuthorBot})
+	assert.ErrorIs(suite.T(), err, domain.ErrInvalidInput)
+
+	assert.Equal(suite.T(), 0, suite.outbox.Len())
+}
+
+// TestPublishFailureKeepsEvent tests that events the broker did not accept
+// stay in the outbox, claimed until their claim expires
+func (suite *OutboxTestSuite) TestPublishFailureKeepsEvent() {
+	suite.subscribe()
+	ctx := context.Background()
+
+	_, err := suite.service.CreateBotMessage(ctx, &domain.Message{ChannelID: suite.channelID, UserEmail: "StockBot", AuthorType: domain.AuthorBot, Content: "AAPL.US quote is $180.00 per share"})
+	suite.Require().NoError(err)
+	suite.broker.Close()
+
+	relayed, err := suite.relay.RelayPending(ctx)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), 0, relayed)
+	assert.Equal(suite.T(), 1, suite.outbox.Len())
+
+	claimed, err := suite.outbox.ClaimPending(ctx, 10, time.Now().Add(time.Minute))
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), claimed)
+}
+
+// TestEventsWithoutSubscribersAreDelivered tests that events no server is
+// subscribed to are not retried forever
+func (suite *OutboxTestSuite) TestEventsWithoutSubscribersAreDelivered() {
+	ctx := context.Background()
+
+	_, err := suite.service.CreateMessage(ctx, &domain.CreateMessageRequest{ChannelID: suite.channelID, Content: "hello"}, "user1@jobsity.com")
+	suite.Require().NoError(err)
+
+	relayed, err := suite.relay.RelayPending(ctx)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, relayed)
+	assert.Equal(suite.T(), 0, suite.outbox.Len())
+}
+
+// TestWorkerEventsAreRelayed tests that events sent outside message writes,
+// to a channel or to one user, reach subscribers through the outbox
+func (suite *OutboxTestSuite) TestWorkerEventsAreRelayed() {
+	suite.subscribe()
+	ctx := context.Background()
+
+	events := service.NewOutboxEvents(suite.outbox, nil)
+	events.Broadcast(suite.channelID, "unfurl", map[string]interface{}{"id": "message-1"})
+	service.SendCommandReply(events.SendToUser, suite.channelID, "user1@jobsity.com", "AAPL.US is not a known symbol")
+	assert.Equal(suite.T(), 2, suite.outbox.Len())
+
+	relayed, err := suite.relay.RelayPending(ctx)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 2, relayed)
+
+	unfurled := suite.receive()
+	assert.Equal(suite.T(), "unfurl", unfurled.eventType)
+	assert.Empty(suite.T(), unfurled.userEmail)
+	assert.Equal(suite.T(), "message-1", unfurled.data["id"])
+
+	reply := suite.receive()
+	assert.Equal(suite.T(), "new_message", reply.eventType)
+	assert.Equal(suite.T(), suite.channelID, reply.channelID)
+	assert.Equal(suite.T(), "user1@jobsity.com", reply.userEmail)
+	assert.Equal(suite.T(), true, reply.data["ephemeral"])
+}
+
+// TestBrokerEventsReachEveryServer tests that an ephemeral reply sent without
+// the outbox reaches the subscribers of other servers
+func (suite *OutboxTestSuite) TestBrokerEventsReachEveryServer() {
+	suite.subscribe()
+	otherServer := make(chan broadcastEvent, 10)
+	suite.subscribeTo(otherServer)
+
+	events := service.NewBrokerEvents(suite.broker)
+	service.SendCommandReply(events.SendToUser, suite.channelID, "user1@jobsity.com", "AAPL.US is not a known symbol")
+	assert.Equal(suite.T(), 0, suite.outbox.Len())
+
+	for _, server := range []chan broadcastEvent{suite.events, otherServer} {
+		select {
+		case reply := <-server:
+			assert.Equal(suite.T(), "new_message", reply.eventType)
+			assert.Equal(suite.T(), suite.channelID, reply.channelID)
+			assert.Equal(suite.T(), "user1@jobsity.com", reply.userEmail)
+			assert.Equal(suite.T(), true, reply.data["ephemeral"])
+		case <-time.After(time.Second):
+			suite.FailNow("no event broadcast")
+		}
+	}
+}
+
+func TestOutboxSuite(t *testing.T) {
+	suite.Run(t, new(OutboxTestSuite))
+}
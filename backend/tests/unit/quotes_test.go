@@ -0,0 +1,298 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/quotes"
+	"jobsity-backend/internal/quotes/quotestest"
+	"jobsity-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// QuotesTestSuite contains the test suite for market data providers
+type QuotesTestSuite struct {
+	suite.Suite
+	server *quotestest.StooqServer
+	stooq  quotes.QuoteProvider
+}
+
+// SetupTest starts a stooq stand-in before each test
+func (suite *QuotesTestSuite) SetupTest() {
+	suite.server = quotestest.NewStooqServer(&quotes.Quote{
+		Symbol: "AAPL.US",
+		Time:   time.Date(2024, 1, 5, 22, 0, 9, 0, time.UTC),
+		Open:   181.99,
+		High:   182.76,
+		Low:    180.17,
+		Close:  181.18,
+		Volume: 62303300,
+	})
+	suite.stooq = quotes.NewStooqProvider(quotes.StooqOptions{BaseURL: suite.server.URL, Timeout: time.Second})
+}
+
+// TearDownTest stops the stooq stand-in
+func (suite *QuotesTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+// TestStooqQuote tests fetching and parsing a stooq quote
+func (suite *QuotesTestSuite) TestStooqQuote() {
+	quote, err := suite.stooq.Quote(context.Background(), "AAPL.US")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "AAPL.US", quote.Symbol)
+	assert.Equal(suite.T(), 181.18, quote.Close)
+	assert.Equal(suite.T(), 181.99, quote.Open)
+	assert.Equal(suite.T(), 182.76, quote.High)
+	assert.Equal(suite.T(), 180.17, quote.Low)
+	assert.Equal(suite.T(), int64(62303300), quote.Volume)
+	assert.Equal(suite.T(), time.Date(2024, 1, 5, 22, 0, 9, 0, time.UTC), quote.Time)
+	assert.Equal(suite.T(), "stooq", quote.Provider)
+}
+
+// TestStooqUnknownSymbol tests that N/D rows are reported as not found
+func (suite *QuotesTestSuite) TestStooqUnknownSymbol() {
+	_, err := suite.stooq.Quote(context.Background(), "nope.us")
+
+	assert.ErrorIs(suite.T(), err, quotes.ErrSymbolNotFound)
+}
+
+// TestStooqServerError tests that HTTP failures are returned as errors
+func (suite *QuotesTestSuite) TestStooqServerError() {
+	suite.server.FailWith(http.StatusServiceUnavailable)
+
+	_, err := suite.stooq.Quote(context.Background(), "aapl.us")
+
+	assert.Error(suite.T(), err)
+	assert.NotErrorIs(suite.T(), err, quotes.ErrSymbolNotFound)
+}
+
+// TestParseStooqCSV tests parsing by header and rejecting malformed responses
+func (suite *QuotesTestSuite) TestParseStooqCSV() {
+	quote, err := quotes.ParseStooqCSV(strings.NewReader("Symbol,Close\nMSFT.US,370.5\n"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 370.5, quote.Close)
+	assert.Zero(suite.T(), quote.Volume)
+
+	_, err = quotes.ParseStooqCSV(strings.NewReader("Symbol,Close\n"))
+	assert.Error(suite.T(), err)
+
+	_, err = quotes.ParseStooqCSV(strings.NewReader("Symbol,Close\nMSFT.US,abc\n"))
+	assert.Error(suite.T(), err)
+	assert.NotErrorIs(suite.T(), err, quotes.ErrSymbolNotFound)
+}
+
+// TestChainFailover tests that the chain falls back to the next provider
+func (suite *QuotesTestSuite) TestChainFailover() {
+	primary := quotes.NewFakeProvider("primary")
+	primary.Fail("aapl.us", errors.New("connection refused"))
+	secondary := quotes.NewFakeProvider("secondary")
+	secondary.Set(&quotes.Quote{Symbol: "AAPL.US", Close: 100})
+
+	quote, err := quotes.NewChain(primary, secondary).Quote(context.Background(), "aapl.us")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "secondary", quote.Provider)
+	assert.Equal(suite.T(), 1, primary.Calls("aapl.us"))
+}
+
+// TestChainNotFound tests that a symbol is only unknown when no provider has it
+func (suite *QuotesTestSuite) TestChainNotFound() {
+	primary := quotes.NewFakeProvider("primary")
+	secondary := quotes.NewFakeProvider("secondary")
+
+	_, err := quotes.NewChain(primary, secondary).Quote(context.Background(), "nope.us")
+	assert.ErrorIs(suite.T(), err, quotes.ErrSymbolNotFound)
+
+	secondary.Fail("nope.us", errors.New("timeout"))
+	_, err = quotes.NewChain(primary, secondary).Quote(context.Background(), "nope.us")
+	assert.Error(suite.T(), err)
+	assert.NotErrorIs(suite.T(), err, quotes.ErrSymbolNotFound)
+
+	_, err = quotes.NewChain().Quote(context.Background(), "nope.us")
+	assert.ErrorIs(suite.T(), err, quotes.ErrNoProviders)
+}
+
+// TestChainByName tests building the configured provider chain
+func (suite *QuotesTestSuite) TestChainByName() {
+	provider, err := quotes.NewChainByName([]string{"stooq"}, quotes.StooqOptions{BaseURL: suite.server.URL, Timeout: time.Second})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "stooq", provider.Name())
+
+	quote, err := provider.Quote(context.Background(), "aapl.us")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 181.18, quote.Close)
+
+	_, err = quotes.NewChainByName([]string{"stooq", "nasdaq"}, quotes.StooqOptions{})
+	assert.EqualError(suite.T(), err, `unknown quote provider "nasdaq"`)
+
+	_, err = quotes.NewChainByName(nil, quotes.StooqOptions{})
+	assert.ErrorIs(suite.T(), err, quotes.ErrNoProviders)
+}
+
+// TestStockBotReply tests the bot's answers against the stooq stand-in
+func (suite *QuotesTestSuite) TestStockBotReply() {
+	bot, err := service.NewStockBot(nil, suite.stooq, broker.ConsumerOptions{})
+	assert.NoError(suite.T(), err)
+
+	reply, err := bot.Reply(context.Background(), []string{"aapl.us"}, false)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "AAPL.US quote is $181.18 per share", reply)
+
+	reply, err = bot.Reply(context.Background(), []string{"nope.us"}, false)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Error fetching stock data for nope.us", reply)
+
+	suite.server.FailWith(http.StatusInternalServerError)
+	reply, err = bot.Reply(context.Background(), []string{"aapl.us"}, false)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "Error fetching stock data for aapl.us", reply)
+}
+
+// TestStockBotReplyBatch tests that a batch is answered one line per symbol in order
+func (suite *QuotesTestSuite) TestStockBotReplyBatch() {
+	suite.server.Set(&quotes.Quote{Symbol: "MSFT.US", Close: 370.5})
+	bot, _ := service.NewStockBot(nil, suite.stooq, broker.ConsumerOptions{})
+
+	reply, err := bot.Reply(context.Background(), []string{"msft.us", "nope.us", "aapl.us"}, false)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "MSFT.US quote is $370.50 per share\n"+
+		"Error fetching stock data for nope.us\n"+
+		"AAPL.US quote is $181.18 per share", reply)
+}
+
+// TestStockBotReplyDetail tests the full quote, leaving out fields without data
+func (suite *QuotesTestSuite) TestStockBotReplyDetail() {
+	suite.server.Set(&quotes.Quote{Symbol: "MSFT.US", Close: 370.5})
+	bot, _ := service.NewStockBot(nil, suite.stooq, broker.ConsumerOptions{})
+
+	reply, err := bot.Reply(context.Background(), []string{"aapl.us", "msft.us"}, true)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "AAPL.US quote is $181.18 per share (open $181.99, high $182.76, low $180.17, "+
+		"volume 62,303,300, change -$0.81 (-0.45%), as of 2024-01-05 22:00)\n"+
+		"MSFT.US quote is $370.50 per share (no further data)", reply)
+}
+
+// TestCachingProviderHits tests that quotes are served from the cache until they expire
+func (suite *QuotesTestSuite) TestCachingProviderHits() {
+	fake := quotes.NewFakeProvider("fake")
+	fake.Set(&quotes.Quote{Symbol: "AAPL.US", Close: 100})
+	cache := quotes.NewCachingProvider(fake, quotes.CacheOptions{TTL: 50 * time.Millisecond})
+
+	for i := 0; i < 3; i++ {
+		quote, err := cache.Quote(context.Background(), "AAPL.US")
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), 100.0, quote.Close)
+	}
+	assert.Equal(suite.T(), 1, fake.Calls("aapl.us"))
+
+	time.Sleep(60 * time.Millisecond)
+	_, err := cache.Quote(context.Background(), "aapl.us")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, fake.Calls("aapl.us"))
+
+	stats := cache.Stats()
+	assert.Equal(suite.T(), int64(2), stats.Hits)
+	assert.Equal(suite.T(), int64(2), stats.Misses)
+	assert.Equal(suite.T(), 1, stats.Entries)
+}
+
+// TestCachingProviderNegative tests that unknown symbols are cached but failures are not
+func (suite *QuotesTestSuite) TestCachingProviderNegative() {
+	fake := quotes.NewFakeProvider("fake")
+	fake.Fail("down.us", errors.New("connection refused"))
+	cache := quotes.NewCachingProvider(fake, quotes.CacheOptions{TTL: time.Minute, NegativeTTL: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		_, err := cache.Quote(context.Background(), "nope.us")
+		assert.ErrorIs(suite.T(), err, quotes.ErrSymbolNotFound)
+
+		_, err = cache.Quote(context.Background(), "down.us")
+		assert.Error(suite.T(), err)
+	}
+
+	assert.Equal(suite.T(), 1, fake.Calls("nope.us"))
+	assert.Equal(suite.T(), 2, fake.Calls("down.us"))
+	assert.Equal(suite.T(), int64(1), cache.Stats().NegativeHits)
+}
+
+// TestCachingProviderCoalesces tests that concurrent lookups share one upstream request
+func (suite *QuotesTestSuite) TestCachingProviderCoalesces() {
+	provider := &blockingProvider{release: make(chan struct{})}
+	cache := quotes.NewCachingProvider(provider, quotes.CacheOptions{TTL: time.Minute})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			quote, err := cache.Quote(context.Background(), "aapl.us")
+			assert.NoError(suite.T(), err)
+			assert.Equal(suite.T(), 42.0, quote.Close)
+		}()
+	}
+
+	// Let every lookup join the in-flight request before it completes
+	time.Sleep(50 * time.Millisecond)
+	close(provider.release)
+	wg.Wait()
+
+	stats := cache.Stats()
+	assert.Equal(suite.T(), int64(1), provider.calls.Load())
+	assert.Equal(suite.T(), int64(1), stats.Misses)
+	assert.Equal(suite.T(), int64(10), stats.Misses+stats.Coalesced+stats.Hits)
+}
+
+// TestCachingProviderCallerCancel tests that a cancelled caller does not fail a shared lookup
+func (suite *QuotesTestSuite) TestCachingProviderCallerCancel() {
+	provider := &blockingProvider{release: make(chan struct{})}
+	cache := quotes.NewCachingProvider(provider, quotes.CacheOptions{TTL: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := cache.Quote(ctx, "aapl.us")
+	assert.ErrorIs(suite.T(), err, context.DeadlineExceeded)
+
+	close(provider.release)
+	quote, err := cache.Quote(context.Background(), "aapl.us")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 42.0, quote.Close)
+	assert.Equal(suite.T(), int64(1), provider.calls.Load())
+}
+
+// blockingProvider answers every lookup once release is closed
+type blockingProvider struct {
+	release chan struct{}
+	calls   atomic.Int64
+}
+
+func (p *blockingProvider) Name() string {
+	return "blocking"
+}
+
+func (p *blockingProvider) Quote(ctx context.Context, symbol string) (*quotes.Quote, error) {
+	p.calls.Add(1)
+	select {
+	case <-p.release:
+		return &quotes.Quote{Symbol: strings.ToUpper(symbol), Close: 42}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// TestQuotesSuite runs the quotes test suite
+func TestQuotesSuite(t *testing.T) {
+	suite.Run(t, new(QuotesTestSuite))
+}
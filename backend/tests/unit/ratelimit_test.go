@@ -0,0 +1,196 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/commands"
+	"jobsity-backend/internal/handlers"
+	"jobsity-backend/internal/middleware"
+	"jobsity-backend/internal/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// failingStore is a rate limit store that is always unavailable
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Decision, error) {
+	return ratelimit.Decision{}, errors.New("store unavailable")
+}
+
+// RateLimitTestSuite contains the test suite for per-user rate limits
+type RateLimitTestSuite struct {
+	suite.Suite
+	store *ratelimit.MemoryStore
+}
+
+// SetupTest creates an empty store before each test
+func (suite *RateLimitTestSuite) SetupTest() {
+	suite.store = ratelimit.NewMemoryStore()
+}
+
+// TestParseLimit tests parsing configured limits
+func (suite *RateLimitTestSuite) TestParseLimit() {
+	limit, err := ratelimit.ParseLimit("30/1m")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), ratelimit.Limit{Burst: 30, Per: time.Minute}, limit)
+	assert.Equal(suite.T(), "30/1m0s", limit.String())
+
+	limit, err = ratelimit.ParseLimit("off")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), limit.Unlimited())
+
+	for _, text := range []string{"30", "x/1m", "-1/1m", "30/soon", "30/0s"} {
+		_, err = ratelimit.ParseLimit(text)
+		assert.Error(suite.T(), err, text)
+	}
+}
+
+// TestMemoryStoreBucket tests that a bucket allows a burst and then refills over time
+func (suite *RateLimitTestSuite) TestMemoryStoreBucket() {
+	limit := ratelimit.Limit{Burst: 3, Per: 30 * time.Second}
+	now := time.Date(2024, 1, 5, 12, 0, 0, 0, time.UTC)
+
+	for i := 2; i >= 0; i-- {
+		decision, err := suite.store.Take(context.Background(), "post:user@example.com", limit, now)
+		assert.NoError(suite.T(), err)
+		assert.True(suite.T(), decision.Allowed)
+		assert.Equal(suite.T(), i, decision.Remaining)
+	}
+
+	decision, _ := suite.store.Take(context.Background(), "post:user@example.com", limit, now)
+	assert.False(suite.T(), decision.Allowed)
+	assert.Equal(suite.T(), 10*time.Second, decision.RetryAfter)
+
+	// Other users and actions have their own buckets
+	decision, _ = suite.store.Take(context.Background(), "post:other@example.com", limit, now)
+	assert.True(suite.T(), decision.Allowed)
+
+	// One token is back after a third of the period
+	decision, _ = suite.store.Take(context.Background(), "post:user@example.com", limit, now.Add(4*time.Second))
+	assert.False(suite.T(), decision.Allowed)
+	assert.Equal(suite.T(), 6*time.Second, decision.RetryAfter)
+	assert.Equal(suite.T(), 6, decision.RetryAfterSeconds())
+
+	decision, _ = suite.store.Take(context.Background(), "post:user@example.com", limit, now.Add(10*time.Second))
+	assert.True(suite.T(), decision.Allowed)
+	assert.Equal(suite.T(), 0, decision.Remaining)
+}
+
+// TestLimiterActions tests that each action has its own limit and unlimited actions always pass
+func (suite *RateLimitTestSuite) TestLimiterActions() {
+	limiter := ratelimit.NewLimiter(suite.store, map[ratelimit.Action]ratelimit.Limit{
+		ratelimit.ActionCommand: {Burst: 1, Per: time.Minute},
+	})
+
+	assert.True(suite.T(), limiter.Allow(context.Background(), ratelimit.ActionCommand, "user@example.com").Allowed)
+	assert.False(suite.T(), limiter.Allow(context.Background(), ratelimit.ActionCommand, "user@example.com").Allowed)
+	for i := 0; i < 5; i++ {
+		assert.True(suite.T(), limiter.Allow(context.Background(), ratelimit.ActionPost, "user@example.com").Allowed)
+	}
+
+	// A failing store does not lock users out
+	limiter = ratelimit.NewLimiter(failingStore{}, map[ratelimit.Action]ratelimit.Limit{
+		ratelimit.ActionCommand: {Burst: 1, Per: time.Minute},
+	})
+	assert.True(suite.T(), limiter.Allow(context.Background(), ratelimit.ActionCommand, "user@example.com").Allowed)
+}
+
+// TestMiddlewareRejects tests the 429 response and Retry-After header
+func (suite *RateLimitTestSuite) TestMiddlewareRejects() {
+	limiter := ratelimit.NewLimiter(suite.store, map[ratelimit.Action]ratelimit.Limit{
+		ratelimit.ActionLogin: {Burst: 2, Per: time.Minute},
+	})
+	app := fiber.New()
+	app.Post("/login", middleware.RateLimitMiddleware(limiter, ratelimit.ActionLogin), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := app.Test(httptest.NewRequest("POST", "/login", nil))
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), fiber.StatusOK, resp.StatusCode)
+	}
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/login", nil))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(suite.T(), "30", resp.Header.Get("Retry-After"))
+}
+
+// TestLoginMiddlewareKeys tests that login attempts are limited per email
+// and client IP, taking the IP from a trusted proxy's header
+func (suite *RateLimitTestSuite) TestLoginMiddlewareKeys() {
+	limiter := ratelimit.NewLimiter(suite.store, map[ratelimit.Action]ratelimit.Limit{
+		ratelimit.ActionLogin: {Burst: 1, Per: time.Minute},
+	})
+	app := fiber.New(fiber.Config{
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          []string{"0.0.0.0"},
+		EnableIPValidation:      true,
+	})
+	app.Post("/login", middleware.LoginRateLimitMiddleware(limiter), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	login := func(email, clientIP string) int {
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"email":"`+email+`","password":"secret"}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set(fiber.HeaderXForwardedFor, clientIP)
+		resp, err := app.Test(req)
+		suite.Require().NoError(err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(suite.T(), fiber.StatusOK, login("user1@jobsity.com", "203.0.113.1"))
+	assert.Equal(suite.T(), fiber.StatusTooManyRequests, login("User1@jobsity.com ", "203.0.113.1"))
+
+	// Other users behind the same address, and the same user elsewhere,
+	// have their own attempts
+	assert.Equal(suite.T(), fiber.StatusOK, login("user2@jobsity.com", "203.0.113.1"))
+	assert.Equal(suite.T(), fiber.StatusOK, login("user1@jobsity.com", "198.51.100.7, 203.0.113.1"))
+}
+
+// TestMessageHandlerLimits tests that posting and commands are limited separately per user
+func (suite *RateLimitTestSuite) TestMessageHandlerLimits() {
+	limiter := ratelimit.NewLimiter(suite.store, map[ratelimit.Action]ratelimit.Limit{
+		ratelimit.ActionCommand: {Burst: 1, Per: time.Minute},
+	})
+	var sent []string
+	send := func(channelID, userEmail, messageType string, data interface{}) int {
+		sent = append(sent, userEmail)
+		return 1
+	}
+	messageHandler := handlers.NewMessageHandler(nil, commands.NewRegistry(), send, limiter)
+
+	app := fiber.New()
+	app.Post("/messages", middleware.AuthMiddleware(), messageHandler.CreateMessage)
+	post := func(userEmail string) int {
+		req := httptest.NewRequest("POST", "/messages", strings.NewReader(`{"channel_id":"channel-1","content":"/help"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Email", userEmail)
+		resp, err := app.Test(req)
+		suite.Require().NoError(err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(suite.T(), fiber.StatusOK, post("user@example.com"))
+	assert.Equal(suite.T(), fiber.StatusTooManyRequests, post("user@example.com"))
+	assert.Equal(suite.T(), fiber.StatusOK, post("other@example.com"))
+
+	// The limited user is told privately as well
+	assert.Equal(suite.T(), []string{"user@example.com", "user@example.com", "other@example.com"}, sent)
+}
+
+// TestRateLimitSuite runs the rate limit test suite
+func TestRateLimitSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitTestSuite))
+}
@@ -11,7 +11,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
 )
 
@@ -71,7 +70,7 @@ func (suite *RepositoryTestSuite) TestMongoUserRepository_FindByEmail() {
 		// Assert
 		assert.Error(suite.T(), err)
 		assert.Nil(suite.T(), user)
-		assert.Equal(suite.T(), mongo.ErrNoDocuments, err)
+		assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
 	})
 }
 
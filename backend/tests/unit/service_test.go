@@ -10,7 +10,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // MockUserRepository is a mock implementation of UserRepository
@@ -126,7 +125,7 @@ func (suite *ServiceTestSuite) TestLoginUserNotFound() {
 	email := "nonexistent@example.com"
 	password := "testpass"
 
-	suite.mockRepo.On("FindByEmail", mock.Anything, email).Return(nil, mongo.ErrNoDocuments)
+	suite.mockRepo.On("FindByEmail", mock.Anything, email).Return(nil, domain.ErrNotFound)
 
 	// Act
 	req := &domain.LoginRequest{
@@ -166,7 +165,7 @@ func (suite *ServiceTestSuite) TestCreateUserSuccess() {
 		Password: "newpass",
 	}
 
-	suite.mockRepo.On("FindByEmail", mock.Anything, req.Email).Return(nil, mongo.ErrNoDocuments)
+	suite.mockRepo.On("FindByEmail", mock.Anything, req.Email).Return(nil, domain.ErrNotFound)
 	suite.mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil)
 
 	// Act
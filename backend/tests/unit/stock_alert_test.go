@@ -0,0 +1,242 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/commands"
+	"jobsity-backend/internal/quotes"
+	"jobsity-backend/internal/service"
+	"jobsity-backend/pkg/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// MockStockAlertRepository is a mock implementation of StockAlertRepository
+type MockStockAlertRepository struct {
+	mock.Mock
+}
+
+func (m *MockStockAlertRepository) Create(ctx context.Context, alert *domain.StockAlert) error {
+	args := m.Called(ctx, alert)
+	return args.Error(0)
+}
+
+func (m *MockStockAlertRepository) FindByID(ctx context.Context, id string) (*domain.StockAlert, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.StockAlert), args.Error(1)
+}
+
+func (m *MockStockAlertRepository) FindActive(ctx context.Context) ([]*domain.StockAlert, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*domain.StockAlert), args.Error(1)
+}
+
+func (m *MockStockAlertRepository) FindActiveByUser(ctx context.Context, channelID, userEmail string) ([]*domain.StockAlert, error) {
+	args := m.Called(ctx, channelID, userEmail)
+	return args.Get(0).([]*domain.StockAlert), args.Error(1)
+}
+
+func (m *MockStockAlertRepository) CountActiveByUser(ctx context.Context, userEmail string) (int64, error) {
+	args := m.Called(ctx, userEmail)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStockAlertRepository) MarkTriggered(ctx context.Context, id string, triggeredAt time.Time) (bool, error) {
+	args := m.Called(ctx, id, triggeredAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockStockAlertRepository) Rearm(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockStockAlertRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// StockAlertTestSuite contains the test suite for stock price alerts
+type StockAlertTestSuite struct {
+	suite.Suite
+	mockRepo  *MockStockAlertRepository
+	registry  *commands.Registry
+	provider  *quotes.FakeProvider
+	publisher *fakePublisher
+	scheduler *service.StockAlertScheduler
+}
+
+// SetupTest sets up the alert commands and scheduler before each test
+func (suite *StockAlertTestSuite) SetupTest() {
+	suite.mockRepo = new(MockStockAlertRepository)
+	alertService := service.NewStockAlertService(suite.mockRepo, 2)
+	suite.registry = commands.NewRegistry()
+	suite.registry.MustRegister(service.NewAlertCommand(alertService))
+	suite.registry.MustRegister(service.NewAlertsCommand(alertService))
+
+	suite.provider = quotes.NewFakeProvider("fake")
+	suite.publisher = &fakePublisher{}
+	suite.scheduler = service.NewStockAlertScheduler(suite.mockRepo, suite.provider, suite.publisher, time.Minute)
+}
+
+// TestCreateAlert tests that /alert stores a rule for the user and channel
+func (suite *StockAlertTestSuite) TestCreateAlert() {
+	suite.mockRepo.On("CountActiveByUser", mock.Anything, "user@example.com").Return(int64(0), nil)
+	suite.mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(alert *domain.StockAlert) bool {
+		return alert.ChannelID == "channel-1" && alert.UserEmail == "user@example.com" &&
+			alert.StockCode == "aapl.us" && alert.Operator == domain.AlertAbove && alert.Threshold == 200
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.StockAlert).ID = "507f1f77bcf86cd799439011"
+	}).Return(nil)
+
+	result, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/alert AAPL.US > 200")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Alert 507f1f77bcf86cd799439011 set: AAPL.US > $200.00", result.Message)
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+// TestCreateAlertCompactCondition tests conditions written without spaces
+func (suite *StockAlertTestSuite) TestCreateAlertCompactCondition() {
+	suite.mockRepo.On("CountActiveByUser", mock.Anything, "user@example.com").Return(int64(0), nil)
+	suite.mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(alert *domain.StockAlert) bool {
+		return alert.Operator == domain.AlertBelow && alert.Threshold == 150.5
+	})).Return(nil)
+
+	_, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/alert aapl.us <$150.5")
+
+	assert.NoError(suite.T(), err)
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+// TestCreateAlertInvalidCondition tests that malformed conditions are usage errors
+func (suite *StockAlertTestSuite) TestCreateAlertInvalidCondition() {
+	for _, content := range []string{"/alert aapl.us = 200", "/alert aapl.us > abc", "/alert aapl.us > -5", "/alert aapl.us"} {
+		_, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", content)
+
+		var usageErr *commands.UsageError
+		assert.ErrorAs(suite.T(), err, &usageErr, content)
+	}
+	suite.mockRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+}
+
+// TestCreateAlertLimit tests that users cannot exceed their alert limit
+func (suite *StockAlertTestSuite) TestCreateAlertLimit() {
+	suite.mockRepo.On("CountActiveByUser", mock.Anything, "user@example.com").Return(int64(2), nil)
+
+	result, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/alert aapl.us > 200")
+
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), result.Message, "maximum number of active alerts")
+	suite.mockRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+}
+
+// TestListAlerts tests that /alerts lists the caller's alerts in the channel
+func (suite *StockAlertTestSuite) TestListAlerts() {
+	suite.mockRepo.On("FindActiveByUser", mock.Anything, "channel-1", "user@example.com").Return([]*domain.StockAlert{
+		{ID: "a1", StockCode: "aapl.us", Operator: domain.AlertAbove, Threshold: 200},
+		{ID: "a2", StockCode: "msft.us", Operator: domain.AlertBelow, Threshold: 150},
+	}, nil).Once()
+	suite.mockRepo.On("FindActiveByUser", mock.Anything, "channel-1", "user@example.com").Return([]*domain.StockAlert{}, nil)
+
+	result, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/alerts")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "a1: AAPL.US > $200.00\na2: MSFT.US < $150.00", result.Message)
+
+	result, err = suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/alerts")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "You have no active alerts in this channel", result.Message)
+}
+
+// TestRemoveAlert tests that users can only remove their own alerts
+func (suite *StockAlertTestSuite) TestRemoveAlert() {
+	own := "507f1f77bcf86cd799439011"
+	other := "507f1f77bcf86cd799439012"
+	missing := "507f1f77bcf86cd799439013"
+	suite.mockRepo.On("FindByID", mock.Anything, own).Return(&domain.StockAlert{ID: own, UserEmail: "user@example.com"}, nil)
+	suite.mockRepo.On("FindByID", mock.Anything, other).Return(&domain.StockAlert{ID: other, UserEmail: "other@example.com"}, nil)
+	suite.mockRepo.On("FindByID", mock.Anything, missing).Return(nil, domain.ErrNotFound)
+	suite.mockRepo.On("FindByID", mock.Anything, "not-an-id").Return(nil, domain.ErrInvalidID)
+	suite.mockRepo.On("Delete", mock.Anything, own).Return(nil)
+
+	result, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/alert remove "+own)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Alert "+own+" removed", result.Message)
+
+	for _, id := range []string{other, missing, "not-an-id"} {
+		result, err = suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/alert remove "+id)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), "You have no alert with id "+id, result.Message)
+	}
+	suite.mockRepo.AssertNumberOfCalls(suite.T(), "Delete", 1)
+}
+
+// TestCheckAlertsFiresCrossed tests that only crossed alerts fire, each with one lookup per symbol
+func (suite *StockAlertTestSuite) TestCheckAlertsFiresCrossed() {
+	suite.provider.Set(&quotes.Quote{Symbol: "AAPL.US", Close: 210})
+	suite.mockRepo.On("FindActive", mock.Anything).Return([]*domain.StockAlert{
+		{ID: "a1", ChannelID: "channel-1", UserEmail: "user@example.com", StockCode: "aapl.us", Operator: domain.AlertAbove, Threshold: 200},
+		{ID: "a2", ChannelID: "channel-1", UserEmail: "user@example.com", StockCode: "aapl.us", Operator: domain.AlertBelow, Threshold: 150},
+		{ID: "a3", ChannelID: "channel-2", UserEmail: "other@example.com", StockCode: "nope.us", Operator: domain.AlertAbove, Threshold: 1},
+	}, nil)
+	suite.mockRepo.On("MarkTriggered", mock.Anything, "a1", mock.Anything).Return(true, nil)
+
+	fired, err := suite.scheduler.CheckAlerts(context.Background())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, fired)
+	assert.Equal(suite.T(), 1, suite.provider.Calls("aapl.us"))
+	assert.Len(suite.T(), suite.publisher.published, 1)
+	assert.Equal(suite.T(), "stock_responses", suite.publisher.published[0].key)
+
+	response, err := service.DecodeStockResponse(suite.publisher.published[0].msg.Body)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "channel-1", response.ChannelID)
+	assert.Equal(suite.T(), "Alert for user@example.com: AAPL.US is now $210.00 (alert AAPL.US > $200.00)", response.Message)
+	suite.mockRepo.AssertNotCalled(suite.T(), "MarkTriggered", mock.Anything, "a2", mock.Anything)
+}
+
+// TestCheckAlertsFiresOnce tests that an alert already fired elsewhere is not posted again
+func (suite *StockAlertTestSuite) TestCheckAlertsFiresOnce() {
+	suite.provider.Set(&quotes.Quote{Symbol: "AAPL.US", Close: 210})
+	suite.mockRepo.On("FindActive", mock.Anything).Return([]*domain.StockAlert{
+		{ID: "a1", ChannelID: "channel-1", StockCode: "aapl.us", Operator: domain.AlertAbove, Threshold: 200},
+	}, nil)
+	suite.mockRepo.On("MarkTriggered", mock.Anything, "a1", mock.Anything).Return(false, nil)
+
+	fired, err := suite.scheduler.CheckAlerts(context.Background())
+
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), fired)
+	assert.Empty(suite.T(), suite.publisher.published)
+}
+
+// TestCheckAlertsRearmsOnPublishFailure tests that alerts are not lost when the broker is down
+func (suite *StockAlertTestSuite) TestCheckAlertsRearmsOnPublishFailure() {
+	suite.provider.Set(&quotes.Quote{Symbol: "AAPL.US", Close: 210})
+	suite.publisher.err = errors.New("broker down")
+	suite.mockRepo.On("FindActive", mock.Anything).Return([]*domain.StockAlert{
+		{ID: "a1", ChannelID: "channel-1", StockCode: "aapl.us", Operator: domain.AlertAbove, Threshold: 200},
+	}, nil)
+	suite.mockRepo.On("MarkTriggered", mock.Anything, "a1", mock.Anything).Return(true, nil)
+	suite.mockRepo.On("Rearm", mock.Anything, "a1").Return(nil)
+
+	fired, err := suite.scheduler.CheckAlerts(context.Background())
+
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), fired)
+	suite.mockRepo.AssertCalled(suite.T(), "Rearm", mock.Anything, "a1")
+}
+
+// TestStockAlertSuite runs the stock alert test suite
+func TestStockAlertSuite(t *testing.T) {
+	suite.Run(t, new(StockAlertTestSuite))
+}
@@ -0,0 +1,150 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/quotes"
+	"jobsity-backend/internal/service"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// failingQuoteProvider fails every lookup
+type failingQuoteProvider struct{}
+
+func (failingQuoteProvider) Name() string { return "failing" }
+
+func (failingQuoteProvider) Quote(ctx context.Context, symbol string) (*quotes.Quote, error) {
+	return nil, errors.New("provider unavailable")
+}
+
+// knownQuoteProvider quotes the symbols in its map and knows no others
+type knownQuoteProvider map[string]float64
+
+func (knownQuoteProvider) Name() string { return "known" }
+
+func (p knownQuoteProvider) Quote(ctx context.Context, symbol string) (*quotes.Quote, error) {
+	price, ok := p[symbol]
+	if !ok {
+		return nil, quotes.ErrSymbolNotFound
+	}
+	return &quotes.Quote{Symbol: symbol, Close: price}, nil
+}
+
+// StockBotTestSuite contains the test suite for the stock bot
+type StockBotTestSuite struct {
+	suite.Suite
+	broker *broker.MemoryBroker
+	bot    *service.StockBot
+}
+
+// SetupTest starts a bot whose lookups always fail before each test
+func (suite *StockBotTestSuite) SetupTest() {
+	suite.broker = broker.NewMemoryBroker()
+
+	var err error
+	suite.bot, err = service.NewStockBot(suite.broker, failingQuoteProvider{}, broker.ConsumerOptions{
+		Prefetch: 1,
+		Retry:    broker.RetryPolicy{MaxRetries: 1, BaseDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond},
+	})
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.bot.Start())
+}
+
+// TearDownTest stops the bot and closes the broker after each test
+func (suite *StockBotTestSuite) TearDownTest() {
+	suite.bot.Close()
+	suite.broker.Close()
+}
+
+// TestAnsweredFailuresAreNotDeadLettered tests that a command whose lookup
+// keeps failing is answered with an error once retries run out, and is then
+// done rather than dead-lettered
+func (suite *StockBotTestSuite) TestAnsweredFailuresAreNotDeadLettered() {
+	responses := make(chan amqp.Delivery, 2)
+	consumer := suite.broker.Consume("stock_responses", 1, func(msg amqp.Delivery) {
+		responses <- msg
+		msg.Ack(false)
+	})
+	defer consumer.Cancel()
+
+	command, err := service.NewStockCommandMessage("channel-1", "user@example.com", "AAPL.US")
+	suite.Require().NoError(err)
+	publishing, err := command.Publishing()
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.broker.Publish("", "stock_commands", false, false, publishing))
+
+	select {
+	case msg := <-responses:
+		response, err := service.DecodeStockResponse(msg.Body)
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), command.CorrelationID, response.CorrelationID)
+		assert.True(suite.T(), response.Ephemeral)
+	case <-time.After(time.Second):
+		suite.FailNow("no response published")
+	}
+
+	suite.Require().Eventually(func() bool {
+		return suite.broker.Len("stock_commands") == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.Never(suite.T(), func() bool {
+		return suite.broker.Len(broker.DeadLetterQueueName("stock_commands")) > 0
+	}, 50*time.Millisecond, 5*time.Millisecond)
+	assert.Empty(suite.T(), responses)
+}
+
+// TestMixedBatchSplitsErrors tests that the quotes of a batch are posted to
+// the channel while its error lines go to the requester only
+func (suite *StockBotTestSuite) TestMixedBatchSplitsErrors() {
+	bot, err := service.NewStockBot(suite.broker, knownQuoteProvider{"AAPL.US": 181.18}, broker.ConsumerOptions{Prefetch: 1})
+	suite.Require().NoError(err)
+	suite.bot.Close()
+	suite.Require().NoError(bot.Start())
+	defer bot.Close()
+
+	responses := make(chan amqp.Delivery, 3)
+	consumer := suite.broker.Consume("stock_responses", 1, func(msg amqp.Delivery) {
+		responses <- msg
+		msg.Ack(false)
+	})
+	defer consumer.Cancel()
+
+	command, err := service.NewStockCommandMessage("channel-1", "user@example.com", "AAPL.US", "BAD.US")
+	suite.Require().NoError(err)
+	publishing, err := command.Publishing()
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.broker.Publish("", "stock_commands", false, false, publishing))
+
+	var public, ephemeral []*service.StockResponse
+	for len(public)+len(ephemeral) < 2 {
+		select {
+		case msg := <-responses:
+			response, err := service.DecodeStockResponse(msg.Body)
+			suite.Require().NoError(err)
+			if response.Ephemeral {
+				ephemeral = append(ephemeral, response)
+			} else {
+				public = append(public, response)
+			}
+		case <-time.After(time.Second):
+			suite.FailNow("no response published")
+		}
+	}
+
+	suite.Require().Len(public, 1)
+	assert.Equal(suite.T(), "AAPL.US quote is $181.18 per share", public[0].Message)
+	suite.Require().Len(ephemeral, 1)
+	assert.Equal(suite.T(), "Error fetching stock data for BAD.US", ephemeral[0].Message)
+	assert.Equal(suite.T(), "user@example.com", ephemeral[0].Requester)
+	assert.Equal(suite.T(), command.CorrelationID, ephemeral[0].CorrelationID)
+}
+
+func TestStockBotSuite(t *testing.T) {
+	suite.Run(t, new(StockBotTestSuite))
+}
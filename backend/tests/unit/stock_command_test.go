@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/commands"
+	"jobsity-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// StockCommandTestSuite contains the test suite for the /stock command
+type StockCommandTestSuite struct {
+	suite.Suite
+	registry  *commands.Registry
+	publisher *service.InMemoryCommandPublisher
+}
+
+// SetupTest sets up a registry with /stock publishing in memory before each test
+func (suite *StockCommandTestSuite) SetupTest() {
+	suite.publisher = service.NewInMemoryCommandPublisher("stock_commands")
+	suite.registry = commands.NewRegistry()
+	suite.registry.MustRegister(service.NewStockCommand(suite.publisher))
+	suite.registry.MustRegister(service.NewQuoteCommand(suite.publisher))
+}
+
+// TestStockCommand tests that /stock enqueues a JSON stock command
+func (suite *StockCommandTestSuite) TestStockCommand() {
+	result, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/stock=aapl.us")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Stock command processed", result.Message)
+	assert.True(suite.T(), result.Async)
+
+	published := suite.publisher.Published("stock_commands")
+	assert.Len(suite.T(), published, 1)
+
+	command, err := service.DecodeStockCommand(published[0].Body)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "channel-1", command.ChannelID)
+	assert.Equal(suite.T(), "user@example.com", command.Requester)
+	assert.Equal(suite.T(), "aapl.us", command.StockCode)
+	assert.Equal(suite.T(), command.CorrelationID, published[0].CorrelationId)
+}
+
+// TestStockCommandBatch tests that /stock accepts several distinct codes and the detail flag
+func (suite *StockCommandTestSuite) TestStockCommandBatch() {
+	_, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/stock=aapl.us,MSFT.US, aapl.us --detail")
+	assert.NoError(suite.T(), err)
+
+	published := suite.publisher.Published("stock_commands")
+	assert.Len(suite.T(), published, 1)
+
+	command, err := service.DecodeStockCommand(published[0].Body)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "aapl.us", command.StockCode)
+	assert.Equal(suite.T(), []string{"aapl.us", "msft.us"}, command.Symbols())
+	assert.True(suite.T(), command.Detail)
+}
+
+// TestQuoteCommand tests that /quote always asks for the full quote
+func (suite *StockCommandTestSuite) TestQuoteCommand() {
+	_, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/quote aapl.us")
+	assert.NoError(suite.T(), err)
+
+	command, err := service.DecodeStockCommand(suite.publisher.Published("stock_commands")[0].Body)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"aapl.us"}, command.Symbols())
+	assert.Empty(suite.T(), command.StockCodes)
+	assert.True(suite.T(), command.Detail)
+}
+
+// TestStockCommandBatchLimit tests that oversized batches are rejected
+func (suite *StockCommandTestSuite) TestStockCommandBatchLimit() {
+	_, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/stock=a,b,c,d,e,f,g,h,i,j,k")
+
+	var usageErr *commands.UsageError
+	assert.ErrorAs(suite.T(), err, &usageErr)
+	assert.Empty(suite.T(), suite.publisher.Published("stock_commands"))
+}
+
+// TestStockCommandRequiresCode tests that /stock without a code is rejected
+func (suite *StockCommandTestSuite) TestStockCommandRequiresCode() {
+	_, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/stock=")
+	var usageErr *commands.UsageError
+	assert.ErrorAs(suite.T(), err, &usageErr)
+
+	_, err = suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/stock=, --detail")
+	usageErr = nil
+	assert.ErrorAs(suite.T(), err, &usageErr)
+	assert.Empty(suite.T(), suite.publisher.Published("stock_commands"))
+}
+
+// TestStockCommandNotEnqueued tests that publish failures are reported to the user
+func (suite *StockCommandTestSuite) TestStockCommandNotEnqueued() {
+	registry := commands.NewRegistry()
+	registry.MustRegister(service.NewStockCommand(service.NewInMemoryCommandPublisher()))
+
+	result, err := registry.Execute(context.Background(), "channel-1", "user@example.com", "/stock=aapl.us")
+
+	assert.Nil(suite.T(), result)
+	assert.ErrorIs(suite.T(), err, commands.ErrNotEnqueued)
+	assert.ErrorIs(suite.T(), err, broker.ErrUnroutable)
+	assert.Equal(suite.T(), commands.ErrNotEnqueued.Error(), commands.ErrorMessage(err))
+}
+
+// TestStockCommandSuite runs the stock command test suite
+func TestStockCommandSuite(t *testing.T) {
+	suite.Run(t, new(StockCommandTestSuite))
+}
@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"testing"
+
+	"jobsity-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// StockMessageTestSuite contains the test suite for the stock queue envelopes
+type StockMessageTestSuite struct {
+	suite.Suite
+}
+
+// TestCommandRoundTrip tests encoding and decoding a JSON stock command
+func (suite *StockMessageTestSuite) TestCommandRoundTrip() {
+	command, err := service.NewStockCommandMessage("channel|1", "user|x@example.com", "aapl.us")
+	assert.NoError(suite.T(), err)
+
+	publishing, err := command.Publishing()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "application/json", publishing.ContentType)
+	assert.Equal(suite.T(), command.CorrelationID, publishing.CorrelationId)
+
+	decoded, err := service.DecodeStockCommand(publishing.Body)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), service.StockSchemaVersion, decoded.SchemaVersion)
+	assert.Equal(suite.T(), command.CorrelationID, decoded.CorrelationID)
+	assert.Equal(suite.T(), "channel|1", decoded.ChannelID)
+	assert.Equal(suite.T(), "user|x@example.com", decoded.Requester)
+	assert.Equal(suite.T(), "aapl.us", decoded.StockCode)
+	assert.True(suite.T(), command.CreatedAt.Equal(decoded.CreatedAt))
+}
+
+// TestResponseRoundTrip tests that responses carry the command's correlation id
+func (suite *StockMessageTestSuite) TestResponseRoundTrip() {
+	command, err := service.NewStockCommandMessage("channel-1", "user@example.com", "aapl.us")
+	assert.NoError(suite.T(), err)
+
+	publishing, err := service.NewStockResponseMessage(command, "AAPL.US quote is $1.00 | per share").Publishing()
+	assert.NoError(suite.T(), err)
+
+	decoded, err := service.DecodeStockResponse(publishing.Body)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), command.CorrelationID, decoded.CorrelationID)
+	assert.Equal(suite.T(), "channel-1", decoded.ChannelID)
+	assert.Equal(suite.T(), "user@example.com", decoded.Requester)
+	assert.Equal(suite.T(), "stock_bot", decoded.Author)
+	assert.Equal(suite.T(), "AAPL.US quote is $1.00 | per share", decoded.Message)
+	assert.True(suite.T(), command.CreatedAt.Equal(decoded.RequestedAt))
+}
+
+// TestDecodeLegacyFormat tests the pipe-separated compatibility reader
+func (suite *StockMessageTestSuite) TestDecodeLegacyFormat() {
+	command, err := service.DecodeStockCommand([]byte("channel-1|user@example.com|aapl.us"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "channel-1", command.ChannelID)
+	assert.Equal(suite.T(), "user@example.com", command.Requester)
+	assert.Equal(suite.T(), "aapl.us", command.StockCode)
+	assert.NotEmpty(suite.T(), command.CorrelationID)
+
+	response, err := service.DecodeStockResponse([]byte("channel-1|user@example.com|stock_bot|a | b"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "stock_bot", response.Author)
+	assert.Equal(suite.T(), "a | b", response.Message)
+
+	_, err = service.DecodeStockCommand([]byte("channel-1|aapl.us"))
+	assert.ErrorIs(suite.T(), err, service.ErrInvalidStockMessage)
+}
+
+// TestDecodeLegacyCorrelationID tests that a redelivered pipe-separated
+// message decodes with the same correlation id
+func (suite *StockMessageTestSuite) TestDecodeLegacyCorrelationID() {
+	body := []byte("channel-1|user@example.com|stock_bot|AAPL.US quote is $181.18 per share")
+	first, err := service.DecodeStockResponse(body)
+	suite.Require().NoError(err)
+	again, err := service.DecodeStockResponse(body)
+	suite.Require().NoError(err)
+	assert.NotEmpty(suite.T(), first.CorrelationID)
+	assert.Equal(suite.T(), first.CorrelationID, again.CorrelationID)
+
+	other, err := service.DecodeStockResponse([]byte("channel-1|user@example.com|stock_bot|MSFT.US quote is $370.50 per share"))
+	suite.Require().NoError(err)
+	assert.NotEqual(suite.T(), first.CorrelationID, other.CorrelationID)
+
+	command, err := service.DecodeStockCommand([]byte("channel-1|user@example.com|aapl.us"))
+	suite.Require().NoError(err)
+	commandAgain, err := service.DecodeStockCommand([]byte("channel-1|user@example.com|aapl.us"))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), command.CorrelationID, commandAgain.CorrelationID)
+}
+
+// TestDecodeRejectsInvalidEnvelopes tests schema version and type checks
+func (suite *StockMessageTestSuite) TestDecodeRejectsInvalidEnvelopes() {
+	_, err := service.DecodeStockCommand([]byte(`{"schema_version":2,"channel_id":"c","stock_code":"s"}`))
+	assert.ErrorIs(suite.T(), err, service.ErrUnsupportedSchemaVersion)
+
+	_, err = service.DecodeStockCommand([]byte(`{"schema_version":1,"type":"stock_response","channel_id":"c","stock_code":"s"}`))
+	assert.ErrorIs(suite.T(), err, service.ErrInvalidStockMessage)
+
+	_, err = service.DecodeStockCommand([]byte(`{"schema_version":1,"channel_id":"c"}`))
+	assert.ErrorIs(suite.T(), err, service.ErrInvalidStockMessage)
+
+	_, err = service.DecodeStockResponse([]byte(`{"schema_version":1`))
+	assert.ErrorIs(suite.T(), err, service.ErrInvalidStockMessage)
+}
+
+// TestStockMessageSuite runs the stock message test suite
+func TestStockMessageSuite(t *testing.T) {
+	suite.Run(t, new(StockMessageTestSuite))
+}
@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/internal/service"
+	"jobsity-backend/internal/storage"
+	"jobsity-backend/pkg/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// flakyBlobStore is a blob store whose reads fail until failures runs out
+type flakyBlobStore struct {
+	storage.BlobStore
+	mutex    sync.Mutex
+	failures int
+}
+
+func (s *flakyBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return nil, errors.New("storage unavailable")
+	}
+	return s.BlobStore.Get(ctx, key)
+}
+
+// ThumbnailWorkerTestSuite contains the test suite for the thumbnail worker
+type ThumbnailWorkerTestSuite struct {
+	suite.Suite
+	ctx         context.Context
+	queue       *broker.MemoryBroker
+	attachments *repository.MemoryAttachmentRepository
+	blobStore   *flakyBlobStore
+	worker      *service.ThumbnailWorker
+}
+
+// SetupTest starts a worker over in-memory repositories and a local blob
+// store before each test
+func (suite *ThumbnailWorkerTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.queue = broker.NewMemoryBroker()
+	suite.attachments = repository.NewMemoryAttachmentRepository()
+
+	local, err := storage.NewLocalBlobStore(suite.T().TempDir())
+	suite.Require().NoError(err)
+	suite.blobStore = &flakyBlobStore{BlobStore: local}
+
+	policy := broker.RetryPolicy{MaxRetries: 2, BaseDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	suite.worker, err = service.NewThumbnailWorker(suite.queue, suite.attachments, repository.NewMemoryMessageRepository(), suite.blobStore, nil, policy)
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.worker.Start())
+}
+
+// TearDownTest stops the worker and closes the broker after each test
+func (suite *ThumbnailWorkerTestSuite) TearDownTest() {
+	suite.worker.Close()
+	suite.queue.Close()
+}
+
+// upload stores data as an attachment and queues it for processing
+func (suite *ThumbnailWorkerTestSuite) upload(contentType string, data []byte) *domain.Attachment {
+	attachment := &domain.Attachment{ChannelID: "channel-1", UploadedBy: "user1@jobsity.com", FileName: "photo", ContentType: contentType, Size: int64(len(data)), StorageKey: "uploads/photo"}
+	suite.Require().NoError(suite.attachments.Create(suite.ctx, attachment))
+	suite.Require().NoError(suite.blobStore.Put(suite.ctx, attachment.StorageKey, bytes.NewReader(data), int64(len(data)), contentType))
+	suite.Require().NoError(suite.worker.Enqueue(attachment))
+	return attachment
+}
+
+// TestTransientFailureIsRetried tests that a job failing on storage is
+// retried until the image is stripped of its metadata
+func (suite *ThumbnailWorkerTestSuite) TestTransientFailureIsRetried() {
+	suite.blobStore.failures = 1
+	original := jpegWithEXIF(testImage(64, 48), 1)
+	attachment := suite.upload("image/jpeg", original)
+
+	assert.Eventually(suite.T(), func() bool {
+		processed, err := suite.attachments.FindByID(suite.ctx, attachment.ID)
+		return err == nil && processed.ProcessedAt != nil
+	}, time.Second, 5*time.Millisecond)
+
+	reader, err := suite.blobStore.Get(suite.ctx, attachment.StorageKey)
+	suite.Require().NoError(err)
+	defer reader.Close()
+	stripped, err := io.ReadAll(reader)
+	suite.Require().NoError(err)
+	assert.NotEqual(suite.T(), original, stripped)
+	assert.Equal(suite.T(), 0, suite.queue.Len("attachment_processing.dead"))
+}
+
+// TestInvalidImageIsDeadLettered tests that an image that cannot be decoded
+// is dead-lettered without being retried
+func (suite *ThumbnailWorkerTestSuite) TestInvalidImageIsDeadLettered() {
+	attachment := suite.upload("image/png", []byte("not an image"))
+
+	assert.Eventually(suite.T(), func() bool {
+		return suite.queue.Len("attachment_processing.dead") == 1
+	}, time.Second, 5*time.Millisecond)
+
+	unprocessed, err := suite.attachments.FindByID(suite.ctx, attachment.ID)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), unprocessed.ProcessedAt)
+}
+
+func TestThumbnailWorkerSuite(t *testing.T) {
+	suite.Run(t, new(ThumbnailWorkerTestSuite))
+}
@@ -0,0 +1,173 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/unfurl"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const previewPage = `<!DOCTYPE html>
+<html><head>
+<title>Fallback   title</title>
+<meta property="og:title" content="Quarterly Results">
+<meta property="og:description" content="Revenue is up.">
+<meta property="og:image" content="/images/cover.png">
+<meta property="og:site_name" content="Example News">
+</head><body><p>ignored</p></body></html>`
+
+// UnfurlTestSuite contains the test suite for link unfurling
+type UnfurlTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	requests int32
+}
+
+func (suite *UnfurlTestSuite) SetupTest() {
+	atomic.StoreInt32(&suite.requests, 0)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&suite.requests, 1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, previewPage)
+	})
+	mux.HandleFunc("/title-only", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><head><title> Just a title </title></head></html>")
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG"))
+	})
+	mux.HandleFunc("/huge", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><head>"+strings.Repeat("<!-- padding -->", 10000)+"<title>Too far</title></head></html>")
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, previewPage)
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&suite.requests, 1)
+		http.NotFound(w, r)
+	})
+	suite.server = httptest.NewServer(mux)
+}
+
+func (suite *UnfurlTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+// newFetcher creates a fetcher that may reach the local test server
+func (suite *UnfurlTestSuite) newFetcher(options unfurl.FetcherOptions) *unfurl.Fetcher {
+	options.AllowedNetworks = append(options.AllowedNetworks, "127.0.0.1/32", "::1/128")
+	fetcher, err := unfurl.NewFetcher(options)
+	suite.Require().NoError(err)
+	return fetcher
+}
+
+// TestExtractURLs tests URL detection in message content
+func (suite *UnfurlTestSuite) TestExtractURLs() {
+	urls := unfurl.ExtractURLs("see https://example.com/a, and (http://example.org/b). again https://example.com/a ftp://nope x https://c.io https://d.io")
+
+	assert.Equal(suite.T(), []string{"https://example.com/a", "http://example.org/b", "https://c.io"}, urls)
+	assert.Empty(suite.T(), unfurl.ExtractURLs("no links here"))
+}
+
+// TestFetchOpenGraph tests that OpenGraph metadata is extracted
+func (suite *UnfurlTestSuite) TestFetchOpenGraph() {
+	fetcher := suite.newFetcher(unfurl.FetcherOptions{})
+
+	preview, err := fetcher.Fetch(context.Background(), suite.server.URL+"/article")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Quarterly Results", preview.Title)
+	assert.Equal(suite.T(), "Revenue is up.", preview.Description)
+	assert.Equal(suite.T(), suite.server.URL+"/images/cover.png", preview.ImageURL)
+	assert.Equal(suite.T(), "Example News", preview.SiteName)
+}
+
+// TestFetchTitleFallback tests that <title> is used without OpenGraph tags
+func (suite *UnfurlTestSuite) TestFetchTitleFallback() {
+	fetcher := suite.newFetcher(unfurl.FetcherOptions{})
+
+	preview, err := fetcher.Fetch(context.Background(), suite.server.URL+"/title-only")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Just a title", preview.Title)
+}
+
+// TestFetchBlocksPrivateAddresses tests SSRF protection without an allow-list
+func (suite *UnfurlTestSuite) TestFetchBlocksPrivateAddresses() {
+	fetcher, err := unfurl.NewFetcher(unfurl.FetcherOptions{})
+	suite.Require().NoError(err)
+
+	_, err = fetcher.Fetch(context.Background(), suite.server.URL+"/article")
+
+	assert.ErrorIs(suite.T(), err, unfurl.ErrBlockedAddress)
+
+	for _, target := range []string{"http://169.254.169.254/latest/meta-data", "http://10.0.0.1/", "http://[::1]/"} {
+		_, err = fetcher.Fetch(context.Background(), target)
+		assert.ErrorIs(suite.T(), err, unfurl.ErrBlockedAddress, target)
+	}
+}
+
+// TestFetchRejectsNonHTML tests that non-HTML responses are not parsed
+func (suite *UnfurlTestSuite) TestFetchRejectsNonHTML() {
+	fetcher := suite.newFetcher(unfurl.FetcherOptions{})
+
+	_, err := fetcher.Fetch(context.Background(), suite.server.URL+"/image.png")
+
+	assert.Equal(suite.T(), unfurl.ErrNotHTML, err)
+}
+
+// TestFetchSizeCap tests that only the first MaxBytes of a page are read
+func (suite *UnfurlTestSuite) TestFetchSizeCap() {
+	fetcher := suite.newFetcher(unfurl.FetcherOptions{MaxBytes: 1024})
+
+	_, err := fetcher.Fetch(context.Background(), suite.server.URL+"/huge")
+
+	assert.Error(suite.T(), err)
+}
+
+// TestFetchTimeout tests that slow servers are abandoned
+func (suite *UnfurlTestSuite) TestFetchTimeout() {
+	fetcher := suite.newFetcher(unfurl.FetcherOptions{Timeout: 100 * time.Millisecond})
+
+	_, err := fetcher.Fetch(context.Background(), suite.server.URL+"/slow")
+
+	assert.Error(suite.T(), err)
+}
+
+// TestCachingFetcher tests that previews and failures are cached
+func (suite *UnfurlTestSuite) TestCachingFetcher() {
+	cache := unfurl.NewCachingFetcher(suite.newFetcher(unfurl.FetcherOptions{}), time.Minute, 10)
+
+	for i := 0; i < 3; i++ {
+		preview, err := cache.Fetch(context.Background(), suite.server.URL+"/article")
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), "Quarterly Results", preview.Title)
+
+		_, err = cache.Fetch(context.Background(), suite.server.URL+"/missing")
+		assert.Error(suite.T(), err)
+	}
+
+	// One request for the article, one for the missing page
+	assert.Equal(suite.T(), int32(2), atomic.LoadInt32(&suite.requests))
+}
+
+// TestUnfurlSuite runs the test suite
+func TestUnfurlSuite(t *testing.T) {
+	suite.Run(t, new(UnfurlTestSuite))
+}
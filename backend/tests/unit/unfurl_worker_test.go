@@ -0,0 +1,207 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/broker"
+	"jobsity-backend/internal/repository"
+	"jobsity-backend/internal/service"
+	"jobsity-backend/pkg/domain"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakePreviewFetcher returns a preview titled after each URL, failing for
+// URLs in failing, and calls beforeReturn before it returns
+type fakePreviewFetcher struct {
+	failing      map[string]bool
+	beforeReturn func()
+}
+
+func (f *fakePreviewFetcher) Fetch(ctx context.Context, rawURL string) (*domain.LinkPreview, error) {
+	if f.beforeReturn != nil {
+		f.beforeReturn()
+	}
+	if f.failing[rawURL] {
+		return nil, errors.New("unreachable")
+	}
+	return &domain.LinkPreview{URL: rawURL, Title: rawURL}, nil
+}
+
+// UnfurlWorkerTestSuite contains the test suite for the link unfurl worker
+type UnfurlWorkerTestSuite struct {
+	suite.Suite
+	ctx      context.Context
+	queue    *broker.MemoryBroker
+	messages *repository.MemoryMessageRepository
+	fetcher  *fakePreviewFetcher
+	worker   *service.UnfurlWorker
+	events   []map[string]interface{}
+}
+
+// SetupTest creates a worker over an in-memory message repository before each test
+func (suite *UnfurlWorkerTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.messages = repository.NewMemoryMessageRepository()
+	suite.fetcher = &fakePreviewFetcher{failing: make(map[string]bool)}
+	suite.events = nil
+	suite.queue = broker.NewMemoryBroker()
+
+	var err error
+	suite.worker, err = service.NewUnfurlWorker(suite.queue, suite.messages, suite.fetcher, func(channelID string, messageType string, data interface{}) {
+		suite.events = append(suite.events, toMap(data))
+	})
+	suite.Require().NoError(err)
+}
+
+// TearDownTest closes the broker after each test
+func (suite *UnfurlWorkerTestSuite) TearDownTest() {
+	suite.queue.Close()
+}
+
+// message stores a message with content and previews
+func (suite *UnfurlWorkerTestSuite) message(content string, previews ...*domain.LinkPreview) *domain.Message {
+	message := &domain.Message{ChannelID: "channel-1", UserEmail: "user1@jobsity.com", AuthorType: domain.AuthorUser, Content: content}
+	suite.Require().NoError(suite.messages.Create(suite.ctx, message))
+	if len(previews) > 0 {
+		suite.Require().NoError(suite.messages.UpdatePreviews(suite.ctx, message.ID, content, previews))
+	}
+	return message
+}
+
+// job returns the job queued for message as it is now
+func (suite *UnfurlWorkerTestSuite) job(message *domain.Message, urls ...string) *service.LinkUnfurlJob {
+	return &service.LinkUnfurlJob{
+		MessageID:   message.ID,
+		ChannelID:   message.ChannelID,
+		URLs:        urls,
+		ContentHash: service.ContentHash(message.Content),
+	}
+}
+
+// previews returns the stored previews of message
+func (suite *UnfurlWorkerTestSuite) previews(message *domain.Message) []*domain.LinkPreview {
+	found, err := suite.messages.FindByID(suite.ctx, message.ID)
+	suite.Require().NoError(err)
+	return found.Previews
+}
+
+// edit replaces the content of message
+func (suite *UnfurlWorkerTestSuite) edit(message *domain.Message, content string) {
+	edited := *message
+	edited.Content = content
+	suite.Require().NoError(suite.messages.Update(suite.ctx, &edited))
+	message.Content = content
+}
+
+// TestStoresPreviews tests that fetched previews are stored and announced
+func (suite *UnfurlWorkerTestSuite) TestStoresPreviews() {
+	message := suite.message("see https://a.example")
+
+	suite.Require().NoError(suite.worker.ProcessJob(suite.ctx, suite.job(message, "https://a.example")))
+
+	suite.Require().Len(suite.previews(message), 1)
+	assert.Equal(suite.T(), "https://a.example", suite.previews(message)[0].URL)
+	suite.Require().Len(suite.events, 1)
+	assert.Len(suite.T(), suite.events[0]["previews"], 1)
+}
+
+// TestClearsPreviewsOfRemovedLinks tests that editing every link out of a
+// message clears its previews
+func (suite *UnfurlWorkerTestSuite) TestClearsPreviewsOfRemovedLinks() {
+	message := suite.message("see https://a.example", &domain.LinkPreview{URL: "https://a.example"})
+	suite.edit(message, "no links now")
+
+	suite.Require().NoError(suite.worker.ProcessJob(suite.ctx, suite.job(message)))
+
+	assert.Empty(suite.T(), suite.previews(message))
+	suite.Require().Len(suite.events, 1)
+	assert.Equal(suite.T(), []interface{}{}, suite.events[0]["previews"])
+}
+
+// TestClearsPreviewsWhenFetchesFail tests that previews of an edited
+// message are cleared when none of its links can be previewed
+func (suite *UnfurlWorkerTestSuite) TestClearsPreviewsWhenFetchesFail() {
+	message := suite.message("see https://a.example", &domain.LinkPreview{URL: "https://a.example"})
+	suite.edit(message, "see https://b.example")
+	suite.fetcher.failing["https://b.example"] = true
+
+	suite.Require().NoError(suite.worker.ProcessJob(suite.ctx, suite.job(message, "https://b.example")))
+
+	assert.Empty(suite.T(), suite.previews(message))
+	assert.Len(suite.T(), suite.events, 1)
+}
+
+// TestSkipsJobsForEditedMessages tests that a job queued before an edit
+// does not replace the previews of the edited message
+func (suite *UnfurlWorkerTestSuite) TestSkipsJobsForEditedMessages() {
+	message := suite.message("see https://old.example")
+	stale := suite.job(message, "https://old.example")
+	suite.edit(message, "see https://new.example")
+	suite.Require().NoError(suite.worker.ProcessJob(suite.ctx, suite.job(message, "https://new.example")))
+
+	suite.Require().NoError(suite.worker.ProcessJob(suite.ctx, stale))
+
+	suite.Require().Len(suite.previews(message), 1)
+	assert.Equal(suite.T(), "https://new.example", suite.previews(message)[0].URL)
+	assert.Len(suite.T(), suite.events, 1)
+}
+
+// TestSkipsMessagesEditedDuringFetch tests that previews fetched while the
+// message was edited are not stored
+func (suite *UnfurlWorkerTestSuite) TestSkipsMessagesEditedDuringFetch() {
+	message := suite.message("see https://old.example")
+	job := suite.job(message, "https://old.example")
+	suite.fetcher.beforeReturn = func() { suite.edit(message, "see https://new.example") }
+
+	suite.Require().NoError(suite.worker.ProcessJob(suite.ctx, job))
+
+	assert.Empty(suite.T(), suite.previews(message))
+	assert.Empty(suite.T(), suite.events)
+}
+
+// TestEnqueue tests that only messages with links or previews are queued
+func (suite *UnfurlWorkerTestSuite) TestEnqueue() {
+	jobs := make(chan service.LinkUnfurlJob, 3)
+	suite.queue.Consume("link_unfurls", 1, func(delivery amqp.Delivery) {
+		var job service.LinkUnfurlJob
+		json.Unmarshal(delivery.Body, &job)
+		jobs <- job
+		delivery.Ack(false)
+	})
+
+	plain := &domain.Message{ID: "m1", Content: "no links"}
+	linked := &domain.Message{ID: "m2", Content: "see https://a.example"}
+	unlinked := &domain.Message{ID: "m3", Content: "links removed", Previews: []*domain.LinkPreview{{URL: "https://a.example"}}}
+	for _, message := range []*domain.Message{plain, linked, unlinked} {
+		suite.Require().NoError(suite.worker.Enqueue(message))
+	}
+
+	receive := func() service.LinkUnfurlJob {
+		select {
+		case job := <-jobs:
+			return job
+		case <-time.After(time.Second):
+			suite.FailNow("no job queued")
+			return service.LinkUnfurlJob{}
+		}
+	}
+	job := receive()
+	assert.Equal(suite.T(), "m2", job.MessageID)
+	assert.Equal(suite.T(), []string{"https://a.example"}, job.URLs)
+	assert.Equal(suite.T(), service.ContentHash(linked.Content), job.ContentHash)
+
+	job = receive()
+	assert.Equal(suite.T(), "m3", job.MessageID)
+	assert.Empty(suite.T(), job.URLs)
+}
+
+func TestUnfurlWorkerSuite(t *testing.T) {
+	suite.Run(t, new(UnfurlWorkerTestSuite))
+}
@@ -0,0 +1,217 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/commands"
+	"jobsity-backend/internal/quotes"
+	"jobsity-backend/internal/service"
+	"jobsity-backend/pkg/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// MockWatchlistRepository is a mock implementation of WatchlistRepository
+type MockWatchlistRepository struct {
+	mock.Mock
+}
+
+func (m *MockWatchlistRepository) FindByChannel(ctx context.Context, channelID string) (*domain.Watchlist, error) {
+	args := m.Called(ctx, channelID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Watchlist), args.Error(1)
+}
+
+func (m *MockWatchlistRepository) Save(ctx context.Context, watchlist *domain.Watchlist) error {
+	args := m.Called(ctx, watchlist)
+	return args.Error(0)
+}
+
+func (m *MockWatchlistRepository) FindDue(ctx context.Context, now time.Time) ([]*domain.Watchlist, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]*domain.Watchlist), args.Error(1)
+}
+
+func (m *MockWatchlistRepository) ClaimRun(ctx context.Context, id string, scheduledAt, ranAt, nextRunAt time.Time) (bool, error) {
+	args := m.Called(ctx, id, scheduledAt, ranAt, nextRunAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWatchlistRepository) Delete(ctx context.Context, channelID string) error {
+	args := m.Called(ctx, channelID)
+	return args.Error(0)
+}
+
+// WatchlistTestSuite contains the test suite for channel watchlists
+type WatchlistTestSuite struct {
+	suite.Suite
+	mockRepo  *MockWatchlistRepository
+	registry  *commands.Registry
+	provider  *quotes.FakeProvider
+	publisher *fakePublisher
+	scheduler *service.WatchlistScheduler
+}
+
+// SetupTest sets up the watch commands and digest scheduler before each test
+func (suite *WatchlistTestSuite) SetupTest() {
+	suite.mockRepo = new(MockWatchlistRepository)
+	watchlistService, err := service.NewWatchlistService(suite.mockRepo, "0 21 * * 1-5", 3)
+	suite.Require().NoError(err)
+	suite.registry = commands.NewRegistry()
+	suite.registry.MustRegister(service.NewWatchCommand(watchlistService))
+	suite.registry.MustRegister(service.NewUnwatchCommand(watchlistService))
+
+	suite.provider = quotes.NewFakeProvider("fake")
+	suite.publisher = &fakePublisher{}
+	suite.scheduler = service.NewWatchlistScheduler(suite.mockRepo, suite.provider, suite.publisher, time.Minute, time.Hour)
+}
+
+// TestWatchCreatesWatchlist tests that /watch creates a watchlist with the default schedule
+func (suite *WatchlistTestSuite) TestWatchCreatesWatchlist() {
+	suite.mockRepo.On("FindByChannel", mock.Anything, "channel-1").Return(nil, domain.ErrNotFound)
+	suite.mockRepo.On("Save", mock.Anything, mock.MatchedBy(func(watchlist *domain.Watchlist) bool {
+		return watchlist.ChannelID == "channel-1" && watchlist.Schedule == "0 21 * * 1-5" &&
+			assert.ObjectsAreEqual([]string{"aapl.us", "msft.us"}, watchlist.StockCodes) &&
+			watchlist.NextRunAt.After(time.Now()) && watchlist.NextRunAt.Hour() == 21
+	})).Return(nil)
+
+	result, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/watch aapl.us MSFT.US")
+
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), result.Message, "This channel watches AAPL.US, MSFT.US")
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+// TestWatchLimit tests that watchlists cannot grow past the symbol limit
+func (suite *WatchlistTestSuite) TestWatchLimit() {
+	suite.mockRepo.On("FindByChannel", mock.Anything, "channel-1").Return(&domain.Watchlist{
+		ChannelID:  "channel-1",
+		StockCodes: []string{"a.us", "b.us", "c.us"},
+		Schedule:   "0 21 * * 1-5",
+	}, nil)
+
+	result, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/watch=b.us,d.us")
+
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), result.Message, "watchlist is full")
+	suite.mockRepo.AssertNotCalled(suite.T(), "Save", mock.Anything, mock.Anything)
+}
+
+// TestWatchSchedule tests changing the digest schedule
+func (suite *WatchlistTestSuite) TestWatchSchedule() {
+	suite.mockRepo.On("FindByChannel", mock.Anything, "channel-1").Return(&domain.Watchlist{
+		ChannelID:  "channel-1",
+		StockCodes: []string{"aapl.us"},
+		Schedule:   "0 21 * * 1-5",
+	}, nil)
+	suite.mockRepo.On("Save", mock.Anything, mock.MatchedBy(func(watchlist *domain.Watchlist) bool {
+		return watchlist.Schedule == "30 14 * * *" && watchlist.NextRunAt.Minute() == 30
+	})).Return(nil)
+
+	_, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/watch schedule 30 14 * * *")
+	assert.NoError(suite.T(), err)
+
+	for _, content := range []string{"/watch schedule 61 * * * *", "/watch schedule 0 0 30 2 *", "/watch schedule"} {
+		_, err = suite.registry.Execute(context.Background(), "channel-1", "user@example.com", content)
+		var usageErr *commands.UsageError
+		assert.ErrorAs(suite.T(), err, &usageErr, content)
+	}
+	suite.mockRepo.AssertNumberOfCalls(suite.T(), "Save", 1)
+}
+
+// TestUnwatchDeletesEmptyWatchlist tests that removing the last stock deletes the watchlist
+func (suite *WatchlistTestSuite) TestUnwatchDeletesEmptyWatchlist() {
+	suite.mockRepo.On("FindByChannel", mock.Anything, "channel-1").Return(&domain.Watchlist{
+		ChannelID:  "channel-1",
+		StockCodes: []string{"aapl.us"},
+		Schedule:   "0 21 * * 1-5",
+	}, nil)
+	suite.mockRepo.On("Delete", mock.Anything, "channel-1").Return(nil)
+
+	result, err := suite.registry.Execute(context.Background(), "channel-1", "user@example.com", "/unwatch AAPL.US")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "This channel no longer watches any stocks", result.Message)
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+// TestRunDuePostsDigest tests that a due digest is posted and rescheduled
+func (suite *WatchlistTestSuite) TestRunDuePostsDigest() {
+	scheduledAt := time.Date(2024, 1, 5, 21, 0, 0, 0, time.UTC)
+	now := scheduledAt.Add(20 * time.Second)
+	suite.provider.Set(&quotes.Quote{Symbol: "AAPL.US", Open: 200, Close: 210})
+	suite.mockRepo.On("FindDue", mock.Anything, now).Return([]*domain.Watchlist{{
+		ID:         "w1",
+		ChannelID:  "channel-1",
+		StockCodes: []string{"aapl.us", "nope.us"},
+		Schedule:   "0 21 * * 1-5",
+		NextRunAt:  scheduledAt,
+	}}, nil)
+	// The next weekday run after Friday is Monday
+	suite.mockRepo.On("ClaimRun", mock.Anything, "w1", scheduledAt, now, time.Date(2024, 1, 8, 21, 0, 0, 0, time.UTC)).Return(true, nil)
+
+	posted, err := suite.scheduler.RunDue(context.Background(), now)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, posted)
+	assert.Len(suite.T(), suite.publisher.published, 1)
+
+	response, err := service.DecodeStockResponse(suite.publisher.published[0].msg.Body)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "channel-1", response.ChannelID)
+	assert.Equal(suite.T(), "Watchlist digest for 2024-01-05 21:00 UTC\n"+
+		"AAPL.US: open $200.00, close $210.00, change +$10.00 (+5.00%)\n"+
+		"NOPE.US: Error fetching stock data", response.Message)
+}
+
+// TestRunDueCatchUp tests that recently missed digests are posted late and older ones skipped
+func (suite *WatchlistTestSuite) TestRunDueCatchUp() {
+	now := time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)
+	recent := &domain.Watchlist{ID: "w1", ChannelID: "channel-1", StockCodes: []string{"aapl.us"}, Schedule: "30 8 * * *", NextRunAt: now.Add(-30 * time.Minute)}
+	stale := &domain.Watchlist{ID: "w2", ChannelID: "channel-2", StockCodes: []string{"aapl.us"}, Schedule: "0 21 * * 1-5", NextRunAt: now.Add(-12 * time.Hour)}
+	suite.provider.Set(&quotes.Quote{Symbol: "AAPL.US", Close: 210})
+	suite.mockRepo.On("FindDue", mock.Anything, now).Return([]*domain.Watchlist{recent, stale}, nil)
+	suite.mockRepo.On("ClaimRun", mock.Anything, mock.Anything, mock.Anything, now, mock.Anything).Return(true, nil)
+
+	posted, err := suite.scheduler.RunDue(context.Background(), now)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, posted)
+	assert.Len(suite.T(), suite.publisher.published, 1)
+
+	response, err := service.DecodeStockResponse(suite.publisher.published[0].msg.Body)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "channel-1", response.ChannelID)
+	assert.Equal(suite.T(), "Watchlist digest for 2024-01-08 08:30 UTC (delayed by 30 min)\nAAPL.US: close $210.00", response.Message)
+
+	// Both runs are moved to their next occurrence
+	suite.mockRepo.AssertCalled(suite.T(), "ClaimRun", mock.Anything, "w1", recent.NextRunAt, now, time.Date(2024, 1, 9, 8, 30, 0, 0, time.UTC))
+	suite.mockRepo.AssertCalled(suite.T(), "ClaimRun", mock.Anything, "w2", stale.NextRunAt, now, time.Date(2024, 1, 8, 21, 0, 0, 0, time.UTC))
+}
+
+// TestRunDueClaimedElsewhere tests that a run claimed by another scheduler is not posted twice
+func (suite *WatchlistTestSuite) TestRunDueClaimedElsewhere() {
+	now := time.Date(2024, 1, 5, 21, 0, 10, 0, time.UTC)
+	suite.mockRepo.On("FindDue", mock.Anything, now).Return([]*domain.Watchlist{{
+		ID: "w1", ChannelID: "channel-1", StockCodes: []string{"aapl.us"}, Schedule: "0 21 * * 1-5", NextRunAt: now.Truncate(time.Minute),
+	}}, nil)
+	suite.mockRepo.On("ClaimRun", mock.Anything, "w1", mock.Anything, now, mock.Anything).Return(false, nil)
+
+	posted, err := suite.scheduler.RunDue(context.Background(), now)
+
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), posted)
+	assert.Empty(suite.T(), suite.publisher.published)
+	assert.Zero(suite.T(), suite.provider.Calls("aapl.us"))
+}
+
+// TestWatchlistSuite runs the watchlist test suite
+func TestWatchlistSuite(t *testing.T) {
+	suite.Run(t, new(WatchlistTestSuite))
+}
@@ -0,0 +1,107 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/websocket"
+
+	wsclient "github.com/fasthttp/websocket"
+	fiberws "github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// WebSocketHubTestSuite contains the test suite for delivering messages
+// through the WebSocket hub
+type WebSocketHubTestSuite struct {
+	suite.Suite
+	hub *websocket.Hub
+	app *fiber.App
+	url string
+}
+
+// SetupTest serves the WebSocket handler on a free port before each test
+func (suite *WebSocketHubTestSuite) SetupTest() {
+	suite.hub = websocket.NewHub()
+	go suite.hub.Run()
+	handler := websocket.NewHandler(suite.hub, nil, nil)
+
+	suite.app = fiber.New(fiber.Config{DisableStartupMessage: true})
+	suite.app.Get("/ws", fiberws.New(handler.HandleWebSocket))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	go suite.app.Listener(listener)
+	suite.url = "ws://" + listener.Addr().String() + "/ws"
+}
+
+// TearDownTest stops the server and the hub after each test
+func (suite *WebSocketHubTestSuite) TearDownTest() {
+	suite.app.Shutdown()
+	suite.hub.Stop()
+}
+
+// connect opens a connection for userEmail in channelID
+func (suite *WebSocketHubTestSuite) connect(channelID, userEmail string) *wsclient.Conn {
+	conn, _, err := wsclient.DefaultDialer.Dial(suite.url+"?channel_id="+channelID+"&user_email="+userEmail, nil)
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// TestSlowClientsAreDisconnected tests that a client that stops reading is
+// disconnected while messages are broadcast and sent to it concurrently, and
+// that other clients keep receiving messages
+func (suite *WebSocketHubTestSuite) TestSlowClientsAreDisconnected() {
+	suite.connect("channel-1", "slow@jobsity.com")
+	fast := suite.connect("channel-2", "fast@jobsity.com")
+	suite.Require().Eventually(func() bool { return suite.hub.GetClientCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	// The fast client keeps reading until its last message
+	received := make(chan struct{})
+	go func() {
+		defer close(received)
+		for {
+			_, data, err := fast.ReadMessage()
+			if err != nil || bytes.Contains(data, []byte(`"last"`)) {
+				return
+			}
+		}
+	}()
+
+	// Large messages fill the slow client's socket and then its send buffer
+	payload, _ := json.Marshal(map[string]string{"type": "message", "content": string(bytes.Repeat([]byte("x"), 64*1024))})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				suite.hub.BroadcastToChannel("channel-1", payload)
+				suite.hub.SendToUser("channel-1", "slow@jobsity.com", payload)
+			}
+		}()
+	}
+	wg.Wait()
+
+	suite.Require().Eventually(func() bool { return suite.hub.GetClientCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(suite.T(), 0, suite.hub.GetChannelClientCount("channel-1"))
+	assert.Equal(suite.T(), 0, suite.hub.SendToUser("channel-1", "slow@jobsity.com", payload))
+
+	assert.Equal(suite.T(), 1, suite.hub.SendToUser("channel-2", "fast@jobsity.com", []byte(`{"type":"last"}`)))
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		suite.Fail("fast client stopped receiving")
+	}
+}
+
+func TestWebSocketHubSuite(t *testing.T) {
+	suite.Run(t, new(WebSocketHubTestSuite))
+}
@@ -0,0 +1,151 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"jobsity-backend/internal/websocket"
+
+	wsclient "github.com/fasthttp/websocket"
+	fiberws "github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// WebSocketShutdownTestSuite contains the test suite for shutting down
+// WebSocket connections
+type WebSocketShutdownTestSuite struct {
+	suite.Suite
+	hub     *websocket.Hub
+	handler *websocket.Handler
+	app     *fiber.App
+	url     string
+	stopped bool
+}
+
+// SetupTest serves the WebSocket handler on a free port before each test
+func (suite *WebSocketShutdownTestSuite) SetupTest() {
+	suite.hub = websocket.NewHub()
+	go suite.hub.Run()
+	suite.handler = websocket.NewHandler(suite.hub, nil, nil)
+
+	suite.stopped = false
+
+	suite.app = fiber.New(fiber.Config{DisableStartupMessage: true})
+	suite.app.Get("/ws", fiberws.New(suite.handler.HandleWebSocket))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	go suite.app.Listener(listener)
+	suite.url = "ws://" + listener.Addr().String() + "/ws?user_email=user1@jobsity.com"
+}
+
+// TearDownTest stops the server and the hub after each test
+func (suite *WebSocketShutdownTestSuite) TearDownTest() {
+	suite.app.Shutdown()
+	if !suite.stopped {
+		suite.hub.Stop()
+	}
+}
+
+// connect opens a connection and reads its welcome message
+func (suite *WebSocketShutdownTestSuite) connect() *wsclient.Conn {
+	conn, _, err := wsclient.DefaultDialer.Dial(suite.url, nil)
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { conn.Close() })
+
+	assert.Equal(suite.T(), "connected", suite.read(conn)["type"])
+	return conn
+}
+
+// read returns the next message sent to conn
+func (suite *WebSocketShutdownTestSuite) read(conn *wsclient.Conn) map[string]interface{} {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, data, err := conn.ReadMessage()
+	suite.Require().NoError(err)
+
+	var message map[string]interface{}
+	suite.Require().NoError(json.Unmarshal(data, &message))
+	return message
+}
+
+// assertGoingAway asserts that the server closes conn as going away
+func (suite *WebSocketShutdownTestSuite) assertGoingAway(conn *wsclient.Conn) {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := conn.ReadMessage()
+	assert.True(suite.T(), wsclient.IsCloseError(err, wsclient.CloseGoingAway), "unexpected error: %v", err)
+}
+
+// TestShutdownTellsClientsToReconnect tests that every client is sent the
+// reconnect hint before its connection is closed
+func (suite *WebSocketShutdownTestSuite) TestShutdownTellsClientsToReconnect() {
+	first := suite.connect()
+	second := suite.connect()
+	suite.Require().Eventually(func() bool { return suite.hub.GetClientCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- suite.handler.Shutdown(ctx, 3*time.Second) }()
+
+	for _, conn := range []*wsclient.Conn{first, second} {
+		message := suite.read(conn)
+		assert.Equal(suite.T(), "server_shutdown", message["type"])
+		assert.Equal(suite.T(), map[string]interface{}{"reconnect_after_ms": float64(3000)}, message["data"])
+		suite.assertGoingAway(conn)
+	}
+
+	assert.NoError(suite.T(), <-done)
+	assert.Equal(suite.T(), 0, suite.hub.GetClientCount())
+}
+
+// TestLateClientsAreSentAway tests that clients connecting during shutdown
+// are told to reconnect too
+func (suite *WebSocketShutdownTestSuite) TestLateClientsAreSentAway() {
+	suite.Require().NoError(suite.handler.Shutdown(context.Background(), time.Second))
+
+	conn, _, err := wsclient.DefaultDialer.Dial(suite.url, nil)
+	suite.Require().NoError(err)
+	defer conn.Close()
+
+	// Queued messages can arrive together, one per line
+	var types []interface{}
+	for {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			assert.True(suite.T(), wsclient.IsCloseError(err, wsclient.CloseGoingAway), "unexpected error: %v", err)
+			break
+		}
+		for _, line := range bytes.Split(data, []byte{'\n'}) {
+			var message map[string]interface{}
+			suite.Require().NoError(json.Unmarshal(line, &message))
+			types = append(types, message["type"])
+		}
+	}
+	assert.Contains(suite.T(), types, "server_shutdown")
+}
+
+// TestShutdownTimesOut tests that Shutdown gives up on clients that do not
+// disconnect in time
+func (suite *WebSocketShutdownTestSuite) TestShutdownTimesOut() {
+	suite.connect()
+	suite.Require().Eventually(func() bool { return suite.hub.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	// The connection cannot be unregistered while the hub is stopped
+	suite.hub.Stop()
+	suite.stopped = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(suite.T(), suite.handler.Shutdown(ctx, time.Second), context.DeadlineExceeded)
+}
+
+func TestWebSocketShutdownSuite(t *testing.T) {
+	suite.Run(t, new(WebSocketShutdownTestSuite))
+}
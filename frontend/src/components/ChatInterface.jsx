@@ -9,6 +9,12 @@ const ChatInterface = ({ channel, user, onConnectionChange }) => {
   const [loading, setLoading] = useState(false);
   const messagesEndRef = useRef(null);
   const wsRef = useRef(null);
+  const reconnectTimerRef = useRef(null);
+
+  const cancelReconnect = () => {
+    clearTimeout(reconnectTimerRef.current);
+    reconnectTimerRef.current = null;
+  };
 
   const scrollToBottom = () => {
     messagesEndRef.current?.scrollIntoView({ behavior: "smooth" });
@@ -21,6 +27,7 @@ const ChatInterface = ({ channel, user, onConnectionChange }) => {
   // Cleanup on component unmount
   useEffect(() => {
     return () => {
+      cancelReconnect();
       if (wsRef.current) {
         console.log("Component unmounting, closing WebSocket connection");
         wsRef.current.close();
@@ -48,6 +55,7 @@ const ChatInterface = ({ channel, user, onConnectionChange }) => {
     connectWebSocket();
 
     return () => {
+      cancelReconnect();
       if (wsRef.current) {
         wsRef.current.close();
         wsRef.current = null;
@@ -133,8 +141,13 @@ const ChatInterface = ({ channel, user, onConnectionChange }) => {
   const handleWebSocketMessage = (message) => {
     switch (message.type) {
       case "new_message":
-        // Add new message to the end since backend returns oldest first
-        setMessages((prev) => [...prev, message.data]);
+        // Add new message to the end since backend returns oldest first.
+        // Events can be delivered more than once, so skip known messages.
+        setMessages((prev) =>
+          prev.some((msg) => msg.id === message.data.id)
+            ? prev
+            : [...prev, message.data]
+        );
         break;
       case "message_updated":
         setMessages((prev) =>
@@ -144,6 +157,19 @@ const ChatInterface = ({ channel, user, onConnectionChange }) => {
       case "message_deleted":
         setMessages((prev) => prev.filter((msg) => msg.id !== message.data.id));
         break;
+      case "server_shutdown": {
+        // The server is restarting. Reconnect after the delay it asks for,
+        // spread out so clients do not all reconnect at once, and reload the
+        // messages sent in the meantime.
+        const delay = message.data?.reconnect_after_ms ?? 2000;
+        cancelReconnect();
+        reconnectTimerRef.current = setTimeout(() => {
+          reconnectTimerRef.current = null;
+          fetchMessages();
+          connectWebSocket();
+        }, delay + Math.random() * delay);
+        break;
+      }
     }
   };
 
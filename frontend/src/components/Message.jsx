@@ -15,7 +15,7 @@ const Message = ({ message, isOwn, showAvatar }) => {
     <div
       className={`message ${isOwn ? "own" : "other"} ${
         showAvatar ? "with-avatar" : "no-avatar"
-      }`}
+      } ${message.ephemeral ? "ephemeral" : ""}`}
     >
       {showAvatar && !isOwn && (
         <div className="message-avatar">{getInitials(message.user_email)}</div>
@@ -27,8 +27,19 @@ const Message = ({ message, isOwn, showAvatar }) => {
         )}
 
         <div className="message-bubble">
-          <div className="message-text">{message.content}</div>
-          <div className="message-time">{formatTime(message.created_at)}</div>
+          {message.content_html ? (
+            // content_html is rendered and sanitized by the server
+            <div
+              className="message-text"
+              dangerouslySetInnerHTML={{ __html: message.content_html }}
+            />
+          ) : (
+            <div className="message-text">{message.content}</div>
+          )}
+          <div className="message-time">
+            {message.ephemeral && "Only visible to you · "}
+            {formatTime(message.created_at)}
+          </div>
         </div>
       </div>
     </div>